@@ -0,0 +1,73 @@
+// cmd/iqfetch/exitcode.go
+package main
+
+import (
+	"errors"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+)
+
+// Process exit codes, documented in the README.
+const (
+	exitOK             = 0
+	exitFailure        = 1 // any other error
+	exitPartialFailure = 2 // report written, but some applications failed
+	exitThreshold      = 2 // count mode: violations at or above FAIL_ON_THREAT
+	exitConfigError    = 3
+	exitAuthError      = 4
+	exitNoApplications = 5
+	exitRunTimeout     = 6 // run deadline fired; a partial report may have been written
+	exitGateThreat     = 7 // count mode: GATE on threat held
+	exitGateCount      = 8 // count mode: GATE on count held
+	exitGateApps       = 9 // count mode: GATE on apps held
+
+	exitNetworkError = 10 // IQ Server unreachable or the connection dropped
+	exitForbidden    = 11 // credentials accepted, but HTTP 403 on a call the run needs
+)
+
+// exitCode maps an error returned by report generation to the process exit code.
+func exitCode(err error) int {
+	var partial *services.PartialFailureError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &partial):
+		return exitPartialFailure
+	case client.IsUnauthorized(err):
+		return exitAuthError
+	case client.IsForbidden(err):
+		return exitForbidden
+	case errors.Is(err, services.ErrUnknownPolicy), errors.Is(err, services.ErrReportIDScope):
+		return exitConfigError
+	case errors.Is(err, services.ErrNoApplications):
+		return exitNoApplications
+	case errors.Is(err, services.ErrRunTimeout), errors.Is(err, client.ErrTimeout):
+		return exitRunTimeout
+	case errors.Is(err, client.ErrNetwork):
+		return exitNetworkError
+	default:
+		return exitFailure
+	}
+}
+
+// gateExitCode returns exitThreshold when counts include a violation with a threat level of
+// at least failOnThreat, and exitOK otherwise or when failOnThreat is 0.
+func gateExitCode(counts *services.Counts, failOnThreat int) int {
+	if failOnThreat > 0 && counts.AtOrAbove(failOnThreat) > 0 {
+		return exitThreshold
+	}
+	return exitOK
+}
+
+// gateCode returns the exit code for a run that failed gate.
+func gateCode(gate *services.Gate) int {
+	switch gate.Metric {
+	case services.GateThreat:
+		return exitGateThreat
+	case services.GateCount:
+		return exitGateCount
+	default:
+		return exitGateApps
+	}
+}
@@ -0,0 +1,97 @@
+// cmd/iqfetch/exitcode_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+)
+
+func TestExitCode(t *testing.T) {
+	unauthorized := &client.APIError{Endpoint: "applications", StatusCode: 401, Message: "401 Unauthorized"}
+	forbidden := &client.APIError{Endpoint: "applications", StatusCode: 403, Message: "403 Forbidden"}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"partial", &services.PartialFailureError{Failures: []services.AppFailure{{PublicID: "a", Err: errors.New("boom")}}, Total: 2}, exitPartialFailure},
+		{"auth", fmt.Errorf("get applications: %w", unauthorized), exitAuthError},
+		{"bad credentials at preflight", fmt.Errorf("%w: %w", client.ErrBadCredentials, unauthorized), exitAuthError},
+		{"forbidden at preflight", fmt.Errorf("%w to list organizations: %w", client.ErrPermissionDenied, forbidden), exitForbidden},
+		{"forbidden listing applications", fmt.Errorf("%w for organization org-1: get applications: %w", client.ErrPermissionDenied, forbidden), exitForbidden},
+		{"server error", fmt.Errorf("get applications: %w", &client.APIError{StatusCode: 500}), exitFailure},
+		{"no applications", services.ErrNoApplications, exitNoApplications},
+		{"unknown policy", fmt.Errorf("%w: Securty-*", services.ErrUnknownPolicy), exitConfigError},
+		{"report ID scope", fmt.Errorf("%w: report r1, 2 applications", services.ErrReportIDScope), exitConfigError},
+		{"run timeout", &services.RunTimeoutError{Timeout: time.Second, Incomplete: 1, Total: 2, Err: context.DeadlineExceeded}, exitRunTimeout},
+		{"request timeout", fmt.Errorf("get applications: %w", &client.APIError{Err: fmt.Errorf("%w: %w", client.ErrTimeout, context.DeadlineExceeded)}), exitRunTimeout},
+		{"network", fmt.Errorf("get applications: %w", &client.APIError{Err: fmt.Errorf("%w: connection reset", client.ErrNetwork)}), exitNetworkError},
+		{"other", errors.New("disk full"), exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateExitCode(t *testing.T) {
+	counts := &services.Counts{Total: 3, ByThreatLevel: map[int]int{3: 2, 8: 1}}
+	tests := []struct {
+		failOnThreat int
+		want         int
+	}{
+		{0, exitOK},
+		{3, 2}, // the documented FAIL_ON_THREAT code
+		{8, 2},
+		{9, exitOK},
+	}
+	for _, tt := range tests {
+		if got := gateExitCode(counts, tt.failOnThreat); got != tt.want {
+			t.Errorf("gateExitCode(FAIL_ON_THREAT=%d) = %d, want %d", tt.failOnThreat, got, tt.want)
+		}
+	}
+}
+
+func TestGateCode(t *testing.T) {
+	// Each gate has its own code, distinct from the one failed applications and FAIL_ON_THREAT share
+	codes := map[int]string{exitPartialFailure: "partial failure or FAIL_ON_THREAT"}
+	for _, expr := range []string{"threat>=8", "count>0", "apps==0"} {
+		gate, err := services.ParseGate(expr)
+		if err != nil {
+			t.Fatalf("ParseGate(%q): %v", expr, err)
+		}
+		code := gateCode(gate)
+		if code == exitOK || codes[code] != "" {
+			t.Errorf("gateCode(%s) = %d, want a distinct non-zero code", expr, code)
+		}
+		codes[code] = expr
+	}
+}
+
+func TestHasPartialOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		result services.Result
+		want   bool
+	}{
+		{"nothing", services.Result{}, false},
+		{"report", services.Result{Path: "reports_output/r.csv"}, true},
+		{"split", services.Result{Paths: []string{"a.csv", "b.csv"}}, true},
+		{"counts", services.Result{Counts: &services.Counts{Total: 1}}, true},
+	}
+	for _, tt := range tests {
+		if got := hasPartialOutput(tt.result); got != tt.want {
+			t.Errorf("%s: hasPartialOutput = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,25 +3,51 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/config"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/upload"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
-	// Load config from config/.env and environment
-	cfg, err := config.Load()
+	os.Exit(run())
+}
+
+// run executes the CLI and returns the process exit code; deferred cleanup runs before exit.
+func run() int {
+	// Flags are handled before config loading so --version works without credentials
+	var showVersion bool
+	var configFile, configJSON string
+	flag.BoolVar(&showVersion, "version", false, "print version information and exit")
+	flag.BoolVar(&showVersion, "v", false, "print version information and exit (shorthand)")
+	flag.StringVar(&configFile, "config", "", "path to the dotenv config file (default $CONFIG_FILE, then config/.env)")
+	flag.StringVar(&configJSON, "config-json", "", "path to a JSON config file; env vars override its values (default $CONFIG_JSON)")
+	flag.Parse()
+	if showVersion {
+		printVersion(os.Stdout)
+		return exitOK
+	}
+
+	// Load config from the environment (plus --config, $CONFIG_FILE or config/.env) over an optional JSON file
+	cfg, err := config.LoadWith(config.LoadOptions{EnvFile: configFile, JSONFile: configJSON})
 	if err != nil {
 		// Log fatal failure to standard error stream, as slog setup hasn't completed yet
 		fmt.Fprintf(os.Stderr, "FATAL: failed to load config: %v\n", err) //nolint:errcheck
-		os.Exit(1)
+		return exitConfigError
 	}
 
 	// Open project-root/app.log for append; create if missing
@@ -29,12 +55,17 @@ func main() {
 	if err != nil {
 		// Log fatal failure to standard error stream, as logger setup hasn't completed yet
 		fmt.Fprintf(os.Stderr, "FATAL: failed to open app.log: %v\n", err) //nolint:errcheck
-		os.Exit(1)
+		return exitFailure
 	}
 	defer logFile.Close()
 
-	// Logger setup (console writer for stdout, json for file)
-	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	// Logger setup (console writer for stdout, json for file).
+	// When the report (or count mode's counts) goes to stdout, console logs move to stderr to keep stdout pure.
+	consoleOut := os.Stdout
+	if cfg.OutputDest == services.OutputDestStdout || cfg.OutputMode == services.OutputModeCount {
+		consoleOut = os.Stderr
+	}
+	consoleWriter := zerolog.ConsoleWriter{Out: consoleOut, TimeFormat: time.RFC3339}
 	multiWriter := zerolog.MultiLevelWriter(consoleWriter, logFile)
 
 	// Configure global logger
@@ -44,45 +75,176 @@ func main() {
 	log.Info().
 		Str("IQServerURL", cfg.IQServerURL).
 		Str("OrganizationID", cfg.OrganizationID).
+		Int("OrganizationIDs", len(cfg.OrganizationIDs)).
 		Msg("Loaded configuration")
 
-	// Build client
-	log.Info().Str("url", cfg.IQServerURL).Msg("Creating IQ client")
-	iqClient, err := client.NewClient(cfg.IQServerURL, cfg.IQUsername, cfg.IQPassword, log.Logger)
-	if err != nil {
-		log.Fatal().Err(err).Msg("failed to create client")
+	opts := cfg.ServiceOptions(log.Logger)
+	if opts.UserAgent == "" {
+		opts.UserAgent = userAgent()
+	}
+	opts.ToolVersion = version
+	if isTerminal(os.Stdout) {
+		opts.Progress = progressBar(os.Stderr)
 	}
-	log.Info().Msg("IQ client created")
-
-	// Service
-	reportService := services.NewIQReportService(cfg, iqClient, log.Logger)
-	log.Info().Str("outputDir", cfg.OutputDir).Msg("Report service initialized")
 
-	// Context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// The run deadline (RUN_TIMEOUT_SECONDS) is applied by the service; requests carry their own HTTP timeout
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	if cfg.ScheduleInterval > 0 {
+		// Scheduled runs repeat until SIGINT or SIGTERM, which also aborts a run in progress
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+
+	// Build client; with IQ_SERVERS the service builds one per instance and an unreachable
+	// instance is reported as a failure rather than stopping the others
+	var iqClient *client.Client
+	if len(opts.Instances) == 0 {
+		log.Info().Str("url", opts.ServerURL).Msg("Creating IQ client")
+		iqClient, err = client.NewClient(opts.ServerURL, opts.Username, opts.Password, log.Logger, opts.ClientOptions()...)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create client")
+			return exitConfigError
+		}
+		log.Info().Msg("IQ client created")
+		defer logRequestStats(iqClient.Stats)
+
+		// Preflight: confirm the server is reachable and the credentials work before fanning out
+		if err := iqClient.Ping(ctx); err != nil {
+			log.Error().Err(err).Str("cause", client.FailureCause(err)).Msg("IQ Server preflight failed")
+			return exitCode(err)
+		}
+		log.Info().Msg("IQ Server preflight succeeded")
+	} else {
+		log.Info().Int("instances", len(opts.Instances)).Msg("Reporting on multiple IQ Servers")
+	}
 
-	// Optional organization filter
-	var orgIDPointer *string
-	if cfg.OrganizationID != "" {
-		orgID := cfg.OrganizationID
-		orgIDPointer = &orgID
+	// Optional upload of the written files (OUTPUT_S3_URI)
+	if cfg.OutputS3URI != "" {
+		uploader, err := upload.NewS3(ctx, cfg.OutputS3URI)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to configure S3 upload")
+			return exitConfigError
+		}
+		opts.Uploader = uploader
 	}
 
-	// Output filename
-	filename := time.Now().Format("2006-01-02_15-04-05") + ".csv"
-	log.Info().Str("filename", filename).Msg("Report filename set")
+	// Service
+	reportService, err := services.NewIQReportService(opts, iqClient)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create report service")
+		return exitConfigError
+	}
+	log.Info().Str("outputDir", cfg.OutputDir).Msg("Report service initialized")
 
 	// Ensure output directory exists
-	_ = os.MkdirAll(cfg.OutputDir, 0o755)
+	if cfg.OutputDest == services.OutputDestFile {
+		_ = os.MkdirAll(cfg.OutputDir, report.Permissions{Dir: opts.OutputDirMode}.DirMode())
+	}
 
-	// Generate report
+	if cfg.ScheduleInterval > 0 {
+		log.Info().Dur("interval", cfg.ScheduleInterval).Msg("Running on a schedule until stopped")
+		return schedule(ctx, cfg.ScheduleInterval, 0, func(ctx context.Context) int {
+			return generateReport(ctx, cfg, reportService)
+		})
+	}
+	return generateReport(ctx, cfg, reportService)
+}
+
+// generateReport runs one report and prints where it was written, returning the exit code.
+func generateReport(ctx context.Context, cfg *config.Config, reportService *services.IQReportService) int {
 	log.Info().Str("orgID", cfg.OrganizationID).Msg("Starting report generation")
-	path, err := reportService.GenerateLatestPolicyReport(ctx, orgIDPointer, filename)
-	if err != nil {
-		log.Fatal().Err(err).Msg("report generation failed")
+	result, err := reportService.GenerateLatestPolicyReport(ctx)
+	var partial *services.PartialFailureError
+	switch {
+	case errors.As(err, &partial):
+		log.Warn().Err(err).Int("appsFailed", len(partial.Failures)).Msg("report generated with failed applications")
+	case errors.Is(err, services.ErrRunTimeout):
+		log.Error().Err(err).Int("exitCode", exitCode(err)).Int("runTimeoutSeconds", cfg.RunTimeoutSeconds).Msg("report generation timed out; raise RUN_TIMEOUT_SECONDS if the server is healthy")
+		if !cfg.WritePartialOnTimeout || !hasPartialOutput(result) {
+			return exitCode(err)
+		}
+	case err != nil:
+		log.Error().Err(err).Str("cause", client.FailureCause(err)).Int("exitCode", exitCode(err)).Msg("report generation failed")
+		return exitCode(err)
+	}
+	log.Info().
+		Int("applications", result.Summary.Applications).
+		Int("appsNoReport", result.Summary.AppsNoReport).
+		Int("appsZeroViolations", result.Summary.AppsZeroViolations).
+		Int("appsAccessDenied", result.Summary.AppsAccessDenied).
+		Int("appsFailed", result.Summary.AppsFailed).
+		Int("totalRows", result.Summary.TotalRows).
+		Int("truncatedRows", result.Summary.TruncatedRows).
+		Int("staleReports", len(result.Summary.StaleReports)).
+		Msg("Report summary")
+
+	if result.Counts != nil {
+		if encErr := printCounts(os.Stdout, result.Counts); encErr != nil {
+			log.Error().Err(encErr).Msg("failed to print counts")
+			return exitFailure
+		}
+		if code := gateExitCode(result.Counts, cfg.FailOnThreat); code != exitOK {
+			log.Error().Int("failOnThreat", cfg.FailOnThreat).Int("violations", result.Counts.AtOrAbove(cfg.FailOnThreat)).Msg("violations at or above the FAIL_ON_THREAT threshold")
+			return code
+		}
+		if gate, gateErr := services.ParseGate(cfg.Gate); gateErr != nil {
+			log.Error().Err(gateErr).Msg("invalid GATE")
+			return exitConfigError
+		} else if gate != nil && gate.Fails(result.Counts, result.Summary) {
+			log.Error().Str("gate", gate.String()).Int(gate.Metric, gate.Measure(result.Counts, result.Summary)).Msg("GATE failed")
+			return gateCode(gate)
+		}
+		log.Info().Msg("Count completed")
+		return exitCode(err)
+	}
+	if cfg.OutputDest == services.OutputDestStdout {
+		log.Info().Msg("Report generation completed")
+		return exitCode(err)
 	}
+	if len(result.Paths) > 0 {
+		log.Info().Strs("paths", result.Paths).Msg("Report generation completed")
+		for _, p := range result.Paths {
+			fmt.Printf("Wrote report: %s\n", filepath.Clean(p))
+		}
+	} else {
+		log.Info().Str("path", filepath.Clean(result.Path)).Msg("Report generation completed")
+		fmt.Printf("Wrote report: %s\n", filepath.Clean(result.Path))
+	}
+	if result.ErrorsPath != "" {
+		fmt.Printf("Wrote error report: %s\n", filepath.Clean(result.ErrorsPath))
+	}
+	if result.RedactionKeyPath != "" {
+		fmt.Printf("Wrote redaction key: %s\n", filepath.Clean(result.RedactionKeyPath))
+	}
+	return exitCode(err)
+}
+
+// hasPartialOutput reports whether a timed-out run still produced output to report on: a
+// written report, or the counts of a count-mode run.
+func hasPartialOutput(result services.Result) bool {
+	return result.Path != "" || len(result.Paths) > 0 || result.Counts != nil
+}
+
+// logRequestStats logs overall and per-endpoint request latency for the run.
+func logRequestStats(stats func() client.Stats) {
+	st := stats()
+	for _, e := range append(st.Endpoints, st.Overall) {
+		log.Info().
+			Str("endpoint", e.Endpoint).
+			Int("count", e.Count).
+			Dur("min", e.Min).
+			Dur("p50", e.P50).
+			Dur("p95", e.P95).
+			Dur("max", e.Max).
+			Msg("Request timing")
+	}
+}
 
-	log.Info().Str("path", filepath.Clean(path)).Msg("Report generation completed")
-	fmt.Printf("Wrote report: %s\n", filepath.Clean(path))
+// printCounts writes count mode's violation counts as indented JSON.
+func printCounts(w io.Writer, counts *services.Counts) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(counts)
 }
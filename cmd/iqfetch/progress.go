@@ -0,0 +1,37 @@
+// cmd/iqfetch/progress.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+)
+
+const progressBarWidth = 30
+
+// isTerminal reports whether f is attached to a character device (an interactive terminal).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar returns a ProgressFunc that redraws a single-line bar on w.
+func progressBar(w io.Writer) services.ProgressFunc {
+	return func(done, total int) {
+		if total <= 0 {
+			return
+		}
+		filled := done * progressBarWidth / total
+		fmt.Fprintf(w, "\r[%s%s] %d/%d apps", //nolint:errcheck
+			strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), done, total)
+		if done == total {
+			fmt.Fprintln(w) //nolint:errcheck
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// cmd/iqfetch/schedule.go
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// schedule calls run at once and then every interval (SCHEDULE_INTERVAL) until ctx is done,
+// logging each run's outcome. A run that outlasts the interval delays the next one rather
+// than overlapping it. It returns exitOK once ctx is done; maxRuns > 0 stops after that
+// many runs instead and returns the last run's exit code.
+func schedule(ctx context.Context, interval time.Duration, maxRuns int, run func(context.Context) int) int {
+	for n := 1; ; n++ {
+		start := time.Now()
+		code := run(ctx)
+		if ctx.Err() != nil {
+			log.Info().Int("runs", n).Msg("Scheduler stopped")
+			return exitOK
+		}
+		ev := log.Info()
+		if code != exitOK {
+			ev = log.Warn()
+		}
+		ev.Int("run", n).Int("exitCode", code).Dur("duration", time.Since(start)).Msg("Scheduled run finished")
+		if maxRuns > 0 && n >= maxRuns {
+			return code
+		}
+
+		next := start.Add(interval)
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+		log.Info().Time("next", next).Msg("Waiting for the next scheduled run")
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info().Int("runs", n).Msg("Scheduler stopped")
+			return exitOK
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// cmd/iqfetch/schedule_test.go
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	t.Run("run cap", func(t *testing.T) {
+		runs := 0
+		code := schedule(context.Background(), time.Millisecond, 3, func(context.Context) int {
+			runs++
+			if runs == 3 {
+				return exitPartialFailure
+			}
+			return exitOK
+		})
+		if runs != 3 || code != exitPartialFailure {
+			t.Errorf("runs = %d, code = %d, want 3 runs ending with the last run's code", runs, code)
+		}
+	})
+
+	t.Run("next run at the logged time", func(t *testing.T) {
+		// A run that outlasts the interval starts the next one at once; the one after
+		// still waits a full interval from that start.
+		const interval = 50 * time.Millisecond
+		var starts []time.Time
+		schedule(context.Background(), interval, 3, func(context.Context) int {
+			starts = append(starts, time.Now())
+			if len(starts) == 1 {
+				time.Sleep(interval + interval/5)
+			}
+			return exitOK
+		})
+		if gap := starts[2].Sub(starts[1]); gap < interval {
+			t.Errorf("third run started %v after the second, want at least %v", gap, interval)
+		}
+	})
+
+	t.Run("cancel during a run", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runs := 0
+		code := schedule(ctx, time.Millisecond, 10, func(context.Context) int {
+			runs++
+			if runs == 2 {
+				cancel()
+				return exitFailure // the aborted run's failure does not become the exit code
+			}
+			return exitOK
+		})
+		if runs != 2 || code != exitOK {
+			t.Errorf("runs = %d, code = %d, want 2 runs and exitOK", runs, code)
+		}
+	})
+
+	t.Run("cancel while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runs := 0
+		done := make(chan int)
+		go func() {
+			done <- schedule(ctx, time.Hour, 0, func(context.Context) int {
+				runs++
+				return exitOK
+			})
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		select {
+		case code := <-done:
+			if runs != 1 || code != exitOK {
+				t.Errorf("runs = %d, code = %d, want 1 run and exitOK", runs, code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("schedule did not stop after cancel")
+		}
+	})
+}
@@ -0,0 +1,25 @@
+// cmd/iqfetch/version.go
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Build metadata, overridden at build time via
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// userAgent identifies this tool and its version in IQ Server access logs.
+func userAgent() string {
+	return "iqfetch/" + version
+}
+
+// printVersion writes the build metadata in a single line.
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "iqfetch %s (commit %s, built %s)\n", version, commit, date) //nolint:errcheck
+}
@@ -0,0 +1,31 @@
+// cmd/iqfetch/version_test.go
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	oldVersion := version
+	t.Cleanup(func() { version = oldVersion })
+	version = "v1.2.3"
+
+	if got := userAgent(); got != "iqfetch/v1.2.3" {
+		t.Errorf("userAgent = %q", got)
+	}
+}
+
+func TestPrintVersion(t *testing.T) {
+	oldVersion, oldCommit, oldDate := version, commit, date
+	t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+	version, commit, date = "v1.2.3", "abc1234", "2026-01-02T03:04:05Z"
+
+	var buf bytes.Buffer
+	printVersion(&buf)
+
+	want := "iqfetch v1.2.3 (commit abc1234, built 2026-01-02T03:04:05Z)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printVersion = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+// internal/client/breaker.go
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting IQ Server while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open: IQ Server is failing, request skipped")
+
+// WithCircuitBreaker stops sending requests for cooldown once threshold consecutive requests
+// have failed within window; failures are transport errors and 5xx responses. After the
+// cooldown a single trial request is let through: success closes the breaker, failure
+// reopens it. threshold <= 0 disables the breaker.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) Option {
+	return func(o *clientOptions) {
+		o.breakerThreshold = threshold
+		o.breakerWindow = window
+		o.breakerCooldown = cooldown
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a consecutive-failure circuit breaker shared by all goroutines using a client.
+type breaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu          sync.Mutex
+	state       breakerState
+	failures    int       // consecutive failures in the current streak
+	streakStart time.Time // time of the streak's first failure
+	openedAt    time.Time
+	trial       bool // a half-open trial request is in flight
+}
+
+func newBreaker(threshold int, window, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, window: window, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a request may be sent now. A true result must be followed by
+// exactly one call to done.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		fallthrough
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+	}
+	return true
+}
+
+// outcome classifies a finished request for the breaker.
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeNeutral // e.g. cancelled by the caller; says nothing about server health
+)
+
+// done records the result of a request admitted by allow.
+func (b *breaker) done(o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasTrial := b.state == breakerHalfOpen && b.trial
+	if wasTrial {
+		b.trial = false
+	}
+	switch o {
+	case outcomeSuccess:
+		// A straggler admitted before the breaker opened must not close it early
+		if b.state != breakerOpen {
+			b.state = breakerClosed
+			b.failures = 0
+		}
+	case outcomeFailure:
+		if wasTrial {
+			b.open()
+			return
+		}
+		now := b.now()
+		if b.failures == 0 || now.Sub(b.streakStart) > b.window {
+			b.failures, b.streakStart = 0, now
+		}
+		b.failures++
+		if b.state == breakerClosed && b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+func (b *breaker) open() {
+	b.state = breakerOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
+
+// breakerTransport consults the breaker around every round trip.
+type breakerTransport struct {
+	breaker *breaker
+	next    http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		t.breaker.done(outcomeNeutral)
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.done(outcomeFailure)
+	default:
+		t.breaker.done(outcomeSuccess)
+	}
+	return resp, err
+}
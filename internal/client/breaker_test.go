@@ -0,0 +1,105 @@
+// internal/client/breaker_test.go
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker_OpensThenRecovers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(3, 10*time.Second, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	for range 3 {
+		if !b.allow() {
+			t.Fatal("closed breaker rejected a request")
+		}
+		b.done(outcomeFailure)
+	}
+	if b.allow() {
+		t.Fatal("breaker should be open after 3 consecutive failures")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !b.allow() {
+		t.Fatal("breaker should let a trial through after the cooldown")
+	}
+	if b.allow() {
+		t.Fatal("only one trial may be in flight while half-open")
+	}
+	b.done(outcomeFailure)
+	if b.allow() {
+		t.Fatal("a failed trial should reopen the breaker")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !b.allow() {
+		t.Fatal("expected a second trial")
+	}
+	b.done(outcomeSuccess)
+	if !b.allow() || !b.allow() {
+		t.Fatal("a successful trial should close the breaker")
+	}
+}
+
+func TestBreaker_FailuresOutsideWindowDoNotAccumulate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(2, 10*time.Second, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.allow()
+	b.done(outcomeFailure)
+	now = now.Add(11 * time.Second)
+	b.allow()
+	b.done(outcomeFailure)
+	if !b.allow() {
+		t.Error("failures further apart than the window should not open the breaker")
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithCircuitBreaker(2, time.Minute, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := rCtx(t)
+
+	for range 2 {
+		if _, err := c.GetOrganizations(ctx); err == nil {
+			t.Fatal("expected 503 error")
+		}
+	}
+	_, err = c.GetOrganizations(ctx)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third request error = %v, want ErrCircuitOpen", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server hits = %d, want 2 (open breaker must not reach the server)", hits.Load())
+	}
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := c.GetOrganizations(ctx); err != nil {
+		t.Fatalf("trial after cooldown: %v", err)
+	}
+	if _, err := c.GetOrganizations(ctx); err != nil {
+		t.Fatalf("request after recovery: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// internal/client/cassette.go
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cassetteEntry is one recorded response, stored as <dir>/<name>.json.
+type cassetteEntry struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Query       string `json:"query,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// cassetteName derives a readable, filesystem-safe file name for a request. The path
+// (host excluded) is kept legible; a short hash of method, path, query and any request
+// body keeps names unique, so POSTs for different components do not share a file.
+func cassetteName(req *http.Request) string {
+	key := req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery
+	if body := requestBody(req); len(body) > 0 {
+		key += "\n" + string(body)
+	}
+	sum := sha256.Sum256([]byte(key))
+	readable := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, strings.Trim(req.URL.Path, "/"))
+	if len(readable) > 100 {
+		readable = readable[:100]
+	}
+	return readable + "_" + hex.EncodeToString(sum[:4]) + ".json"
+}
+
+// requestBody returns a copy of the request body without consuming it, or nil.
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	b, _ := io.ReadAll(rc)
+	return b
+}
+
+// recordingTransport passes requests through to next and saves each response under dir.
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("record %s: read body: %w", req.URL.Path, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := cassetteEntry{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       req.URL.RawQuery,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("record %s: %w", req.URL.Path, err)
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, cassetteName(req)), b, 0o644); err != nil {
+		return nil, fmt.Errorf("record %s: %w", req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// replayTransport serves responses recorded by recordingTransport without touching the network.
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b, err := os.ReadFile(filepath.Join(t.dir, cassetteName(req)))
+	if err != nil {
+		return nil, fmt.Errorf("replay %s %s: no recording: %w", req.Method, req.URL.Path, err)
+	}
+	var entry cassetteEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("replay %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	header := make(http.Header)
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}, nil
+}
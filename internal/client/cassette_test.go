@@ -0,0 +1,76 @@
+// internal/client/cassette_test.go
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRecordThenReplay_ProducesIdenticalRows(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/reports/applications/aid-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-1"}})
+	})
+	mux.HandleFunc("/api/v2/applications/apid-1/reports/rpt-1/policy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"reportTime": 1700000000000,
+			"components": []any{map[string]any{
+				"displayName":         "commons-io : commons-io : 2.4",
+				"componentIdentifier": map[string]any{"format": "maven", "coordinates": map[string]any{"groupId": "commons-io", "artifactId": "commons-io", "version": "2.4"}},
+				"violations": []any{map[string]any{
+					"policyName":        "Security-High",
+					"policyThreatLevel": 9,
+					"constraints": []any{map[string]any{
+						"constraintName": "High risk CVSS score",
+						"conditions":     []any{map[string]any{"conditionSummary": "Security Vulnerability Severity >= 7"}},
+					}},
+				}},
+			}},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	dir := t.TempDir()
+
+	fetch := func(cl *Client) (*ReportInfo, []ViolationRow) {
+		t.Helper()
+		info, err := cl.GetLatestReportInfo(rCtx(t), "aid-1", "")
+		if err != nil {
+			t.Fatalf("GetLatestReportInfo: %v", err)
+		}
+		rows, err := cl.GetPolicyViolations(rCtx(t), "apid-1", "rpt-1", "org")
+		if err != nil {
+			t.Fatalf("GetPolicyViolations: %v", err)
+		}
+		return info, rows
+	}
+
+	recorder, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithRecordDir(dir))
+	if err != nil {
+		t.Fatalf("NewClient(record): %v", err)
+	}
+	recordedInfo, recordedRows := fetch(recorder)
+	srv.Close() // replay must not need the network
+
+	replayer, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithReplayDir(dir))
+	if err != nil {
+		t.Fatalf("NewClient(replay): %v", err)
+	}
+	replayedInfo, replayedRows := fetch(replayer)
+
+	if !reflect.DeepEqual(recordedInfo, replayedInfo) {
+		t.Errorf("report info differs:\nrecorded %+v\nreplayed %+v", recordedInfo, replayedInfo)
+	}
+	if len(recordedRows) != 1 || !reflect.DeepEqual(recordedRows, replayedRows) {
+		t.Errorf("rows differ:\nrecorded %+v\nreplayed %+v", recordedRows, replayedRows)
+	}
+
+	// Requests that were never recorded fail instead of reaching out.
+	if _, err := replayer.GetLatestReportInfo(rCtx(t), "aid-2", ""); err == nil {
+		t.Error("expected error for unrecorded request")
+	}
+}
@@ -2,15 +2,23 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
 )
 
 // Client holds the HTTP client configuration and logger.
@@ -18,6 +26,17 @@ type Client struct {
 	baseURL string
 	logger  zerolog.Logger
 	http    *resty.Client
+	stats   *statsCollector
+	// transport is the pooled HTTP transport beneath any replay, recording or breaker wrappers.
+	transport *http.Transport
+
+	selection  ReportSelection
+	stageOrder []string
+	parse      parseOptions
+	// policyPageSize is the page size requested from the policy violations report.
+	policyPageSize int
+	// orgs caches the organizations GetOrganizationsByIDs resolved.
+	orgs *orgCache
 }
 
 // =================================================================
@@ -28,6 +47,7 @@ type Client struct {
 type Application struct {
 	ID             string `json:"id"`
 	PublicID       string `json:"publicId"`
+	Name           string `json:"name"`
 	OrganizationID string `json:"organizationId"`
 }
 
@@ -49,6 +69,8 @@ type organizationsEnvelope struct {
 type ReportInfo struct {
 	Stage         string `json:"stage"`
 	ReportHTMLURL string `json:"reportHtmlUrl"`
+	// EvaluationDate is when the report was evaluated; zero if IQ did not return it.
+	EvaluationDate time.Time `json:"evaluationDate"`
 }
 
 // =================================================================
@@ -58,6 +80,24 @@ type ReportInfo struct {
 // Condition is the lowest level detail within a constraint.
 type Condition struct {
 	ConditionSummary string `json:"conditionSummary"`
+	// ConditionReason explains what matched, e.g. the licenses found for a license condition.
+	ConditionReason string `json:"conditionReason"`
+	// Reasons details the findings behind the condition, where IQ returns them.
+	Reasons []Reason `json:"reasons"`
+}
+
+// Reason is one finding behind a condition, e.g. a vulnerability, with an optional
+// reference to it.
+type Reason struct {
+	Reason    string           `json:"reason"`
+	Reference *ReasonReference `json:"reference"`
+}
+
+// ReasonReference identifies what a Reason refers to, e.g. a CVE ID (Value) of type
+// SECURITY_VULNERABILITY_REFID, or a link to it.
+type ReasonReference struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
 }
 
 // Constraint is a group of conditions within a policy violation.
@@ -68,25 +108,35 @@ type Constraint struct {
 
 // Violation details a specific policy break for a component.
 type Violation struct {
-	PolicyName        string       `json:"policyName"`
-	PolicyThreatLevel float64      `json:"policyThreatLevel"` // IQ Server returns numeric fields as float64
-	Constraints       []Constraint `json:"constraints"`
+	PolicyName        string      `json:"policyName"`
+	PolicyThreatLevel ThreatLevel `json:"policyThreatLevel"` // number or numeric string, see ThreatLevel
+	// PolicyAction is the action IQ applied for the report's stage (e.g. "fail", "warn",
+	// "notify"); empty when the IQ version does not report it.
+	PolicyAction string       `json:"policyAction"`
+	Constraints  []Constraint `json:"constraints"`
 }
 
 type ComponentIdentifier struct {
-	Format string `json:"format"`
+	Format      string      `json:"format"`
+	Coordinates Coordinates `json:"coordinates"`
 }
 
 // Component is a library/asset with associated violations.
 type Component struct {
 	DisplayName         string      `json:"displayName"`
+	PackageURL          string      `json:"packageUrl"`
 	Violations          []Violation `json:"violations"`
 	ComponentIdentifier `json:"componentIdentifier"`
 }
 
 // PolicyViolationReport is the top-level structure for the policy violations report API.
 type PolicyViolationReport struct {
+	ReportTime int64       `json:"reportTime"` // Evaluation time, milliseconds since the Unix epoch
 	Components []Component `json:"components"`
+	// Page (1-based) and PageCount are set when the server paginates the components;
+	// both are zero for an unpaged response.
+	Page      int `json:"page,omitempty"`
+	PageCount int `json:"pageCount,omitempty"`
 }
 
 // =================================================================
@@ -100,18 +150,156 @@ type ViolationRow struct {
 	Policy         string
 	Format         string
 	Component      string
-	Threat         int
+	Group          string
+	Name           string
+	Version        string
+	Threat         int     // PolicyThreatLevel truncated to an integer
+	ThreatRaw      float64 // PolicyThreatLevel as reported, e.g. 7.5
 	PolicyAction   string
 	ConstraintName string
-	Condition      string
-	CVE            string
+	Condition      string   // Conditions joined with the configured separator
+	Conditions     []string // Individual condition summaries
+	// CVE joins CVEs with "; ".
+	CVE string
+	// CVEs lists the CVE IDs the constraint's conditions reference, sorted and deduplicated.
+	CVEs []string
+	// License lists the license IDs a license-type violation matched, comma separated.
+	License     string
+	EvaluatedAt time.Time // Zero when the report did not include a reportTime
+	// Clean marks the placeholder row of a component without violations.
+	Clean bool
+	// ComponentRef identifies the component for follow-up API calls such as remediation.
+	ComponentRef ComponentRef
+	// Purl is the component's package URL, as reported by IQ or built by
+	// ComponentIdentifier.PackageURL; empty when neither yields one.
+	Purl string
+	// Occurrences is the number of conditions merged into Condition, e.g. one per
+	// vulnerability a security constraint matched; 0 for clean rows.
+	Occurrences int
+	// Reasons lists the reasons of the constraint's conditions, see constraintReasons.
+	Reasons []string
 }
 
 // =================================================================
 // Client Initialization
 // =================================================================
 
-func NewClient(serverURL, username, password string, logger zerolog.Logger) (*Client, error) {
+// DefaultAPIBasePath is the path IQ Server exposes its v2 REST API under.
+const DefaultAPIBasePath = "/api/v2"
+
+// Option customizes a Client at construction time.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	apiBasePath       string
+	strictAPIPath     bool
+	requestsPerSecond float64
+	recordDir         string
+	replayDir         string
+	selection         ReportSelection
+	stageOrder        []string
+	includeClean      bool
+	conditionSep      string
+	policies          policyFilter
+	userAgent         string
+	breakerThreshold  int
+	breakerWindow     time.Duration
+	breakerCooldown   time.Duration
+	retries           int
+	retryBase         time.Duration
+	retryMax          time.Duration
+	headers           map[string]string
+	httpTrace         bool
+	pool              ConnPool
+	tlsServerName     string
+	policyPageSize    int
+	observer          RequestObserver
+}
+
+// parseOptions controls how policy violation reports are flattened into rows.
+type parseOptions struct {
+	includeClean bool
+	// conditionSep joins a constraint's condition summaries into ViolationRow.Condition.
+	conditionSep string
+	policies     policyFilter
+}
+
+// DefaultConditionSeparator joins condition summaries when WithConditionSeparator is not used.
+const DefaultConditionSeparator = " | "
+
+// WithAPIBasePath sets the API prefix appended to the server host (default /api/v2).
+func WithAPIBasePath(basePath string) Option {
+	return func(o *clientOptions) { o.apiBasePath = basePath }
+}
+
+// WithStrictAPIPath makes NewClient reject a serverURL whose path is neither empty
+// nor already ending in the API base path, instead of appending with a warning.
+func WithStrictAPIPath(strict bool) Option {
+	return func(o *clientOptions) { o.strictAPIPath = strict }
+}
+
+// WithRateLimit caps the client at rps requests per second across all goroutines sharing it.
+// Zero (or negative) means unlimited.
+func WithRateLimit(rps float64) Option {
+	return func(o *clientOptions) { o.requestsPerSecond = rps }
+}
+
+// WithRecordDir saves every response body under dir, keyed by request path, for later replay.
+func WithRecordDir(dir string) Option {
+	return func(o *clientOptions) { o.recordDir = dir }
+}
+
+// WithReplayDir serves responses previously saved by WithRecordDir from dir instead of the
+// network. It takes precedence over WithRecordDir.
+func WithReplayDir(dir string) Option {
+	return func(o *clientOptions) { o.replayDir = dir }
+}
+
+// WithIncludeCleanComponents makes GetPolicyViolations emit one Clean row, with empty
+// policy fields and threat 0, for each component that has no violations.
+func WithIncludeCleanComponents(include bool) Option {
+	return func(o *clientOptions) { o.includeClean = include }
+}
+
+// WithConditionSeparator sets the string joining a constraint's condition summaries in
+// ViolationRow.Condition; empty keeps DefaultConditionSeparator.
+func WithConditionSeparator(sep string) Option {
+	return func(o *clientOptions) {
+		if sep != "" {
+			o.conditionSep = sep
+		}
+	}
+}
+
+// DefaultPolicyPageSize is the number of components requested per page of the policy
+// violations report when WithPolicyPageSize is not used.
+const DefaultPolicyPageSize = 500
+
+// WithPolicyPageSize sets the components requested per page of the policy violations
+// report, for servers that paginate it; values <= 0 keep DefaultPolicyPageSize.
+func WithPolicyPageSize(n int) Option {
+	return func(o *clientOptions) {
+		if n > 0 {
+			o.policyPageSize = n
+		}
+	}
+}
+
+// DefaultUserAgent is sent when WithUserAgent is not used.
+const DefaultUserAgent = "iqfetch"
+
+// WithUserAgent sets the User-Agent header sent with every request; empty keeps DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(o *clientOptions) {
+		if ua != "" {
+			o.userAgent = ua
+		}
+	}
+}
+
+// NewClient builds an IQ Server API client. serverURL is normally just the host
+// (e.g. https://iq.example.com); the API base path is appended to it.
+func NewClient(serverURL, username, password string, logger zerolog.Logger, opts ...Option) (*Client, error) {
 	// Defense checks
 	if strings.TrimSpace(serverURL) == "" {
 		return nil, fmt.Errorf("serverURL is required")
@@ -124,23 +312,102 @@ func NewClient(serverURL, username, password string, logger zerolog.Logger) (*Cl
 	}
 	// The logger is a struct, so it cannot be nil. No check needed.
 
-	// Expect serverURL to already include /api/v2
-	baseURL := strings.TrimSuffix(serverURL, "/")
-	u, err := url.Parse(baseURL)
+	o := clientOptions{apiBasePath: DefaultAPIBasePath, userAgent: DefaultUserAgent, conditionSep: DefaultConditionSeparator, policyPageSize: DefaultPolicyPageSize, observer: nopObserver{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := o.policies.validate(); err != nil {
+		return nil, err
+	}
+	for name, value := range o.headers {
+		if err := CheckHeader(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	baseURL, err := normalizeBaseURL(serverURL, o.apiBasePath, o.strictAPIPath, logger)
 	if err != nil {
-		return nil, fmt.Errorf("invalid baseURL: %w", err)
+		return nil, err
 	}
-	u.Path = path.Clean(u.Path)
-	baseURL = u.String()
-	baseURL = strings.TrimRight(baseURL, "/") + "/"
 
 	r := resty.New().
 		SetBaseURL(baseURL).
 		SetBasicAuth(username, password).
 		SetHeader("Accept", "application/json").
+		SetHeader("User-Agent", o.userAgent).
+		SetHeaders(o.headers).
 		SetTimeout(30 * time.Second)
 
-	// Resty hooks for logging
+	// Keep-alive pool of the underlying transport, before any wrapping below
+	transport, ok := r.GetClient().Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		r.SetTransport(transport)
+	}
+	o.pool.apply(transport)
+	applyTLSServerName(transport, o.tlsServerName)
+
+	// VCR-style cassettes: replay from disk, or record real responses to disk
+	switch {
+	case o.replayDir != "":
+		logger.Info().Str("dir", o.replayDir).Msg("Replaying recorded IQ Server responses")
+		r.SetTransport(&replayTransport{dir: o.replayDir})
+	case o.recordDir != "":
+		if err := os.MkdirAll(o.recordDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create record dir: %w", err)
+		}
+		logger.Info().Str("dir", o.recordDir).Msg("Recording IQ Server responses")
+		next := r.GetClient().Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		r.SetTransport(&recordingTransport{dir: o.recordDir, next: next})
+	}
+
+	// Circuit breaker shared by every request of this client
+	if o.breakerThreshold > 0 {
+		next := r.GetClient().Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		r.SetTransport(&breakerTransport{breaker: newBreaker(o.breakerThreshold, o.breakerWindow, o.breakerCooldown), next: next})
+	}
+
+	// Retries with full-jitter backoff; each attempt passes the breaker and rate limit again
+	if o.retries > 0 {
+		newBackoff(o.retryBase, o.retryMax, nil).apply(r, o.retries)
+	}
+
+	// Shared token bucket: every request waits for a token, honoring its context
+	if o.requestsPerSecond > 0 {
+		limiter := rate.NewLimiter(rate.Limit(o.requestsPerSecond), 1)
+		r.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return fmt.Errorf("rate limit wait: %w", err)
+			}
+			return nil
+		})
+	}
+
+	cl := &Client{
+		baseURL: baseURL,
+		logger:  logger,
+		http:    r,
+		stats:   newStatsCollector(),
+
+		transport: transport,
+
+		selection:      o.selection,
+		stageOrder:     o.stageOrder,
+		policyPageSize: o.policyPageSize,
+		parse:          parseOptions{includeClean: o.includeClean, conditionSep: o.conditionSep, policies: o.policies},
+		orgs:           newOrgCache(nil),
+	}
+	basePath := cl.basePath()
+
+	// Resty hooks for logging and latency stats
+	customHeaders := slices.Collect(maps.Keys(o.headers))
 	r.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
 		logger.Debug().
 			Str("method", req.Method).
@@ -155,14 +422,16 @@ func NewClient(serverURL, username, password string, logger zerolog.Logger) (*Cl
 			Str("url", resp.Request.URL).
 			Str("method", resp.Request.Method).
 			Msg("Request completed")
+		if o.httpTrace {
+			traceResponse(logger, resp, customHeaders)
+		}
+		if raw := resp.Request.RawRequest; raw != nil {
+			cl.stats.add(endpointTemplate(basePath, raw.URL.Path), resp.Time())
+		}
 		return nil
 	})
+	observeRequests(r, o.observer, basePath)
 
-	cl := &Client{
-		baseURL: baseURL,
-		logger:  logger,
-		http:    r,
-	}
 	logger.Info().Str("baseURL", baseURL).Msg("Initialized IQServer API client")
 	return cl, nil
 }
@@ -191,7 +460,7 @@ func (c *Client) GetApplications(ctx context.Context, orgID *string) ([]Applicat
 		SetError(&map[string]any{}).
 		Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, transportError(endpoint, err)
 	}
 
 	c.logger.Debug().Int("status", resp.StatusCode()).Str("body", resp.String()).Msg("raw response")
@@ -202,125 +471,448 @@ func (c *Client) GetApplications(ctx context.Context, orgID *string) ([]Applicat
 			Str("statusText", resp.Status()).
 			Str("rawBodySnippet", strings.TrimSpace(resp.String())).
 			Msg("Failed to fetch applications from API")
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), resp.String())
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.String()}
 	}
 
 	return env.Applications, nil
 }
 
+// isNoReportsBody reports whether the body of a 404 from the reports endpoint is IQ's answer for
+// an application without reports (e.g. "No reports found for application"), rather than an
+// unknown application or a wrong API path.
+func isNoReportsBody(body string) bool {
+	return strings.Contains(strings.ToLower(body), "no report")
+}
+
 // GetLatestReportInfo fetches the metadata for the most recent report for a given internal application ID.
-func (c *Client) GetLatestReportInfo(ctx context.Context, appID string) (*ReportInfo, error) {
+// When stage is non-empty only reports for that stage are considered; nil is returned if none exist.
+// Among several reports the one chosen by WithReportSelection wins, never simply the first listed.
+// The shapes IQ versions use for a never-scanned application (204, a 404 saying there are no
+// reports, an empty array or an empty object) all yield nil; other error statuses, including a
+// 404 for an unknown application or API path, are returned as *APIError.
+func (c *Client) GetLatestReportInfo(ctx context.Context, appID, stage string) (*ReportInfo, error) {
 	endpoint := fmt.Sprintf("reports/applications/%s", appID)
-	var reports []ReportInfo
 
 	resp, err := c.http.R().
 		SetContext(ctx).
-		SetResult(&reports).
 		Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, transportError(endpoint, err)
+	}
+	if resp.StatusCode() == http.StatusNoContent || (resp.StatusCode() == http.StatusNotFound && isNoReportsBody(resp.String())) {
+		c.logger.Debug().
+			Str("appId", appID).
+			Int("status", resp.StatusCode()).
+			Str("rawBodySnippet", strings.TrimSpace(resp.String())).
+			Msg("No reports for application")
+		return nil, nil
 	}
 	if resp.IsError() {
 		c.logger.Error().
 			Str("appID", appID).
 			Int("status", resp.StatusCode()).
 			Str("statusText", resp.Status()).
+			Str("rawBodySnippet", strings.TrimSpace(resp.String())).
 			Msg("Failed to fetch latest report info")
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), resp.Status())
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
+	}
+
+	var reports []ReportInfo
+	switch body := bytes.TrimSpace(resp.Body()); string(body) {
+	case "", "{}", "null":
+		// Treated like an empty array
+	default:
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", endpoint, err)
+		}
 	}
 
-	if len(reports) > 0 {
-		c.logger.Debug().Int("count", len(reports)).Str("appId", appID).Msg("Found reports")
-		r := reports[0]
-		return &r, nil
+	c.logger.Debug().Int("count", len(reports)).Str("appId", appID).Str("stage", stage).Msg("Found reports")
+	if r := selectReport(reports, stage, c.selection, c.stageOrder); r != nil {
+		return r, nil
 	}
 
-	c.logger.Debug().Str("appId", appID).Msg("No reports found")
+	c.logger.Debug().Str("appId", appID).Str("stage", stage).Msg("No reports found")
+	return nil, nil
+}
+
+// reportHistoryEnvelope is the response of the per-application report history endpoint.
+type reportHistoryEnvelope struct {
+	Reports []ReportInfo `json:"reports"`
+}
+
+// GetLatestReportForStage fetches the most recent report of one stage for a given internal
+// application ID in a single call, using IQ's report history filtered by stage and limited to
+// one entry, instead of listing every report and picking client-side. It returns nil when the
+// application has no report for the stage. A 404 is ambiguous (no reports, or an IQ version
+// without the history endpoint), so it falls back to GetLatestReportInfo.
+func (c *Client) GetLatestReportForStage(ctx context.Context, appID, stage string) (*ReportInfo, error) {
+	endpoint := fmt.Sprintf("reports/applications/%s/history", url.PathEscape(appID))
+
+	var env reportHistoryEnvelope
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{"stage": stage, "limit": "1"}).
+		Get(endpoint)
+	if err != nil {
+		return nil, transportError(endpoint, err)
+	}
+	switch resp.StatusCode() {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusNotFound:
+		c.logger.Debug().Str("appId", appID).Str("stage", stage).Msg("Report history not found, listing reports instead")
+		return c.GetLatestReportInfo(ctx, appID, stage)
+	}
+	if resp.IsError() {
+		c.logger.Error().
+			Str("appID", appID).
+			Str("stage", stage).
+			Int("status", resp.StatusCode()).
+			Str("statusText", resp.Status()).
+			Msg("Failed to fetch latest report for stage")
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
+	}
+
+	if body := bytes.TrimSpace(resp.Body()); len(body) > 0 && string(body) != "null" {
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", endpoint, err)
+		}
+	}
+	// The server already filtered by stage; entries that omit it belong to the stage asked for.
+	// Older servers may ignore limit, so the newest entry is picked rather than the first.
+	for i := range env.Reports {
+		if env.Reports[i].Stage == "" {
+			env.Reports[i].Stage = stage
+		}
+	}
+	if r := selectReport(env.Reports, stage, SelectLatest, nil); r != nil {
+		return r, nil
+	}
+
+	c.logger.Debug().Str("appId", appID).Str("stage", stage).Msg("No reports found for stage")
 	return nil, nil
 }
 
 // GetPolicyViolations fetches the detailed policy violation report for a specific application and report ID.
 func (c *Client) GetPolicyViolations(ctx context.Context, publicID, reportID, orgName string) ([]ViolationRow, error) {
-	c.logger.Debug().Str("publicId", publicID).Str("reportId", reportID).Msg("Fetching policy violations")
+	c.logger.Debug().Str("publicId", publicID).Str("reportId", reportID).Int("pageSize", c.policyPageSize).Msg("Fetching policy violations")
 
 	endpoint := fmt.Sprintf("applications/%s/reports/%s/policy", publicID, reportID)
-	params := url.Values{"includeViolationTimes": []string{"true"}}
 
-	var report PolicyViolationReport // Use the explicit struct
+	// A paginating server reports pageCount on the first page; the remaining pages are
+	// fetched in order and their components accumulated before parsing.
+	var report PolicyViolationReport
+	for page := 1; ; page++ {
+		var part PolicyViolationReport
+		if err := c.getPolicyPage(ctx, endpoint, page, &part); err != nil {
+			c.logger.Error().
+				Err(err).
+				Str("publicId", publicID).
+				Str("reportId", reportID).
+				Int("page", page).
+				Msg("Failed to fetch policy violations report")
+			return nil, err
+		}
+		if page == 1 {
+			report = part
+		} else {
+			report.Components = append(report.Components, part.Components...)
+		}
+		if page >= part.PageCount || len(part.Components) == 0 {
+			break
+		}
+	}
+	if report.PageCount > 1 {
+		c.logger.Debug().Str("publicId", publicID).Int("pages", report.PageCount).Int("components", len(report.Components)).Msg("Fetched paged policy violations")
+	}
+
+	// Parse and filter to ViolationRow using the structured data
+	return parseToViolationRows(report, publicID, orgName, c.parse), nil
+}
+
+// getPolicyPage fetches one page of the policy violations report into dst.
+func (c *Client) getPolicyPage(ctx context.Context, endpoint string, page int, dst *PolicyViolationReport) error {
+	params := url.Values{
+		"includeViolationTimes": []string{"true"},
+		"page":                  []string{strconv.Itoa(page)},
+		"pageSize":              []string{strconv.Itoa(c.policyPageSize)},
+	}
 	resp, err := c.http.R().
 		SetContext(ctx).
 		SetQueryParamsFromValues(params).
-		SetResult(&report). // Unmarshal directly into struct
 		Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return transportError(endpoint, err)
 	}
 	if resp.IsError() {
-		c.logger.Error().
-			Str("publicId", publicID).
-			Str("reportId", reportID).
-			Int("status", resp.StatusCode()).
-			Msg("Failed to fetch policy violations report")
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), resp.Status())
+		return &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
 	}
+	return c.decodeJSON(resp, endpoint, dst)
+}
 
-	// Parse and filter to ViolationRow using the structured data
-	return parseToViolationRows(report, publicID, orgName), nil
+// maxBodySnippet bounds the response body logged for a malformed response.
+const maxBodySnippet = 512
+
+// decodeJSON unmarshals the body of resp into dst, whatever its Content-Type. A body that
+// is not valid JSON (truncated, an HTML error page) is logged with a snippet and returned
+// as an *APIError wrapping ErrMalformedResponse.
+func (c *Client) decodeJSON(resp *resty.Response, endpoint string, dst any) error {
+	err := json.Unmarshal(resp.Body(), dst)
+	if err == nil {
+		return nil
+	}
+	snippet := strings.TrimSpace(string(resp.Body()))
+	if len(snippet) > maxBodySnippet {
+		snippet = strings.ToValidUTF8(snippet[:maxBodySnippet], "") + "..."
+	}
+	c.logger.Error().
+		Err(err).
+		Str("endpoint", endpoint).
+		Int("status", resp.StatusCode()).
+		Str("contentType", resp.Header().Get("Content-Type")).
+		Int("bodyBytes", len(resp.Body())).
+		Str("rawBodySnippet", snippet).
+		Msg("Malformed JSON response")
+	return &APIError{
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode(),
+		Message:    fmt.Sprintf("%v: %v", ErrMalformedResponse, err),
+		Err:        fmt.Errorf("%w: %w", ErrMalformedResponse, err),
+	}
 }
 
 // GetOrganizations fetches the list of all organizations.
 func (c *Client) GetOrganizations(ctx context.Context) ([]Organization, error) {
 	c.logger.Debug().Msg("Fetching organizations")
 
+	const endpoint = "organizations"
 	var env organizationsEnvelope
 	resp, err := c.http.R().
 		SetContext(ctx).
 		SetResult(&env).
-		Get("organizations")
+		Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, transportError(endpoint, err)
 	}
 	if resp.IsError() {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), resp.String())
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.String()}
 	}
 
 	c.logger.Debug().Int("count", len(env.Organizations)).Msg("Retrieved organizations")
 	return env.Organizations, nil
 }
 
+// orgsByIDThreshold is the number of organization IDs above which GetOrganizationsByIDs
+// fetches the full list once instead of one request per ID.
+const orgsByIDThreshold = 20
+
+// GetOrganizationsByIDs resolves only the given organization IDs, reusing those resolved
+// within the last hour. Small sets are fetched one organization at a time; larger ones fall
+// back to a single full listing filtered client-side. IDs the server does not know (HTTP 404)
+// are omitted from the result.
+func (c *Client) GetOrganizationsByIDs(ctx context.Context, ids []string) ([]Organization, error) {
+	want := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			want[id] = struct{}{}
+		}
+	}
+	var cached []Organization
+	for id := range want {
+		if org, ok := c.orgs.get(id); ok {
+			cached = append(cached, org)
+			delete(want, id)
+		}
+	}
+	if len(want) == 0 {
+		if len(cached) > 0 {
+			c.logger.Debug().Int("cached", len(cached)).Msg("Organizations resolved from cache")
+		}
+		return cached, nil
+	}
+	orgs, err := c.fetchOrganizationsByIDs(ctx, want)
+	if err != nil {
+		return nil, err
+	}
+	c.orgs.put(orgs)
+	return append(cached, orgs...), nil
+}
+
+// fetchOrganizationsByIDs fetches the organizations in want; see GetOrganizationsByIDs.
+func (c *Client) fetchOrganizationsByIDs(ctx context.Context, want map[string]struct{}) ([]Organization, error) {
+	if len(want) > orgsByIDThreshold {
+		all, err := c.GetOrganizations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		orgs := make([]Organization, 0, len(want))
+		for _, org := range all {
+			if _, ok := want[org.ID]; ok {
+				orgs = append(orgs, org)
+			}
+		}
+		return orgs, nil
+	}
+
+	orgs := make([]Organization, 0, len(want))
+	for id := range want {
+		org, err := c.GetOrganization(ctx, id)
+		if IsNotFound(err) {
+			c.logger.Warn().Str("orgId", id).Msg("Organization not found")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+	c.logger.Debug().Int("requested", len(want)).Int("resolved", len(orgs)).Msg("Retrieved organizations by ID")
+	return orgs, nil
+}
+
+// GetOrganization fetches a single organization by ID. An unknown ID yields an
+// *APIError with HTTP 404 (see IsNotFound).
+func (c *Client) GetOrganization(ctx context.Context, id string) (*Organization, error) {
+	endpoint := fmt.Sprintf("organizations/%s", url.PathEscape(id))
+	var org Organization
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetResult(&org).
+		Get(endpoint)
+	if err != nil {
+		return nil, transportError(endpoint, err)
+	}
+	if resp.IsError() {
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.String()}
+	}
+	return &org, nil
+}
+
 // =================================================================
 // Helper Functions
 // =================================================================
 
+// normalizeBaseURL cleans serverURL and appends basePath to it, returning the result with a trailing slash.
+// A serverURL that already ends in basePath is used as-is. Any other non-root path is kept in front
+// of basePath with a warning, or rejected when strict is set.
+func normalizeBaseURL(serverURL, basePath string, strict bool, logger zerolog.Logger) (string, error) {
+	basePath = strings.TrimRight(path.Clean("/"+basePath), "/")
+
+	u, err := url.Parse(strings.TrimSpace(serverURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid baseURL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid baseURL %q: expected an absolute URL like https://iq.example.com", serverURL)
+	}
+
+	p := strings.TrimRight(path.Clean("/"+u.Path), "/")
+	switch {
+	case basePath == "" || strings.HasSuffix(p, basePath):
+		// Already includes the API prefix (or no prefix is wanted)
+	case p == "":
+		p = basePath
+	case strict:
+		return "", fmt.Errorf("IQ server URL %q has path %q, which does not end in the API base path %s; "+
+			"pass just the host (e.g. %s://%s) or the full %s path", serverURL, p, basePath, u.Scheme, u.Host, basePath)
+	default:
+		logger.Warn().
+			Str("serverURL", serverURL).
+			Str("appended", basePath).
+			Msg("IQ server URL has a path that does not end in the API base path; appending it")
+		p += basePath
+	}
+	u.Path = p + "/"
+	return u.String(), nil
+}
+
 // parseToViolationRows converts the structured API response into flat ViolationRow slice.
-func parseToViolationRows(rawReport PolicyViolationReport, appPublicID string, orgName string) []ViolationRow {
+// Violations of policies rejected by opts.policies are dropped. With opts.includeClean, a
+// component without violations yields a single Clean row.
+func parseToViolationRows(rawReport PolicyViolationReport, appPublicID string, orgName string, opts parseOptions) []ViolationRow {
 	var rows []ViolationRow
+	var evaluatedAt time.Time
+	if rawReport.ReportTime > 0 {
+		evaluatedAt = time.UnixMilli(rawReport.ReportTime).UTC()
+	}
 
 	for _, comp := range rawReport.Components {
 		compName := comp.DisplayName
 		format := comp.ComponentIdentifier.Format
+		group, name, version := componentGAV(comp)
+		purl := comp.PackageURL
+		if purl == "" {
+			purl = comp.ComponentIdentifier.PackageURL()
+		}
+		ref := ComponentRef{PackageURL: comp.PackageURL}
+		if ref.PackageURL == "" && comp.ComponentIdentifier.Format != "" {
+			id := comp.ComponentIdentifier
+			ref.Identifier = &id
+		}
+		if opts.includeClean && len(comp.Violations) == 0 {
+			rows = append(rows, ViolationRow{
+				Application:  appPublicID,
+				Organization: orgName,
+				Format:       format,
+				Component:    compName,
+				Group:        group,
+				Name:         name,
+				Version:      version,
+				Purl:         purl,
+				EvaluatedAt:  evaluatedAt,
+				Clean:        true,
+				ComponentRef: ref,
+			})
+			continue
+		}
 		for _, v := range comp.Violations {
+			if !opts.policies.keep(v.PolicyName) {
+				continue
+			}
 			policyName := v.PolicyName
-			// Threat level comes as float64, cast to int
 			threat := int(v.PolicyThreatLevel)
-			policyAction := fmt.Sprintf("Security-%d", threat)
+			threatRaw := float64(v.PolicyThreatLevel)
+			category := violationCategory(v)
+			policyAction := v.PolicyAction
+			if policyAction == "" {
+				// Older IQ versions omit the action; synthesize "<category>-<threat>"
+				policyAction = fmt.Sprintf("%s-%d", category, threat)
+			}
 			for _, constr := range v.Constraints {
 				constraintName := constr.ConstraintName
 				var condSummaries []string
 				for _, cond := range constr.Conditions {
 					condSummaries = append(condSummaries, cond.ConditionSummary)
 				}
+				cves := constraintCVEs(constr)
+				var license string
+				if category == categoryLicense {
+					license = strings.Join(constraintLicenses(constr), ", ")
+				}
 				rows = append(rows, ViolationRow{
 					Application:    appPublicID,
 					Organization:   orgName,
 					Policy:         policyName,
 					Format:         format,
 					Component:      compName,
+					Group:          group,
+					Name:           name,
+					Version:        version,
+					Purl:           purl,
 					Threat:         threat,
+					ThreatRaw:      threatRaw,
 					PolicyAction:   policyAction,
 					ConstraintName: constraintName,
-					Condition:      strings.Join(condSummaries, " | "),
-					CVE:            "",
+					Condition:      strings.Join(condSummaries, opts.conditionSep),
+					Conditions:     condSummaries,
+					CVE:            strings.Join(cves, "; "),
+					Occurrences:    len(constr.Conditions),
+					Reasons:        constraintReasons(constr),
+					CVEs:           cves,
+					License:        license,
+					EvaluatedAt:    evaluatedAt,
+					ComponentRef:   ref,
 				})
 			}
 		}
@@ -4,10 +4,15 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -60,6 +65,7 @@ func TestClient_EndToEndAgainstStub(t *testing.T) {
 
 		case "/api/v2/applications/app-public-1/reports/rpt-1/policy":
 			resp := map[string]any{
+				"reportTime": 1700000000000,
 				"components": []any{
 					map[string]any{
 						"displayName": "setuptools 80.9.0 (.tar.gz)",
@@ -149,7 +155,7 @@ func TestClient_EndToEndAgainstStub(t *testing.T) {
 	}
 
 	// Latest report
-	reportInfo, err := iqClient.GetLatestReportInfo(rCtx(t), "app-internal-1")
+	reportInfo, err := iqClient.GetLatestReportInfo(rCtx(t), "app-internal-1", "")
 	if err != nil || reportInfo == nil {
 		t.Fatalf("GetLatestReportInfo error = %v ri=%v", err, reportInfo)
 	}
@@ -174,6 +180,9 @@ func TestClient_EndToEndAgainstStub(t *testing.T) {
 	if violationRows[1].Format != "pypi" {
 		t.Errorf("expected format 'pypi', got %q", violationRows[1].Format)
 	}
+	if want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC); !violationRows[0].EvaluatedAt.Equal(want) {
+		t.Errorf("EvaluatedAt = %v, want %v", violationRows[0].EvaluatedAt, want)
+	}
 
 	// Orgs
 	orgs, err := iqClient.GetOrganizations(rCtx(t))
@@ -189,3 +198,612 @@ func rCtx(t *testing.T) context.Context {
 	t.Cleanup(cancel)
 	return ctx
 }
+
+func TestNewClient_APIPathNormalization(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverURL string
+		opts      []Option
+		want      string
+		wantErr   bool
+	}{
+		{"bare host appended", "https://iq.example.com", nil, "https://iq.example.com/api/v2/", false},
+		{"trailing slash appended", "https://iq.example.com/", nil, "https://iq.example.com/api/v2/", false},
+		{"already suffixed", "https://iq.example.com/api/v2", nil, "https://iq.example.com/api/v2/", false},
+		{"suffixed with trailing slash strict", "https://iq.example.com:8070/api/v2/", []Option{WithStrictAPIPath(true)}, "https://iq.example.com:8070/api/v2/", false},
+		{"bare host strict", "https://iq.example.com", []Option{WithStrictAPIPath(true)}, "https://iq.example.com/api/v2/", false},
+		{"context path appended", "https://example.com/iq", nil, "https://example.com/iq/api/v2/", false},
+		{"context path strict", "https://example.com/iq", []Option{WithStrictAPIPath(true)}, "", true},
+		{"custom prefix", "https://iq.example.com", []Option{WithAPIBasePath("/api/v1/")}, "https://iq.example.com/api/v1/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.serverURL, "u", "p", newTestLogger(), tt.opts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got baseURL %q", c.baseURL)
+				}
+				if !strings.Contains(err.Error(), "/api/v2") {
+					t.Errorf("error should mention /api/v2: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient error = %v", err)
+			}
+			if c.baseURL != tt.want {
+				t.Errorf("baseURL = %q, want %q", c.baseURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient_APIBasePathRequestPaths(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer srv.Close()
+
+	for basePath, want := range map[string]string{
+		"/api/v2":        "/api/v2/organizations",
+		"/rest/iq/api/2": "/rest/iq/api/2/organizations",
+	} {
+		iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithAPIBasePath(basePath))
+		if err != nil {
+			t.Fatalf("NewClient(%q) error = %v", basePath, err)
+		}
+		if _, err := iqClient.GetOrganizations(rCtx(t)); err != nil {
+			t.Fatalf("GetOrganizations error = %v", err)
+		}
+		if gotPath != want {
+			t.Errorf("base path %q: request path = %q, want %q", basePath, gotPath, want)
+		}
+	}
+}
+
+func TestGetLatestReportInfo_StageFilter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-build"},
+			{"stage": "release", "reportHtmlUrl": "https://stub/report/rpt-release"},
+		})
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL+"/api/v2", "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+
+	tests := []struct {
+		stage   string
+		wantURL string
+	}{
+		{"", "https://stub/report/rpt-build"},
+		{"build", "https://stub/report/rpt-build"},
+		{"release", "https://stub/report/rpt-release"},
+		{"stage-release", ""},
+	}
+	for _, tt := range tests {
+		ri, err := iqClient.GetLatestReportInfo(rCtx(t), "app-1", tt.stage)
+		if err != nil {
+			t.Fatalf("GetLatestReportInfo(%q) error = %v", tt.stage, err)
+		}
+		if tt.wantURL == "" {
+			if ri != nil {
+				t.Errorf("stage %q: expected nil, got %#v", tt.stage, ri)
+			}
+			continue
+		}
+		if ri == nil || ri.ReportHTMLURL != tt.wantURL {
+			t.Errorf("stage %q: got %#v, want %s", tt.stage, ri, tt.wantURL)
+		}
+	}
+}
+
+func TestGetLatestReportForStage(t *testing.T) {
+	var listed atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/reports/applications/app-1/history":
+			if got := r.URL.Query(); got.Get("stage") != "release" || got.Get("limit") != "1" {
+				t.Errorf("query = %v, want stage=release&limit=1", got)
+			}
+			_, _ = w.Write([]byte(`{"applicationId":"app-1","reports":[{"stage":"release","reportHtmlUrl":"https://stub/report/rpt-release"}]}`))
+		case "/api/v2/reports/applications/app-none/history":
+			_, _ = w.Write([]byte(`{"applicationId":"app-none","reports":[]}`))
+		case "/api/v2/reports/applications/app-old":
+			// Server without the history endpoint: only the listing exists.
+			listed.Add(1)
+			_, _ = w.Write([]byte(`[{"stage":"build","reportHtmlUrl":"https://stub/report/rpt-build"},{"stage":"release","reportHtmlUrl":"https://stub/report/rpt-old"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL+"/api/v2", "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+
+	tests := []struct {
+		appID   string
+		wantURL string
+	}{
+		{"app-1", "https://stub/report/rpt-release"},
+		{"app-none", ""},
+		{"app-old", "https://stub/report/rpt-old"},
+	}
+	for _, tt := range tests {
+		ri, err := iqClient.GetLatestReportForStage(rCtx(t), tt.appID, "release")
+		if err != nil {
+			t.Fatalf("GetLatestReportForStage(%q) error = %v", tt.appID, err)
+		}
+		if tt.wantURL == "" {
+			if ri != nil {
+				t.Errorf("%s: expected nil, got %#v", tt.appID, ri)
+			}
+			continue
+		}
+		if ri == nil || ri.ReportHTMLURL != tt.wantURL || ri.Stage != "release" {
+			t.Errorf("%s: got %#v, want %s", tt.appID, ri, tt.wantURL)
+		}
+	}
+	if n := listed.Load(); n != 1 {
+		t.Errorf("report listing fetched %d times, want 1 (only as fallback)", n)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer srv.Close()
+
+	// One request per 1000s: the first uses the initial token, and the limiter refuses the
+	// next up front because its wait would outlast the context deadline, so no clock is involved
+	iqClient, err := NewClient(srv.URL+"/api/v2", "u", "p", newTestLogger(), WithRateLimit(0.001))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	if _, err := iqClient.GetOrganizations(rCtx(t)); err != nil {
+		t.Fatalf("GetOrganizations error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := iqClient.GetOrganizations(ctx); err == nil || !strings.Contains(err.Error(), "rate limit wait") {
+		t.Errorf("second request error = %v, want the rate limit to refuse it", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server saw %d requests, want 1 within the window", got)
+	}
+
+	// A cancelled context aborts the wait instead of blocking.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := iqClient.GetOrganizations(ctx); err == nil {
+		t.Errorf("expected error for cancelled context")
+	}
+}
+
+func TestGetLatestReportInfo_NoScanShapesSkip(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr bool
+	}{
+		{"204", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }, false},
+		{"empty array", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("[]")) }, false},
+		{"empty object", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("{}")) }, false},
+		{"404", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "No reports found for application", http.StatusNotFound)
+		}, false},
+		{"404 unknown application", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Application not found", http.StatusNotFound)
+		}, true},
+		{"404 wrong path", http.NotFound, true},
+		{"500", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "boom", http.StatusInternalServerError) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			cl, err := NewClient(srv.URL, "u", "p", newTestLogger())
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+
+			info, err := cl.GetLatestReportInfo(rCtx(t), "aid-1", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetLatestReportInfo error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantErr && !errors.As(err, &apiErr) {
+				t.Errorf("error = %T, want *APIError", err)
+			}
+			if info != nil {
+				t.Errorf("info = %+v, want nil (skip)", info)
+			}
+		})
+	}
+}
+
+func TestGetLatestReportInfo_Selection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// reports[0] is older than reports[1]; the API order must not decide
+		_, _ = w.Write([]byte(`[
+			{"stage": "release", "reportHtmlUrl": "https://stub/report/old-release", "evaluationDate": "2026-01-01T00:00:00Z"},
+			{"stage": "build", "reportHtmlUrl": "https://stub/report/new-build", "evaluationDate": "2026-03-01T00:00:00Z"},
+			{"stage": "release", "reportHtmlUrl": "https://stub/report/new-release", "evaluationDate": "2026-02-01T00:00:00Z"}
+		]`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		stage   string
+		wantURL string
+	}{
+		{"latest is the default", nil, "", "https://stub/report/new-build"},
+		{"latest within stage", []Option{WithReportSelection(SelectLatest, nil)}, "release", "https://stub/report/new-release"},
+		{"stage preference", []Option{WithReportSelection(SelectStage, nil)}, "", "https://stub/report/new-release"},
+		{"custom stage order", []Option{WithReportSelection(SelectStage, []string{"build", "release"})}, "", "https://stub/report/new-build"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient error = %v", err)
+			}
+			ri, err := iqClient.GetLatestReportInfo(rCtx(t), "app-1", tt.stage)
+			if err != nil {
+				t.Fatalf("GetLatestReportInfo error = %v", err)
+			}
+			if ri == nil || ri.ReportHTMLURL != tt.wantURL {
+				t.Errorf("got %#v, want %s", ri, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestParseToViolationRows_IncludeClean(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{
+		{DisplayName: "clean-lib 1.0", ComponentIdentifier: ComponentIdentifier{Format: "npm"}},
+		{DisplayName: "bad-lib 2.0", Violations: []Violation{{
+			PolicyName: "Security-High", PolicyThreatLevel: 9,
+			Constraints: []Constraint{{ConstraintName: "c", Conditions: []Condition{{ConditionSummary: "s"}}}},
+		}}},
+	}}
+
+	if rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator}); len(rows) != 1 || rows[0].Clean {
+		t.Fatalf("without includeClean: rows = %+v, want the single violation", rows)
+	}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{includeClean: true, conditionSep: DefaultConditionSeparator})
+	var clean []ViolationRow
+	for _, r := range rows {
+		if r.Clean {
+			clean = append(clean, r)
+		}
+	}
+	if len(rows) != 2 || len(clean) != 1 {
+		t.Fatalf("rows = %+v, want one violation and exactly one clean row", rows)
+	}
+	want := ViolationRow{Application: "app", Organization: "org", Format: "npm", Component: "clean-lib 1.0", Name: "clean-lib", Version: "1.0", Clean: true,
+		ComponentRef: ComponentRef{Identifier: &ComponentIdentifier{Format: "npm"}}}
+	if !reflect.DeepEqual(clean[0], want) {
+		t.Errorf("clean row = %+v, want %+v", clean[0], want)
+	}
+}
+
+func TestNewClient_UserAgent(t *testing.T) {
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		opts []Option
+		want string
+	}{
+		{nil, DefaultUserAgent},
+		{[]Option{WithUserAgent("")}, DefaultUserAgent},
+		{[]Option{WithUserAgent("iqfetch/v1.2.3")}, "iqfetch/v1.2.3"},
+	} {
+		iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), tt.opts...)
+		if err != nil {
+			t.Fatalf("NewClient error = %v", err)
+		}
+		if _, err := iqClient.GetOrganizations(rCtx(t)); err != nil {
+			t.Fatalf("GetOrganizations error = %v", err)
+		}
+		if gotUA != tt.want {
+			t.Errorf("User-Agent = %q, want %q", gotUA, tt.want)
+		}
+	}
+}
+
+func TestParseToViolationRows_ConditionSeparator(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{{
+		PolicyName: "Security-High", PolicyThreatLevel: 9,
+		Constraints: []Constraint{{ConstraintName: "c", Conditions: []Condition{{ConditionSummary: "a"}, {ConditionSummary: "b"}}}},
+	}}}}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: "; "})
+	if len(rows) != 1 || rows[0].Condition != "a; b" || !reflect.DeepEqual(rows[0].Conditions, []string{"a", "b"}) {
+		t.Errorf("rows = %+v, want Condition %q and Conditions [a b]", rows, "a; b")
+	}
+}
+
+func TestParseToViolationRows_Occurrences(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{{
+		PolicyName: "Security-High", PolicyThreatLevel: 9,
+		Constraints: []Constraint{
+			{ConstraintName: "High", Conditions: []Condition{
+				{ConditionSummary: "Security Vulnerability Severity >= 7", ConditionReason: "Found Security Vulnerability CVE-2024-1"},
+				{ConditionSummary: "Security Vulnerability Severity >= 7", ConditionReason: "Found Security Vulnerability CVE-2024-2"},
+				{ConditionSummary: "Security Vulnerability Severity >= 7", ConditionReason: "Found Security Vulnerability CVE-2024-3"},
+			}},
+			{ConstraintName: "Empty"},
+		},
+	}}}}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	if rows[0].Occurrences != 3 {
+		t.Errorf("Occurrences = %d, want 3", rows[0].Occurrences)
+	}
+	if rows[1].Occurrences != 0 {
+		t.Errorf("constraint without conditions: Occurrences = %d, want 0", rows[1].Occurrences)
+	}
+}
+
+func TestParseToViolationRows_Reasons(t *testing.T) {
+	var raw PolicyViolationReport
+	if err := json.Unmarshal([]byte(`{"components":[{"displayName":"lib 1.0","violations":[
+		{"policyName":"Security-High","policyThreatLevel":9,"constraints":[{"constraintName":"High","conditions":[
+			{"conditionSummary":"Security Vulnerability Severity >= 7","reasons":[
+				{"reason":"Found security vulnerability with severity 9.8","reference":{"value":"CVE-2021-44228","type":"SECURITY_VULNERABILITY_REFID"}},
+				{"reason":"Found security vulnerability CVE-2021-45046","reference":{"value":"CVE-2021-45046","type":"SECURITY_VULNERABILITY_REFID"}}]},
+			{"conditionSummary":"Security Vulnerability Severity >= 7","reasons":[
+				{"reason":"Found security vulnerability with severity 9.8","reference":{"value":"CVE-2021-44228","type":"SECURITY_VULNERABILITY_REFID"}},
+				{"reason":"Component is older than 2 years"}]}]},
+		{"constraintName":"No reasons","conditions":[{"conditionSummary":"Age >= 2 years"}]}]}]}]}`), &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	want := []string{
+		"Found security vulnerability with severity 9.8 (CVE-2021-44228)",
+		"Found security vulnerability CVE-2021-45046",
+		"Component is older than 2 years",
+	}
+	if !slices.Equal(rows[0].Reasons, want) {
+		t.Errorf("Reasons = %q, want %q", rows[0].Reasons, want)
+	}
+	if rows[1].Reasons != nil {
+		t.Errorf("constraint without reasons: Reasons = %q, want nil", rows[1].Reasons)
+	}
+}
+
+func TestParseToViolationRows_FractionalThreat(t *testing.T) {
+	var raw PolicyViolationReport
+	if err := json.Unmarshal([]byte(`{"components":[{"displayName":"lib 1.0","violations":[
+		{"policyName":"Security-High","policyThreatLevel":7.5,"constraints":[{"constraintName":"c"}]}]}]}`), &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 1 || rows[0].Threat != 7 || rows[0].ThreatRaw != 7.5 {
+		t.Errorf("rows = %+v, want Threat 7 and ThreatRaw 7.5", rows)
+	}
+}
+
+func TestParseToViolationRows_PolicyAction(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{
+		{PolicyName: "Security-High", PolicyThreatLevel: 9, PolicyAction: "fail", Constraints: []Constraint{{ConstraintName: "a"}}},
+		{PolicyName: "License", PolicyThreatLevel: 4, Constraints: []Constraint{{ConstraintName: "b"}}},
+	}}}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 2 || rows[0].PolicyAction != "fail" || rows[1].PolicyAction != "License-4" {
+		t.Errorf("rows = %+v, want the real action and the category fallback", rows)
+	}
+}
+
+func TestParseToViolationRows_LicenseViolation(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{
+		{PolicyName: "License-Banned", PolicyThreatLevel: 10, Constraints: []Constraint{{
+			ConstraintName: "Banned license",
+			Conditions: []Condition{
+				{ConditionSummary: "License Threat Group is Banned", ConditionReason: "Found 2 Licenses in Banned: GPL-3.0, 'AGPL-3.0'"},
+				{ConditionSummary: "License Threat Group is Banned", ConditionReason: "Observed license: GPL-3.0"},
+			},
+		}}},
+		{PolicyName: "Architecture-Quality", PolicyThreatLevel: 3, Constraints: []Constraint{{
+			ConstraintName: "Old", Conditions: []Condition{{ConditionSummary: "Age older than 5 years"}},
+		}}},
+		{PolicyName: "Security-High", PolicyThreatLevel: 8, Constraints: []Constraint{{
+			ConstraintName: "High", Conditions: []Condition{{ConditionSummary: "Security Vulnerability Severity >= 7", ConditionReason: "Found: CVE-2024-1"}},
+		}}},
+	}}}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3", len(rows))
+	}
+	if rows[0].License != "GPL-3.0, AGPL-3.0" || rows[0].PolicyAction != "License-10" {
+		t.Errorf("license row = License %q, PolicyAction %q", rows[0].License, rows[0].PolicyAction)
+	}
+	if rows[1].License != "" || rows[1].PolicyAction != "Policy-3" {
+		t.Errorf("other row = License %q, PolicyAction %q", rows[1].License, rows[1].PolicyAction)
+	}
+	if rows[2].License != "" || rows[2].PolicyAction != "Security-8" {
+		t.Errorf("security row = License %q, PolicyAction %q", rows[2].License, rows[2].PolicyAction)
+	}
+}
+
+func TestParseToViolationRows_CVEs(t *testing.T) {
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{
+		{PolicyName: "Security-Critical", PolicyThreatLevel: 10, Constraints: []Constraint{
+			{ConstraintName: "Critical", Conditions: []Condition{
+				{ConditionSummary: "Security Vulnerability Severity >= 9", ConditionReason: "Found Security Vulnerability CVE-2023-44487 with Severity >= 9"},
+				{ConditionSummary: "Security Vulnerability Severity >= 9", ConditionReason: "Found Security Vulnerabilities CVE-2021-44228, CVE-2023-44487 and CVE-2021-45046"},
+			}},
+			{ConstraintName: "Sonatype", Conditions: []Condition{
+				{ConditionSummary: "Security Vulnerability Severity >= 9", ConditionReason: "Found Security Vulnerability sonatype-2020-0001"},
+			}},
+		}},
+	}}}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	want := []string{"CVE-2021-44228", "CVE-2021-45046", "CVE-2023-44487"}
+	if !slices.Equal(rows[0].CVEs, want) {
+		t.Errorf("CVEs = %v, want %v", rows[0].CVEs, want)
+	}
+	if rows[0].CVE != "CVE-2021-44228; CVE-2021-45046; CVE-2023-44487" {
+		t.Errorf("CVE = %q", rows[0].CVE)
+	}
+	if rows[1].CVEs != nil || rows[1].CVE != "" {
+		t.Errorf("row without CVEs: CVEs = %v, CVE = %q", rows[1].CVEs, rows[1].CVE)
+	}
+}
+
+func TestGetPolicyViolations_FollowsPages(t *testing.T) {
+	component := func(name string) string {
+		return `{"displayName":"` + name + `","componentIdentifier":{"format":"npm"},"violations":[
+			{"policyName":"Security-High","policyThreatLevel":8,"constraints":[{"constraintName":"c","conditions":[{"conditionSummary":"s"}]}]}]}`
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requested = append(requested, q.Get("page")+"/"+q.Get("pageSize"))
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("page") {
+		case "1":
+			_, _ = w.Write([]byte(`{"reportTime":1700000000000,"page":1,"pageCount":2,"components":[` + component("comp-a") + `]}`))
+		case "2":
+			_, _ = w.Write([]byte(`{"reportTime":1700000000000,"page":2,"pageCount":2,"components":[` + component("comp-b") + `]}`))
+		default:
+			http.Error(w, "unexpected page", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithPolicyPageSize(1))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	rows, err := iqClient.GetPolicyViolations(rCtx(t), "app", "rpt", "org")
+	if err != nil {
+		t.Fatalf("GetPolicyViolations error = %v", err)
+	}
+	if want := []string{"1/1", "2/1"}; !slices.Equal(requested, want) {
+		t.Errorf("requested pages %v, want %v", requested, want)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Component)
+	}
+	if want := []string{"comp-a", "comp-b"}; !slices.Equal(got, want) {
+		t.Errorf("components = %v, want %v", got, want)
+	}
+}
+
+func TestGetPolicyViolations_UnpagedResponse(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"reportTime":1700000000000,"components":[]}`))
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	if _, err := iqClient.GetPolicyViolations(rCtx(t), "app", "rpt", "org"); err != nil || calls != 1 {
+		t.Errorf("err = %v calls = %d, want one request", err, calls)
+	}
+}
+
+func TestGetOrganization(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/organizations/org-1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"org-1","name":"personal"}`))
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	org, err := iqClient.GetOrganization(rCtx(t), "org-1")
+	if err != nil || org.Name != "personal" {
+		t.Fatalf("GetOrganization = %+v, %v", org, err)
+	}
+	if _, err := iqClient.GetOrganization(rCtx(t), "org-2"); !IsNotFound(err) {
+		t.Errorf("unknown org error = %v, want HTTP 404", err)
+	}
+}
+
+func TestGetOrganizationsByIDs_Cache(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/organizations/")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":%q,"name":"name-%s"}`, id, id)
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	now := time.Unix(0, 0)
+	iqClient.orgs = newOrgCache(func() time.Time { return now })
+
+	lookup := func(ids ...string) {
+		t.Helper()
+		orgs, err := iqClient.GetOrganizationsByIDs(rCtx(t), ids)
+		if err != nil || len(orgs) != len(ids) {
+			t.Fatalf("GetOrganizationsByIDs(%v) = %+v, %v", ids, orgs, err)
+		}
+	}
+	lookup("org-1", "org-2")
+	lookup("org-1", "org-3") // only org-3 is fetched
+	if n := hits.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+
+	now = now.Add(orgCacheTTL)
+	lookup("org-1")
+	if n := hits.Load(); n != 4 {
+		t.Errorf("%d requests, want an expired entry fetched again", n)
+	}
+}
@@ -0,0 +1,58 @@
+// internal/client/coordinates.go
+package client
+
+import "strings"
+
+// Coordinates holds the structured identity of a component. IQ uses
+// groupId/artifactId/version for maven, name/version for pypi and similar
+// ecosystems, and packageId/version for npm and nuget.
+type Coordinates struct {
+	GroupID    string `json:"groupId,omitempty"`
+	ArtifactID string `json:"artifactId,omitempty"`
+	Name       string `json:"name,omitempty"`
+	PackageID  string `json:"packageId,omitempty"`
+	Version    string `json:"version,omitempty"`
+	// Extension and Classifier refine maven coordinates; they are kept so the
+	// identifier can be sent back to IQ unchanged.
+	Extension  string `json:"extension,omitempty"`
+	Classifier string `json:"classifier,omitempty"`
+}
+
+// gav collapses the ecosystem-specific coordinate fields into group, name and version.
+func (c Coordinates) gav() (group, name, version string) {
+	name = c.ArtifactID
+	if name == "" {
+		name = c.Name
+	}
+	if name == "" {
+		name = c.PackageID
+	}
+	return c.GroupID, name, c.Version
+}
+
+// componentGAV returns the group, name and version of a component, preferring the
+// structured coordinates and falling back to parsing DisplayName when they are absent.
+func componentGAV(comp Component) (group, name, version string) {
+	if group, name, version = comp.Coordinates.gav(); name != "" {
+		return group, name, version
+	}
+	return parseDisplayName(comp.DisplayName)
+}
+
+// parseDisplayName extracts coordinates from IQ display names such as
+// "commons-io : commons-io : 2.4" (maven) or "setuptools 80.9.0 (.tar.gz)".
+func parseDisplayName(displayName string) (group, name, version string) {
+	s := strings.TrimSpace(displayName)
+	if i := strings.LastIndex(s, " ("); i > 0 && strings.HasSuffix(s, ")") {
+		s = s[:i]
+	}
+
+	if parts := strings.Split(s, " : "); len(parts) >= 3 {
+		return parts[0], parts[1], parts[2]
+	}
+
+	if i := strings.LastIndex(s, " "); i > 0 {
+		return "", s[:i], s[i+1:]
+	}
+	return "", s, ""
+}
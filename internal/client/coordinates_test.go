@@ -0,0 +1,54 @@
+// internal/client/coordinates_test.go
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestComponentGAV(t *testing.T) {
+	tests := []struct {
+		name                       string
+		raw                        string
+		wantGroup, wantName, wantV string
+	}{
+		{
+			name:      "maven coordinates",
+			raw:       `{"displayName":"ignored","componentIdentifier":{"format":"maven","coordinates":{"groupId":"commons-io","artifactId":"commons-io","version":"2.4","extension":"jar"}}}`,
+			wantGroup: "commons-io", wantName: "commons-io", wantV: "2.4",
+		},
+		{
+			name:     "pypi coordinates",
+			raw:      `{"displayName":"ignored","componentIdentifier":{"format":"pypi","coordinates":{"name":"setuptools","version":"80.9.0","extension":"tar.gz"}}}`,
+			wantName: "setuptools", wantV: "80.9.0",
+		},
+		{
+			name:     "npm coordinates",
+			raw:      `{"componentIdentifier":{"format":"npm","coordinates":{"packageId":"lodash","version":"4.17.20"}}}`,
+			wantName: "lodash", wantV: "4.17.20",
+		},
+		{
+			name:     "pypi display name fallback",
+			raw:      `{"displayName":"setuptools 80.9.0 (.tar.gz)","componentIdentifier":{"format":"pypi"}}`,
+			wantName: "setuptools", wantV: "80.9.0",
+		},
+		{
+			name:      "maven display name fallback",
+			raw:       `{"displayName":"org.apache : commons-text : 1.9","componentIdentifier":{"format":"maven"}}`,
+			wantGroup: "org.apache", wantName: "commons-text", wantV: "1.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var comp Component
+			if err := json.Unmarshal([]byte(tt.raw), &comp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			g, n, v := componentGAV(comp)
+			if g != tt.wantGroup || n != tt.wantName || v != tt.wantV {
+				t.Errorf("componentGAV = (%q, %q, %q), want (%q, %q, %q)", g, n, v, tt.wantGroup, tt.wantName, tt.wantV)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// internal/client/cve.go
+package client
+
+import (
+	"regexp"
+	"slices"
+)
+
+// cvePattern matches CVE identifiers such as CVE-2021-44228.
+var cvePattern = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// constraintCVEs extracts the CVE IDs named in a constraint's condition summaries and
+// reasons, sorted and without duplicates; nil when there are none.
+func constraintCVEs(c Constraint) []string {
+	var ids []string
+	for _, cond := range c.Conditions {
+		ids = append(ids, cvePattern.FindAllString(cond.ConditionSummary, -1)...)
+		ids = append(ids, cvePattern.FindAllString(cond.ConditionReason, -1)...)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
@@ -0,0 +1,94 @@
+// internal/client/errors.go
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ErrMalformedResponse is wrapped by the *APIError returned when a response body cannot be
+// decoded, e.g. truncated or invalid JSON.
+var ErrMalformedResponse = errors.New("malformed response body")
+
+// Causes of a request that got no response; the *APIError of such a failure wraps one of them.
+var (
+	// ErrTimeout: a deadline on our side fired first, the context's or the HTTP client's.
+	ErrTimeout = errors.New("request timed out")
+	// ErrCanceled: the request's context was cancelled, e.g. the run was interrupted.
+	ErrCanceled = errors.New("request canceled")
+	// ErrNetwork: the connection failed, e.g. it was refused, reset or closed by the server.
+	ErrNetwork = errors.New("network error")
+)
+
+// APIError describes a failed IQ Server API call. StatusCode is zero when no
+// response was received (transport failure, timeout or cancellation), in which
+// case Err holds the cause.
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	// Message is the HTTP status text or response body returned by the server.
+	Message string
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("request failed: %v", e.Err)
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// IsUnauthorized reports whether err is, or wraps, an API error with HTTP 401.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether err is, or wraps, an API error with HTTP 403.
+func IsForbidden(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound reports whether err is, or wraps, an API error with HTTP 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// transportError returns the *APIError for a request to endpoint that got no response,
+// tagging err with ErrTimeout, ErrCanceled or ErrNetwork. An open circuit breaker and
+// errors of unknown origin are kept as they are.
+func transportError(endpoint string, err error) *APIError {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+	case errors.Is(err, context.Canceled):
+		err = fmt.Errorf("%w: %w", ErrCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		err = fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		err = fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return &APIError{Endpoint: endpoint, Err: err}
+}
+
+// FailureCause names why err's request got no response: "timeout", "canceled" or
+// "network", or "" when err is none of these.
+func FailureCause(err error) string {
+	switch {
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ErrCanceled):
+		return "canceled"
+	case errors.Is(err, ErrNetwork):
+		return "network"
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+// internal/client/errors_test.go
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransportErrorCause(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	hangUp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer hangUp.Close()
+
+	tests := []struct {
+		name   string
+		url    string
+		ctx    func() (context.Context, context.CancelFunc)
+		want   error
+		others []error
+		cause  string
+	}{
+		{
+			name: "context deadline",
+			url:  slow.URL,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			want: ErrTimeout, others: []error{ErrCanceled, ErrNetwork}, cause: "timeout",
+		},
+		{
+			name: "cancelled",
+			url:  slow.URL,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			want: ErrCanceled, others: []error{ErrTimeout, ErrNetwork}, cause: "canceled",
+		},
+		{
+			name: "connection closed by server",
+			url:  hangUp.URL,
+			ctx:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			want: ErrNetwork, others: []error{ErrTimeout, ErrCanceled}, cause: "network",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iqClient, err := NewClient(tt.url+"/api/v2", "u", "p", newTestLogger())
+			if err != nil {
+				t.Fatalf("NewClient error = %v", err)
+			}
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, err = iqClient.GetApplications(ctx, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 0 {
+				t.Fatalf("error = %v, want an *APIError without status", err)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.want)
+			}
+			for _, other := range tt.others {
+				if errors.Is(err, other) {
+					t.Errorf("error = %v, unexpectedly wraps %v", err, other)
+				}
+			}
+			if got := FailureCause(err); got != tt.cause {
+				t.Errorf("FailureCause = %q, want %q", got, tt.cause)
+			}
+		})
+	}
+}
+
+func TestTransportErrorKeepsOpenBreaker(t *testing.T) {
+	err := transportError("applications", ErrCircuitOpen)
+	if !errors.Is(err, ErrCircuitOpen) || FailureCause(err) != "" {
+		t.Errorf("transportError(ErrCircuitOpen) = %v (cause %q), want the breaker error untagged", err, FailureCause(err))
+	}
+}
@@ -0,0 +1,45 @@
+// internal/client/headers.go
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// protectedHeaders are set by the client itself and may not be overridden by WithHeaders.
+var protectedHeaders = []string{"Authorization"}
+
+// WithHeaders sends the given headers on every request, e.g. a token an API gateway in front
+// of IQ Server requires. Names are validated by CheckHeader when the client is built.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *clientOptions) {
+		o.headers = headers
+	}
+}
+
+// CheckHeader reports whether name and value form a valid custom header: name an HTTP token,
+// value free of line breaks, and name not one of the headers the client manages (Authorization).
+func CheckHeader(name, value string) error {
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return !isTokenChar(r) }) >= 0 {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("header %s: value contains a line break", name)
+	}
+	for _, p := range protectedHeaders {
+		if http.CanonicalHeaderKey(name) == p {
+			return fmt.Errorf("header %s is set by the client and cannot be overridden", p)
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether r may appear in an HTTP header name (RFC 9110 token).
+func isTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return r < 0x80 && strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
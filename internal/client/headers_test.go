@@ -0,0 +1,50 @@
+// internal/client/headers_test.go
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_CustomHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithHeaders(map[string]string{"X-Gateway-Token": "gw-secret"}))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	if _, err := iqClient.GetOrganizations(rCtx(t)); err != nil {
+		t.Fatalf("GetOrganizations error = %v", err)
+	}
+	if got.Get("X-Gateway-Token") != "gw-secret" {
+		t.Errorf("X-Gateway-Token = %q", got.Get("X-Gateway-Token"))
+	}
+	if user, pass, ok := (&http.Request{Header: got}).BasicAuth(); !ok || user != "u" || pass != "p" {
+		t.Errorf("Authorization = %q, want basic auth for u", got.Get("Authorization"))
+	}
+
+	for _, bad := range []map[string]string{{"authorization": "Bearer x"}, {"X Bad": "v"}, {"X-Ok": "a\r\nX-Injected: b"}} {
+		if _, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithHeaders(bad)); err == nil {
+			t.Errorf("NewClient accepted headers %q", bad)
+		}
+	}
+}
+
+func TestRedact_CustomHeaders(t *testing.T) {
+	h := http.Header{"X-Gateway-Token": {"gw-secret"}, "Authorization": {"Basic abc"}, "Accept": {"application/json"}}
+	out := redact(h, "X-Gateway-Token")
+	if out.Get("X-Gateway-Token") != "REDACTED" || out.Get("Authorization") != "REDACTED" || out.Get("Accept") != "application/json" {
+		t.Errorf("redacted headers = %v", out)
+	}
+	if strings.Contains(h.Get("X-Gateway-Token"), "REDACTED") {
+		t.Error("redact modified its input")
+	}
+}
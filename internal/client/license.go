@@ -0,0 +1,60 @@
+// internal/client/license.go
+package client
+
+import (
+	"slices"
+	"strings"
+)
+
+// Violation categories, used to synthesize PolicyAction when IQ omits it.
+const (
+	categorySecurity = "Security"
+	categoryLicense  = "License"
+	categoryPolicy   = "Policy"
+)
+
+// violationCategory classifies a violation by its conditions (IQ phrases license
+// conditions as "License ..." and security ones as "Security Vulnerability ..."),
+// falling back to the policy name, and to a generic policy category.
+func violationCategory(v Violation) string {
+	for _, c := range v.Constraints {
+		for _, cond := range c.Conditions {
+			summary := strings.ToLower(cond.ConditionSummary)
+			switch {
+			case strings.HasPrefix(summary, "license"):
+				return categoryLicense
+			case strings.HasPrefix(summary, "security"):
+				return categorySecurity
+			}
+		}
+	}
+	name := strings.ToLower(strings.TrimSpace(v.PolicyName))
+	switch {
+	case strings.HasPrefix(name, "license"):
+		return categoryLicense
+	case strings.HasPrefix(name, "security"):
+		return categorySecurity
+	}
+	return categoryPolicy
+}
+
+// constraintLicenses extracts the license IDs named by a license constraint's condition
+// reasons, e.g. "Found 2 Licenses: GPL-3.0, AGPL-3.0" yields GPL-3.0 and AGPL-3.0,
+// in order and without duplicates.
+func constraintLicenses(c Constraint) []string {
+	var ids []string
+	for _, cond := range c.Conditions {
+		reason := cond.ConditionReason
+		i := strings.LastIndex(reason, ":")
+		if i < 0 {
+			continue
+		}
+		for _, id := range strings.Split(reason[i+1:], ",") {
+			id = strings.Trim(strings.TrimSpace(id), `'"().`)
+			if id != "" && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,127 @@
+// internal/client/observer.go
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RequestInfo describes one attempt of an IQ request to a RequestObserver.
+type RequestInfo struct {
+	Method string
+	// Endpoint is the path template relative to the API base, as in EndpointStats,
+	// e.g. "reports/applications/{id}".
+	Endpoint string
+	// Attempt is 1 for the first try and counts the retries of WithRetries.
+	Attempt int
+}
+
+// RequestResult is the outcome of one attempt of an IQ request.
+type RequestResult struct {
+	// StatusCode is 0 when no response was received.
+	StatusCode int
+	Duration   time.Duration
+	// Err is set when the attempt failed without a usable response (network error,
+	// cancellation, open circuit breaker, undecodable body); error statuses alone leave it nil.
+	Err error
+}
+
+// RequestObserver is notified around every attempt of every request, e.g. to wrap them in
+// tracing spans without this package depending on a tracing library. RequestStart runs
+// after any rate limit wait and returns the context for the attempt (the request carries
+// it, so a tracing transport sees the span); RequestEnd receives that context. Every
+// RequestStart is matched by exactly one RequestEnd. Both are called concurrently for
+// different requests.
+type RequestObserver interface {
+	RequestStart(ctx context.Context, info RequestInfo) context.Context
+	RequestEnd(ctx context.Context, info RequestInfo, result RequestResult)
+}
+
+// nopObserver is the RequestObserver used when WithRequestObserver is not.
+type nopObserver struct{}
+
+func (nopObserver) RequestStart(ctx context.Context, _ RequestInfo) context.Context { return ctx }
+func (nopObserver) RequestEnd(context.Context, RequestInfo, RequestResult)          {}
+
+// WithRequestObserver notifies obs around every request attempt; nil keeps the no-op default.
+func WithRequestObserver(obs RequestObserver) Option {
+	return func(o *clientOptions) {
+		if obs != nil {
+			o.observer = obs
+		}
+	}
+}
+
+// observationKey carries the attempt in flight in the request context.
+type observationKey struct{}
+
+// observation is one attempt handed to RequestStart and not yet ended.
+type observation struct {
+	// parent is the request's context before RequestStart, so a retry starts from it
+	// rather than nesting under the failed attempt.
+	parent context.Context
+	ctx    context.Context
+	info   RequestInfo
+	start  time.Time
+	ended  bool
+}
+
+// observeRequests registers the resty hooks that drive obs. The hooks of one request run
+// sequentially, so an observation needs no locking; end is idempotent because a failed
+// attempt may be seen by both the retry and the error hooks.
+func observeRequests(r *resty.Client, obs RequestObserver, basePath string) {
+	end := func(ctx context.Context, status int, err error) {
+		o, ok := ctx.Value(observationKey{}).(*observation)
+		if !ok || o.ended {
+			return
+		}
+		o.ended = true
+		obs.RequestEnd(o.ctx, o.info, RequestResult{StatusCode: status, Duration: time.Since(o.start), Err: err})
+	}
+
+	r.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+		parent := req.Context()
+		if prev, ok := parent.Value(observationKey{}).(*observation); ok {
+			parent = prev.parent
+		}
+		info := RequestInfo{Method: req.Method, Endpoint: requestEndpoint(basePath, req.URL), Attempt: req.Attempt}
+		o := &observation{parent: parent, info: info, start: time.Now()}
+		o.ctx = context.WithValue(obs.RequestStart(parent, info), observationKey{}, o)
+		req.SetContext(o.ctx)
+		return nil
+	})
+	r.OnAfterResponse(func(c *resty.Client, resp *resty.Response) error {
+		end(resp.Request.Context(), resp.StatusCode(), nil)
+		return nil
+	})
+	r.AddRetryHook(func(resp *resty.Response, err error) {
+		if resp != nil && resp.Request != nil {
+			end(resp.Request.Context(), resp.StatusCode(), err)
+		}
+	})
+	r.OnError(func(req *resty.Request, err error) {
+		status := 0
+		var respErr *resty.ResponseError
+		if errors.As(err, &respErr) && respErr.Response != nil {
+			status = respErr.Response.StatusCode()
+		}
+		end(req.Context(), status, err)
+	})
+}
+
+// requestEndpoint templates the URL a request was made with: normally a path relative to
+// the base URL, or an absolute URL under it.
+func requestEndpoint(basePath, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.IsAbs() || len(u.Path) > 0 && u.Path[0] == '/' {
+		return endpointTemplate(basePath, u.Path)
+	}
+	return endpointTemplate("", u.Path)
+}
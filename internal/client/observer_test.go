@@ -0,0 +1,159 @@
+// internal/client/observer_test.go
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type spanKey struct{}
+
+// recordingObserver records every attempt and checks RequestEnd gets RequestStart's context.
+type recordingObserver struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	starts int
+	ends   []observed
+}
+
+type observed struct {
+	info   RequestInfo
+	result RequestResult
+}
+
+func (o *recordingObserver) RequestStart(ctx context.Context, info RequestInfo) context.Context {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.starts++
+	return context.WithValue(ctx, spanKey{}, info)
+}
+
+func (o *recordingObserver) RequestEnd(ctx context.Context, info RequestInfo, result RequestResult) {
+	if span, ok := ctx.Value(spanKey{}).(RequestInfo); !ok || span != info {
+		o.t.Errorf("RequestEnd for %+v got the context of %+v", info, span)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ends = append(o.ends, observed{info, result})
+}
+
+func (o *recordingObserver) take() (int, []observed) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	starts, ends := o.starts, o.ends
+	o.starts, o.ends = 0, nil
+	return starts, ends
+}
+
+func TestRequestObserver(t *testing.T) {
+	var orgHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/applications/organization/org-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"applications":[]}`))
+	})
+	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
+		if orgHits.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	})
+	mux.HandleFunc("/api/v2/reports/applications/aid-1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	obs := &recordingObserver{t: t}
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(),
+		WithRequestObserver(obs), WithRetries(1, time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	orgID := "org-1"
+
+	tests := []struct {
+		name string
+		call func() error
+		want []observed
+	}{
+		{
+			name: "success",
+			call: func() error { _, err := iqClient.GetApplications(rCtx(t), &orgID); return err },
+			want: []observed{{RequestInfo{http.MethodGet, "applications/organization/{id}", 1}, RequestResult{StatusCode: 200}}},
+		},
+		{
+			name: "retried",
+			call: func() error { _, err := iqClient.GetOrganizations(rCtx(t)); return err },
+			want: []observed{
+				{RequestInfo{http.MethodGet, "organizations", 1}, RequestResult{StatusCode: 503}},
+				{RequestInfo{http.MethodGet, "organizations", 2}, RequestResult{StatusCode: 200}},
+			},
+		},
+		{
+			name: "error status",
+			call: func() error {
+				if _, err := iqClient.GetLatestReportInfo(rCtx(t), "aid-1", ""); err == nil {
+					return errors.New("expected error for 500")
+				}
+				return nil
+			},
+			want: []observed{
+				{RequestInfo{http.MethodGet, "reports/applications/{id}", 1}, RequestResult{StatusCode: 500}},
+				{RequestInfo{http.MethodGet, "reports/applications/{id}", 2}, RequestResult{StatusCode: 500}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			starts, ends := obs.take()
+			if starts != len(ends) {
+				t.Errorf("%d starts, %d ends", starts, len(ends))
+			}
+			got := slices.Clone(ends)
+			for i := range got {
+				if got[i].result.Duration <= 0 {
+					t.Errorf("attempt %d: duration = %v", i+1, got[i].result.Duration)
+				}
+				got[i].result.Duration = 0
+			}
+			if !slices.EqualFunc(got, tt.want, func(a, b observed) bool { return a.info == b.info && a.result == b.result }) {
+				t.Errorf("observed %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestObserver_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // connection refused
+
+	obs := &recordingObserver{t: t}
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithRequestObserver(obs))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	if _, err := iqClient.GetOrganizations(rCtx(t)); err == nil {
+		t.Fatal("expected error for an unreachable server")
+	}
+	starts, ends := obs.take()
+	if starts != 1 || len(ends) != 1 {
+		t.Fatalf("%d starts, %d ends, want 1 each", starts, len(ends))
+	}
+	if r := ends[0].result; r.StatusCode != 0 || r.Err == nil {
+		t.Errorf("result = %+v, want no status and an error", r)
+	}
+}
@@ -0,0 +1,52 @@
+// internal/client/orgcache.go
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// orgCacheTTL is how long GetOrganizationsByIDs reuses a resolved organization, so the
+// repeated runs of a long-lived client skip the lookups yet still pick up renames.
+const orgCacheTTL = time.Hour
+
+// orgCache remembers organizations resolved by ID; it is safe for concurrent use.
+type orgCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]orgCacheEntry
+}
+
+type orgCacheEntry struct {
+	org     Organization
+	expires time.Time
+}
+
+func newOrgCache(now func() time.Time) *orgCache {
+	if now == nil {
+		now = time.Now
+	}
+	return &orgCache{now: now, entries: make(map[string]orgCacheEntry)}
+}
+
+// get returns the cached organization with the given ID unless it has expired.
+func (c *orgCache) get(id string) (Organization, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok || !c.now().Before(e.expires) {
+		delete(c.entries, id)
+		return Organization{}, false
+	}
+	return e.org, true
+}
+
+// put caches orgs for orgCacheTTL.
+func (c *orgCache) put(orgs []Organization) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(orgCacheTTL)
+	for _, org := range orgs {
+		c.entries[org.ID] = orgCacheEntry{org: org, expires: expires}
+	}
+}
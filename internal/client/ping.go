@@ -0,0 +1,46 @@
+// internal/client/ping.go
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnreachable is returned by Ping when no HTTP response was received.
+	ErrUnreachable = errors.New("cannot reach IQ Server")
+	// ErrBadCredentials is returned by Ping when the server rejects the credentials (HTTP 401).
+	ErrBadCredentials = errors.New("IQ Server rejected the credentials")
+	// ErrPermissionDenied is wrapped by errors for HTTP 403 on a call the run cannot do
+	// without, such as Ping or listing applications: the credentials were accepted, but
+	// the user lacks permission for what was asked.
+	ErrPermissionDenied = errors.New("credentials are valid but lack permission")
+)
+
+// Ping performs one cheap authenticated request to confirm the server is reachable and
+// the credentials are valid. Connection failures wrap ErrUnreachable, HTTP 401 wraps
+// ErrBadCredentials and HTTP 403 ErrPermissionDenied; all also wrap the underlying *APIError.
+func (c *Client) Ping(ctx context.Context) error {
+	const endpoint = "organizations"
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetQueryParam("page", "1").
+		Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("%w at %s: %w", ErrUnreachable, c.baseURL, transportError(endpoint, err))
+	}
+
+	apiErr := &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
+	switch {
+	case resp.StatusCode() == http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", ErrBadCredentials, apiErr)
+	case resp.StatusCode() == http.StatusForbidden:
+		return fmt.Errorf("%w to list organizations: %w", ErrPermissionDenied, apiErr)
+	case resp.IsError():
+		return fmt.Errorf("ping %s: %w", c.baseURL, apiErr)
+	}
+	c.logger.Debug().Str("baseURL", c.baseURL).Msg("IQ Server reachable")
+	return nil
+}
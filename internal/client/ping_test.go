@@ -0,0 +1,64 @@
+// internal/client/ping_test.go
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	okSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok || r.URL.Path != "/api/v2/organizations" {
+			http.Error(w, "unexpected", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	defer okSrv.Close()
+
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer authSrv.Close()
+
+	forbiddenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer forbiddenSrv.Close()
+
+	// A closed server refuses connections on its former address.
+	deadSrv := httptest.NewServer(http.NotFoundHandler())
+	deadURL := deadSrv.URL
+	deadSrv.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"reachable", okSrv.URL, nil},
+		{"unauthorized", authSrv.URL, ErrBadCredentials},
+		{"forbidden", forbiddenSrv.URL, ErrPermissionDenied},
+		{"connection refused", deadURL, ErrUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl, err := NewClient(tt.url, "u", "p", newTestLogger())
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			err = cl.Ping(rCtx(t))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Ping() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == ErrBadCredentials && !IsUnauthorized(err) {
+				t.Errorf("IsUnauthorized(%v) = false", err)
+			}
+			if tt.wantErr == ErrPermissionDenied && (!IsForbidden(err) || errors.Is(err, ErrBadCredentials)) {
+				t.Errorf("Ping() = %v, want a 403 distinct from bad credentials", err)
+			}
+		})
+	}
+}
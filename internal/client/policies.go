@@ -0,0 +1,70 @@
+// internal/client/policies.go
+package client
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// Policy is a policy defined on IQ Server.
+type Policy struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	OwnerID     string `json:"ownerId"`
+	OwnerType   string `json:"ownerType"`
+	ThreatLevel int    `json:"threatLevel"`
+	PolicyType  string `json:"policyType"`
+}
+
+type policiesEnvelope struct {
+	Policies []Policy `json:"policies"`
+}
+
+// GetPolicies lists the policies defined on the server, across all owners.
+func (c *Client) GetPolicies(ctx context.Context) ([]Policy, error) {
+	c.logger.Debug().Msg("Fetching policies")
+
+	const endpoint = "policies"
+	var env policiesEnvelope
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetResult(&env).
+		Get(endpoint)
+	if err != nil {
+		return nil, transportError(endpoint, err)
+	}
+	if resp.IsError() {
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.String()}
+	}
+
+	c.logger.Debug().Int("count", len(env.Policies)).Msg("Retrieved policies")
+	return env.Policies, nil
+}
+
+// UnmatchedPolicyPatterns returns the patterns, in the syntax of WithPolicyFilter, that match
+// none of the policies' names, in their given order.
+func UnmatchedPolicyPatterns(patterns []string, policies []Policy) []string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = strings.ToLower(p.Name)
+	}
+	var unmatched []string
+	for _, pattern := range patterns {
+		p := strings.ToLower(strings.TrimSpace(pattern))
+		if p == "" {
+			continue
+		}
+		found := false
+		for _, name := range names {
+			if ok, _ := path.Match(p, name); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return unmatched
+}
@@ -0,0 +1,44 @@
+// internal/client/policies_test.go
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGetPolicies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/policies" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"policies":[
+			{"id":"p1","name":"Security-High","ownerId":"ROOT_ORGANIZATION_ID","ownerType":"ORGANIZATION","threatLevel":9,"policyType":"security"},
+			{"id":"p2","name":"License-Banned","ownerId":"ROOT_ORGANIZATION_ID","ownerType":"ORGANIZATION","threatLevel":10,"policyType":"license"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	policies, err := c.GetPolicies(rCtx(t))
+	if err != nil {
+		t.Fatalf("GetPolicies: %v", err)
+	}
+	if len(policies) != 2 || policies[0].Name != "Security-High" || policies[1].ThreatLevel != 10 {
+		t.Errorf("policies = %+v", policies)
+	}
+}
+
+func TestUnmatchedPolicyPatterns(t *testing.T) {
+	policies := []Policy{{Name: "Security-High"}, {Name: "Security-Medium"}, {Name: "License-Banned"}}
+	got := UnmatchedPolicyPatterns([]string{"security-*", "Securty-High", " License-Banned ", "", "Architecture"}, policies)
+	if want := []string{"Securty-High", "Architecture"}; !slices.Equal(got, want) {
+		t.Errorf("unmatched = %v, want %v", got, want)
+	}
+}
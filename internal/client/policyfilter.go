@@ -0,0 +1,63 @@
+// internal/client/policyfilter.go
+package client
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// policyFilter keeps violations by policy name. Patterns are case-insensitive globs
+// (path.Match syntax, e.g. "Security-*"); exclude wins over include, and an empty
+// include list keeps every policy.
+type policyFilter struct {
+	include []string
+	exclude []string
+}
+
+// WithPolicyFilter restricts GetPolicyViolations to policies matching include and not
+// matching exclude. Patterns are case-insensitive globs such as "Security-*".
+func WithPolicyFilter(include, exclude []string) Option {
+	return func(o *clientOptions) {
+		o.policies = policyFilter{include: lowerAll(include), exclude: lowerAll(exclude)}
+	}
+}
+
+// validate reports the first malformed pattern.
+func (f policyFilter) validate() error {
+	for _, p := range slices.Concat(f.include, f.exclude) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid policy pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// keep reports whether violations of policy should be reported.
+func (f policyFilter) keep(policy string) bool {
+	policy = strings.ToLower(policy)
+	if matchAny(f.exclude, policy) {
+		return false
+	}
+	return len(f.include) == 0 || matchAny(f.include, policy)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerAll(ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
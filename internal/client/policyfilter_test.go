@@ -0,0 +1,48 @@
+// internal/client/policyfilter_test.go
+package client
+
+import "testing"
+
+func TestPolicyFilter(t *testing.T) {
+	f := policyFilter{include: lowerAll([]string{"Security-*", "License"}), exclude: lowerAll([]string{"Security-Low"})}
+	tests := map[string]bool{
+		"Security-High":   true,  // glob include
+		"security-medium": true,  // case-insensitive
+		"License":         true,  // exact include
+		"Security-Low":    false, // exact exclude wins over the glob include
+		"Architecture":    false, // not included
+	}
+	for policy, want := range tests {
+		if got := f.keep(policy); got != want {
+			t.Errorf("keep(%q) = %v, want %v", policy, got, want)
+		}
+	}
+
+	if !(policyFilter{}).keep("Anything") {
+		t.Error("empty filter should keep every policy")
+	}
+}
+
+func TestParseToViolationRows_PolicyFilter(t *testing.T) {
+	violation := func(policy string) Violation {
+		return Violation{PolicyName: policy, Constraints: []Constraint{{ConstraintName: "c"}}}
+	}
+	raw := PolicyViolationReport{Components: []Component{{DisplayName: "lib 1.0", Violations: []Violation{
+		violation("Security-High"), violation("Security-Low"), violation("Architecture-Quality"),
+	}}}}
+
+	opts := parseOptions{conditionSep: DefaultConditionSeparator, policies: policyFilter{
+		include: lowerAll([]string{"security-*"}),
+		exclude: lowerAll([]string{"Security-Low"}),
+	}}
+	rows := parseToViolationRows(raw, "app", "org", opts)
+	if len(rows) != 1 || rows[0].Policy != "Security-High" {
+		t.Errorf("rows = %+v, want only Security-High", rows)
+	}
+}
+
+func TestNewClient_InvalidPolicyPattern(t *testing.T) {
+	if _, err := NewClient("https://iq.example.com", "u", "p", newTestLogger(), WithPolicyFilter([]string{"Security-["}, nil)); err == nil {
+		t.Error("expected error for malformed policy pattern")
+	}
+}
@@ -0,0 +1,37 @@
+// internal/client/pool.go
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// ConnPool tunes the keep-alive connection pool of the client's HTTP transport.
+// Zero fields keep the transport's defaults.
+type ConnPool struct {
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle connections to IQ Server; it should cover the number of
+	// concurrent workers, or finished requests close connections the next one must reopen.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections left idle this long.
+	IdleConnTimeout time.Duration
+}
+
+// WithConnPool applies p to the client's HTTP transport.
+func WithConnPool(p ConnPool) Option {
+	return func(o *clientOptions) { o.pool = p }
+}
+
+// apply sets the non-zero pool settings on t.
+func (p ConnPool) apply(t *http.Transport) {
+	if p.MaxIdleConns > 0 {
+		t.MaxIdleConns = p.MaxIdleConns
+	}
+	if p.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = p.MaxIdleConnsPerHost
+	}
+	if p.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = p.IdleConnTimeout
+	}
+}
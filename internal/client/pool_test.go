@@ -0,0 +1,39 @@
+// internal/client/pool_test.go
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClient_ConnPoolApplied(t *testing.T) {
+	pool := ConnPool{MaxIdleConns: 64, MaxIdleConnsPerHost: 32, IdleConnTimeout: 45 * time.Second}
+	iqClient, err := NewClient("https://iq.example.com", "u", "p", newTestLogger(), WithConnPool(pool))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	tr, ok := iqClient.http.GetClient().Transport.(*http.Transport)
+	if !ok || tr != iqClient.transport {
+		t.Fatalf("resty transport = %T, want the client's pooled *http.Transport", iqClient.http.GetClient().Transport)
+	}
+	if tr.MaxIdleConns != 64 || tr.MaxIdleConnsPerHost != 32 || tr.IdleConnTimeout != 45*time.Second {
+		t.Errorf("transport pool = %d/%d/%v, want 64/32/45s", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+}
+
+func TestNewClient_ConnPoolBeneathBreaker(t *testing.T) {
+	iqClient, err := NewClient("https://iq.example.com", "u", "p", newTestLogger(),
+		WithConnPool(ConnPool{MaxIdleConnsPerHost: 20}),
+		WithCircuitBreaker(3, time.Minute, time.Minute))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	bt, ok := iqClient.http.GetClient().Transport.(*breakerTransport)
+	if !ok {
+		t.Fatalf("resty transport = %T, want *breakerTransport", iqClient.http.GetClient().Transport)
+	}
+	if bt.next != iqClient.transport || iqClient.transport.MaxIdleConnsPerHost != 20 {
+		t.Errorf("breaker should wrap the pooled transport with MaxIdleConnsPerHost 20")
+	}
+}
@@ -0,0 +1,85 @@
+// internal/client/purl.go
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageURL builds the package URL (purl, https://github.com/package-url/purl-spec) of
+// the identified component for the maven, npm, pypi, golang and nuget formats, e.g.
+// pkg:pypi/setuptools@80.9.0. It returns "" for other formats and when the coordinates
+// lack a name or version (or a maven groupId).
+func (id ComponentIdentifier) PackageURL() string {
+	c := id.Coordinates
+	if c.Version == "" {
+		return ""
+	}
+	var namespace, name, qualifiers string
+	switch strings.ToLower(id.Format) {
+	case "maven":
+		if c.GroupID == "" || c.ArtifactID == "" {
+			return ""
+		}
+		namespace, name = c.GroupID, c.ArtifactID
+		var q []string
+		if c.Classifier != "" {
+			q = append(q, "classifier="+purlEscape(c.Classifier))
+		}
+		if c.Extension != "" && c.Extension != "jar" {
+			q = append(q, "type="+purlEscape(c.Extension))
+		}
+		qualifiers = strings.Join(q, "&")
+	case "npm":
+		// Scoped packages (@scope/name) keep the scope as the namespace
+		name = strings.ToLower(c.PackageID)
+		if scope, rest, ok := strings.Cut(name, "/"); ok && strings.HasPrefix(scope, "@") {
+			namespace, name = scope, rest
+		}
+	case "pypi":
+		// The spec normalizes names to lowercase with dashes
+		name = strings.ReplaceAll(strings.ToLower(c.Name), "_", "-")
+	case "golang":
+		// Module paths split at the last slash: github.com/gorilla/mux -> github.com/gorilla + mux
+		name = c.Name
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	case "nuget":
+		name = c.PackageID
+	default:
+		return ""
+	}
+	if name == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:" + strings.ToLower(id.Format) + "/")
+	if namespace != "" {
+		for seg := range strings.SplitSeq(namespace, "/") {
+			b.WriteString(purlEscape(seg) + "/")
+		}
+	}
+	b.WriteString(purlEscape(name) + "@" + purlEscape(c.Version))
+	if qualifiers != "" {
+		b.WriteString("?" + qualifiers)
+	}
+	return b.String()
+}
+
+// purlEscape percent-encodes every byte of s except the unreserved characters
+// A-Z a-z 0-9 . - _ ~, as the purl spec requires for each component.
+func purlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '-', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
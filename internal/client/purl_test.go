@@ -0,0 +1,64 @@
+// internal/client/purl_test.go
+package client
+
+import "testing"
+
+func TestComponentIdentifier_PackageURL(t *testing.T) {
+	tests := []struct {
+		name string
+		id   ComponentIdentifier
+		want string
+	}{
+		{"maven", ComponentIdentifier{Format: "maven", Coordinates: Coordinates{GroupID: "commons-io", ArtifactID: "commons-io", Version: "2.4", Extension: "jar"}},
+			"pkg:maven/commons-io/commons-io@2.4"},
+		{"maven qualifiers", ComponentIdentifier{Format: "maven", Coordinates: Coordinates{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Classifier: "sources", Extension: "zip"}},
+			"pkg:maven/org.example/lib@1.0?classifier=sources&type=zip"},
+		{"maven without group", ComponentIdentifier{Format: "maven", Coordinates: Coordinates{ArtifactID: "lib", Version: "1.0"}}, ""},
+		{"pypi", ComponentIdentifier{Format: "pypi", Coordinates: Coordinates{Name: "setuptools", Version: "80.9.0"}},
+			"pkg:pypi/setuptools@80.9.0"},
+		{"pypi normalized", ComponentIdentifier{Format: "pypi", Coordinates: Coordinates{Name: "Typing_Extensions", Version: "4.12.2"}},
+			"pkg:pypi/typing-extensions@4.12.2"},
+		{"npm", ComponentIdentifier{Format: "npm", Coordinates: Coordinates{PackageID: "lodash", Version: "4.17.21"}},
+			"pkg:npm/lodash@4.17.21"},
+		{"npm scoped", ComponentIdentifier{Format: "npm", Coordinates: Coordinates{PackageID: "@angular/core", Version: "12.3.1"}},
+			"pkg:npm/%40angular/core@12.3.1"},
+		{"golang", ComponentIdentifier{Format: "golang", Coordinates: Coordinates{Name: "github.com/gorilla/mux", Version: "v1.8.0"}},
+			"pkg:golang/github.com/gorilla/mux@v1.8.0"},
+		{"golang incompatible", ComponentIdentifier{Format: "golang", Coordinates: Coordinates{Name: "github.com/docker/docker", Version: "v20.10.7+incompatible"}},
+			"pkg:golang/github.com/docker/docker@v20.10.7%2Bincompatible"},
+		{"nuget", ComponentIdentifier{Format: "nuget", Coordinates: Coordinates{PackageID: "Newtonsoft.Json", Version: "13.0.1"}},
+			"pkg:nuget/Newtonsoft.Json@13.0.1"},
+		{"no version", ComponentIdentifier{Format: "npm", Coordinates: Coordinates{PackageID: "lodash"}}, ""},
+		{"no name", ComponentIdentifier{Format: "pypi", Coordinates: Coordinates{Version: "1.0"}}, ""},
+		{"unsupported format", ComponentIdentifier{Format: "a-name", Coordinates: Coordinates{Name: "x", Version: "1"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.id.PackageURL(); got != tt.want {
+				t.Errorf("PackageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseToViolationRows_Purl(t *testing.T) {
+	violations := []Violation{{PolicyName: "Security-High", PolicyThreatLevel: 8, Constraints: []Constraint{{ConstraintName: "c"}}}}
+	raw := PolicyViolationReport{Components: []Component{
+		{DisplayName: "setuptools 80.9.0", Violations: violations, ComponentIdentifier: ComponentIdentifier{
+			Format: "pypi", Coordinates: Coordinates{Name: "setuptools", Version: "80.9.0"}}},
+		{DisplayName: "lodash 4.17.21", PackageURL: "pkg:npm/lodash@4.17.21?type=tgz", Violations: violations, ComponentIdentifier: ComponentIdentifier{
+			Format: "npm", Coordinates: Coordinates{PackageID: "lodash", Version: "4.17.21"}}},
+		{DisplayName: "mystery 1.0", Violations: violations},
+	}}
+
+	rows := parseToViolationRows(raw, "app", "org", parseOptions{conditionSep: DefaultConditionSeparator})
+	want := []string{"pkg:pypi/setuptools@80.9.0", "pkg:npm/lodash@4.17.21?type=tgz", ""}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %d, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		if rows[i].Purl != w {
+			t.Errorf("row %d Purl = %q, want %q", i, rows[i].Purl, w)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// internal/client/reasons.go
+package client
+
+import (
+	"slices"
+	"strings"
+)
+
+// String renders the reason followed by its reference in parentheses, e.g.
+// "Found security vulnerability with severity >= 7 (CVE-2021-44228)"; a reference the
+// reason already names is not repeated.
+func (r Reason) String() string {
+	text := strings.TrimSpace(r.Reason)
+	if r.Reference == nil {
+		return text
+	}
+	ref := strings.TrimSpace(r.Reference.Value)
+	switch {
+	case ref == "" || strings.Contains(text, ref):
+		return text
+	case text == "":
+		return ref
+	}
+	return text + " (" + ref + ")"
+}
+
+// constraintReasons lists the reasons of a constraint's conditions in order, without
+// empty or repeated entries; nil when IQ returned none.
+func constraintReasons(c Constraint) []string {
+	var reasons []string
+	for _, cond := range c.Conditions {
+		for _, r := range cond.Reasons {
+			if s := r.String(); s != "" && !slices.Contains(reasons, s) {
+				reasons = append(reasons, s)
+			}
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,85 @@
+// internal/client/remediation.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// ComponentRef identifies a component to IQ by package URL or, when the report did
+// not include one, by format and coordinates.
+type ComponentRef struct {
+	PackageURL string               `json:"packageUrl,omitempty"`
+	Identifier *ComponentIdentifier `json:"componentIdentifier,omitempty"`
+}
+
+// Key returns a string identifying the component, for caching lookups.
+func (r ComponentRef) Key() string {
+	if r.PackageURL != "" {
+		return r.PackageURL
+	}
+	if r.Identifier == nil {
+		return ""
+	}
+	c := r.Identifier.Coordinates
+	return fmt.Sprintf("%s:%s:%s:%s:%s:%s", r.Identifier.Format, c.GroupID, c.ArtifactID, c.Name, c.PackageID, c.Version)
+}
+
+// remediationResponse mirrors the component remediation API payload.
+type remediationResponse struct {
+	Remediation struct {
+		VersionChanges []struct {
+			Type string `json:"type"`
+			Data struct {
+				Component struct {
+					ComponentIdentifier ComponentIdentifier `json:"componentIdentifier"`
+				} `json:"component"`
+			} `json:"data"`
+		} `json:"versionChanges"`
+	} `json:"remediation"`
+}
+
+// remediationNoViolations is the version change type IQ uses for the nearest version
+// without any policy violations; it is preferred over e.g. "next-non-failing".
+const remediationNoViolations = "next-no-violations"
+
+// GetComponentRemediation asks IQ which versions of a component would resolve its policy
+// violations for the application (internal ID) at stage. Versions are returned best
+// first, the nearest violation-free version leading; nil when IQ has no suggestion.
+func (c *Client) GetComponentRemediation(ctx context.Context, appID, stage string, ref ComponentRef) ([]string, error) {
+	endpoint := fmt.Sprintf("components/remediation/application/%s", url.PathEscape(appID))
+	c.logger.Debug().Str("appId", appID).Str("component", ref.Key()).Msg("Fetching component remediation")
+
+	req := c.http.R().
+		SetContext(ctx).
+		SetBody(ref)
+	if stage != "" {
+		req.SetQueryParam("stageId", stage)
+	}
+	var raw remediationResponse
+	resp, err := req.SetResult(&raw).Post(endpoint)
+	if err != nil {
+		return nil, transportError(endpoint, err)
+	}
+	if resp.IsError() {
+		c.logger.Warn().
+			Str("appId", appID).
+			Int("status", resp.StatusCode()).
+			Msg("Failed to fetch component remediation")
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
+	}
+
+	// Two passes put the nearest violation-free version first
+	var versions []string
+	for _, preferred := range []bool{true, false} {
+		for _, ch := range raw.Remediation.VersionChanges {
+			v := ch.Data.Component.ComponentIdentifier.Coordinates.Version
+			if (ch.Type == remediationNoViolations) == preferred && v != "" && !slices.Contains(versions, v) {
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions, nil
+}
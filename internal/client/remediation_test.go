@@ -0,0 +1,45 @@
+// internal/client/remediation_test.go
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGetComponentRemediation(t *testing.T) {
+	var gotBody ComponentRef
+	var gotStage string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/components/remediation/application/aid-1" {
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		gotStage = r.URL.Query().Get("stageId")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"remediation":{"versionChanges":[
+			{"type":"next-non-failing","data":{"component":{"componentIdentifier":{"format":"maven","coordinates":{"version":"2.5"}}}}},
+			{"type":"next-no-violations","data":{"component":{"componentIdentifier":{"format":"maven","coordinates":{"version":"2.7"}}}}}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ref := ComponentRef{PackageURL: "pkg:maven/commons-io/commons-io@2.4?type=jar"}
+	versions, err := c.GetComponentRemediation(rCtx(t), "aid-1", "build", ref)
+	if err != nil {
+		t.Fatalf("GetComponentRemediation: %v", err)
+	}
+	if want := []string{"2.7", "2.5"}; !slices.Equal(versions, want) {
+		t.Errorf("versions = %v, want %v (no-violations first)", versions, want)
+	}
+	if gotStage != "build" || gotBody.PackageURL != ref.PackageURL {
+		t.Errorf("request stage = %q body = %+v", gotStage, gotBody)
+	}
+}
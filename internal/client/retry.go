@@ -0,0 +1,88 @@
+// internal/client/retry.go
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// WithRetries retries a request up to count times after a transport error, HTTP 429 or a
+// 5xx response. The wait before each retry is drawn uniformly between 0 and an exponential
+// backoff (base, 2*base, 4*base, ... capped at max), so workers hit by the same server blip
+// spread their retries out instead of returning in lockstep. count <= 0 disables retries.
+func WithRetries(count int, base, max time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retries = count
+		o.retryBase = base
+		o.retryMax = max
+	}
+}
+
+// backoff computes full-jitter retry waits; it is safe for concurrent use.
+type backoff struct {
+	base time.Duration
+	max  time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newBackoff(base, max time.Duration, rng *rand.Rand) *backoff {
+	if rng == nil {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return &backoff{base: base, max: max, rng: rng}
+}
+
+// ceiling is the exponential backoff before retrying after failed attempt n (1-based).
+func (b *backoff) ceiling(attempt int) time.Duration {
+	d := b.base
+	for i := 1; i < attempt && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		d = b.max
+	}
+	return d
+}
+
+// wait returns a random duration in [0, ceiling(attempt)].
+func (b *backoff) wait(attempt int) time.Duration {
+	c := b.ceiling(attempt)
+	if c <= 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Duration(b.rng.Int64N(int64(c) + 1))
+}
+
+// apply enables count retries on r with b's waits.
+func (b *backoff) apply(r *resty.Client, count int) {
+	r.SetRetryCount(count).
+		SetRetryWaitTime(0).
+		SetRetryMaxWaitTime(b.max).
+		SetRetryAfter(func(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+			// resty substitutes its own short wait for 0, so a zero draw still waits ~1ns
+			return b.wait(resp.Request.Attempt), nil
+		}).
+		AddRetryCondition(retryable)
+}
+
+// retryable reports whether a failed attempt is worth repeating: transport errors (but not
+// an open circuit breaker or a cancelled request), 429 and 5xx responses.
+func retryable(resp *resty.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) && (resp == nil || resp.Request.Context().Err() == nil)
+	}
+	if resp == nil {
+		return false
+	}
+	code := resp.StatusCode()
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
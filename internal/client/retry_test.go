@@ -0,0 +1,85 @@
+// internal/client/retry_test.go
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff_FullJitter(t *testing.T) {
+	b := newBackoff(100*time.Millisecond, time.Second, rand.New(rand.NewPCG(1, 2)))
+
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range ceilings {
+		if got := b.ceiling(i + 1); got != want {
+			t.Errorf("ceiling(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		seen := make(map[time.Duration]bool)
+		for range 50 {
+			w := b.wait(attempt)
+			if w < 0 || w > b.ceiling(attempt) {
+				t.Fatalf("wait(%d) = %v, outside [0, %v]", attempt, w, b.ceiling(attempt))
+			}
+			seen[w] = true
+		}
+		if len(seen) < 10 {
+			t.Errorf("wait(%d) produced only %d distinct values in 50 draws", attempt, len(seen))
+		}
+	}
+
+	// The same seed yields the same sequence
+	x, y := newBackoff(time.Second, time.Minute, rand.New(rand.NewPCG(7, 7))), newBackoff(time.Second, time.Minute, rand.New(rand.NewPCG(7, 7)))
+	for attempt := 1; attempt <= 5; attempt++ {
+		if a, b := x.wait(attempt), y.wait(attempt); a != b {
+			t.Errorf("seeded waits differ at attempt %d: %v vs %v", attempt, a, b)
+		}
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"organizations":[{"id":"o1","name":"one"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithRetries(3, time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	orgs, err := iqClient.GetOrganizations(rCtx(t))
+	if err != nil || len(orgs) != 1 {
+		t.Fatalf("GetOrganizations = %v, %v", orgs, err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+
+	// Client errors other than 429 are not retried
+	hits.Store(0)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "nope", http.StatusBadRequest)
+	})
+	if _, err := iqClient.GetOrganizations(rCtx(t)); err == nil {
+		t.Error("expected error for 400")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("requests for 400 = %d, want 1", n)
+	}
+}
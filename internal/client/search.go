@@ -0,0 +1,104 @@
+// internal/client/search.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// searchPageSize is the number of hits requested per advanced search page.
+const searchPageSize = 500
+
+// searchResponse mirrors the advanced search API payload, which groups hits by item type.
+type searchResponse struct {
+	TotalNumberOfHits int `json:"totalNumberOfHits"`
+	GroupingByDTOS    []struct {
+		Entries []struct {
+			ItemType            string `json:"itemType"`
+			ApplicationID       string `json:"applicationId"`
+			ApplicationPublicID string `json:"applicationPublicId"`
+			ApplicationName     string `json:"applicationName"`
+			OrganizationID      string `json:"organizationId"`
+		} `json:"entries"`
+	} `json:"groupingByDTOS"`
+}
+
+// luceneSpecial lists the characters escaped in a query term.
+const luceneSpecial = `+-&|!(){}[]^"~*?:\/ `
+
+// applicationNameQuery builds the advanced search query matching applications whose
+// name contains name literally (IQ's search is case-insensitive).
+func applicationNameQuery(name string) string {
+	var b strings.Builder
+	b.WriteString("itemType:application AND applicationName:*")
+	for _, r := range name {
+		if strings.ContainsRune(luceneSpecial, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('*')
+	return b.String()
+}
+
+// SearchApplications returns the applications whose name contains query, using IQ's
+// advanced search so big instances need not list every application. Results are paged
+// through until all hits are read; no match yields an empty slice and no error.
+func (c *Client) SearchApplications(ctx context.Context, query string) ([]Application, error) {
+	const endpoint = "search/advanced"
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	q := applicationNameQuery(query)
+	c.logger.Debug().Str("query", q).Msg("Searching applications")
+
+	var apps []Application
+	seen := make(map[string]bool)
+	for page := 0; ; page++ {
+		var raw searchResponse
+		resp, err := c.http.R().
+			SetContext(ctx).
+			SetQueryParams(map[string]string{
+				"query":    q,
+				"page":     strconv.Itoa(page),
+				"pageSize": strconv.Itoa(searchPageSize),
+			}).
+			SetResult(&raw).
+			Get(endpoint)
+		if err != nil {
+			return nil, transportError(endpoint, err)
+		}
+		if resp.IsError() {
+			c.logger.Error().
+				Str("endpoint", endpoint).
+				Int("status", resp.StatusCode()).
+				Str("rawBodySnippet", strings.TrimSpace(resp.String())).
+				Msg("Failed to search applications")
+			return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.String()}
+		}
+
+		hits := 0
+		for _, group := range raw.GroupingByDTOS {
+			for _, e := range group.Entries {
+				hits++
+				if e.ItemType != "application" || e.ApplicationID == "" || seen[e.ApplicationID] {
+					continue
+				}
+				seen[e.ApplicationID] = true
+				apps = append(apps, Application{
+					ID:             e.ApplicationID,
+					PublicID:       e.ApplicationPublicID,
+					Name:           e.ApplicationName,
+					OrganizationID: e.OrganizationID,
+				})
+			}
+		}
+		if hits == 0 || (page+1)*searchPageSize >= raw.TotalNumberOfHits {
+			break
+		}
+	}
+	c.logger.Debug().Str("query", query).Int("count", len(apps)).Msg("Application search finished")
+	return apps, nil
+}
@@ -0,0 +1,79 @@
+// internal/client/search_test.go
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestApplicationNameQuery_EscapesTerm(t *testing.T) {
+	got := applicationNameQuery("my app (v2)")
+	want := `itemType:application AND applicationName:*my\ app\ \(v2\)*`
+	if got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+}
+
+func TestSearchApplications_PagesAndDedupes(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/search/advanced" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if q := r.URL.Query().Get("query"); q != "itemType:application AND applicationName:*shop*" {
+			t.Errorf("query = %q", q)
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		w.Header().Set("Content-Type", "application/json")
+		total := strconv.Itoa(searchPageSize + 1)
+		if page == "0" {
+			_, _ = w.Write([]byte(`{"totalNumberOfHits":` + total + `,"groupingByDTOS":[{"entries":[
+				{"itemType":"application","applicationId":"a1","applicationPublicId":"shop-web","applicationName":"Shop Web","organizationId":"o1"},
+				{"itemType":"application","applicationId":"a1","applicationPublicId":"shop-web","applicationName":"Shop Web","organizationId":"o1"},
+				{"itemType":"component","applicationId":"a9"}]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"totalNumberOfHits":` + total + `,"groupingByDTOS":[{"entries":[
+			{"itemType":"application","applicationId":"a2","applicationPublicId":"shop-api","applicationName":"Shop API","organizationId":"o2"}]}]}`))
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	apps, err := iqClient.SearchApplications(rCtx(t), "shop")
+	if err != nil {
+		t.Fatalf("SearchApplications error = %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("requested pages %v, want 0 and 1", pages)
+	}
+	want := []Application{
+		{ID: "a1", PublicID: "shop-web", Name: "Shop Web", OrganizationID: "o1"},
+		{ID: "a2", PublicID: "shop-api", Name: "Shop API", OrganizationID: "o2"},
+	}
+	if len(apps) != len(want) || apps[0] != want[0] || apps[1] != want[1] {
+		t.Errorf("apps = %+v, want %+v", apps, want)
+	}
+}
+
+func TestSearchApplications_NoHits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"totalNumberOfHits":0,"groupingByDTOS":[]}`))
+	}))
+	defer srv.Close()
+
+	iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	apps, err := iqClient.SearchApplications(rCtx(t), "nothing")
+	if err != nil || len(apps) != 0 {
+		t.Errorf("apps = %v err = %v, want none and no error", apps, err)
+	}
+}
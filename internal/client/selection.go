@@ -0,0 +1,63 @@
+// internal/client/selection.go
+package client
+
+import (
+	"slices"
+	"strings"
+)
+
+// ReportSelection decides which of an application's reports GetLatestReportInfo returns.
+type ReportSelection string
+
+const (
+	// SelectLatest picks the report with the newest evaluation date.
+	SelectLatest ReportSelection = "latest"
+	// SelectStage picks the report from the earliest stage in the preference order,
+	// the newest one within that stage.
+	SelectStage ReportSelection = "stage"
+)
+
+// DefaultStageOrder prefers the most production-like stage.
+var DefaultStageOrder = []string{"operate", "release", "stage-release", "build", "source"}
+
+// WithReportSelection sets how GetLatestReportInfo chooses among an application's reports
+// (default SelectLatest). stageOrder is used by SelectStage; nil means DefaultStageOrder.
+func WithReportSelection(sel ReportSelection, stageOrder []string) Option {
+	return func(o *clientOptions) {
+		o.selection = sel
+		o.stageOrder = stageOrder
+	}
+}
+
+// selectReport returns the report chosen by sel among those matching stage (any stage when
+// empty), or nil when none match. Ties keep the order the API listed the reports in.
+func selectReport(reports []ReportInfo, stage string, sel ReportSelection, stageOrder []string) *ReportInfo {
+	if len(stageOrder) == 0 {
+		stageOrder = DefaultStageOrder
+	}
+	rank := func(r ReportInfo) int {
+		if sel != SelectStage {
+			return 0
+		}
+		if i := slices.IndexFunc(stageOrder, func(s string) bool { return strings.EqualFold(s, r.Stage) }); i >= 0 {
+			return i
+		}
+		return len(stageOrder) // unknown stages rank last
+	}
+
+	var best *ReportInfo
+	for i := range reports {
+		r := &reports[i]
+		if stage != "" && !strings.EqualFold(r.Stage, stage) {
+			continue
+		}
+		if best == nil {
+			best = r
+			continue
+		}
+		if rr, rb := rank(*r), rank(*best); rr < rb || (rr == rb && r.EvaluationDate.After(best.EvaluationDate)) {
+			best = r
+		}
+	}
+	return best
+}
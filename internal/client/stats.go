@@ -0,0 +1,114 @@
+// internal/client/stats.go
+package client
+
+import (
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointStats summarizes the latency of the responses received for one endpoint.
+type EndpointStats struct {
+	// Endpoint is the path template relative to the API base, e.g. "reports/applications/{id}".
+	Endpoint string
+	Count    int
+	Min      time.Duration
+	Max      time.Duration
+	P50      time.Duration
+	P95      time.Duration
+}
+
+// Stats holds request latency across all endpoints and per endpoint (sorted by name).
+type Stats struct {
+	Overall   EndpointStats
+	Endpoints []EndpointStats
+}
+
+// statsCollector accumulates response times; it is safe for concurrent use.
+type statsCollector struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{samples: make(map[string][]time.Duration)}
+}
+
+func (s *statsCollector) add(endpoint string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[endpoint] = append(s.samples[endpoint], d)
+}
+
+func (s *statsCollector) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats Stats
+	var all []time.Duration
+	for endpoint, samples := range s.samples {
+		stats.Endpoints = append(stats.Endpoints, summarizeDurations(endpoint, samples))
+		all = append(all, samples...)
+	}
+	sort.Slice(stats.Endpoints, func(i, j int) bool { return stats.Endpoints[i].Endpoint < stats.Endpoints[j].Endpoint })
+	stats.Overall = summarizeDurations("all", all)
+	return stats
+}
+
+// summarizeDurations computes count, min, max and nearest-rank percentiles.
+func summarizeDurations(endpoint string, samples []time.Duration) EndpointStats {
+	st := EndpointStats{Endpoint: endpoint, Count: len(samples)}
+	if len(samples) == 0 {
+		return st
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	percentile := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	st.Min = sorted[0]
+	st.Max = sorted[len(sorted)-1]
+	st.P50 = percentile(0.50)
+	st.P95 = percentile(0.95)
+	return st
+}
+
+// endpointLiterals are the fixed path segments of the IQ endpoints this client calls;
+// every other segment is an identifier.
+var endpointLiterals = map[string]bool{
+	"applications": true, "organization": true, "organizations": true,
+	"reports": true, "policy": true, "vulnerabilities": true,
+	"components": true, "remediation": true, "application": true,
+	"history": true, "policies": true,
+}
+
+// endpointTemplate turns a request path into its template relative to basePath,
+// e.g. /api/v2/reports/applications/abc -> reports/applications/{id}.
+func endpointTemplate(basePath, requestPath string) string {
+	rel := strings.Trim(strings.TrimPrefix(requestPath, strings.TrimRight(basePath, "/")), "/")
+	parts := strings.Split(rel, "/")
+	for i, p := range parts {
+		if !endpointLiterals[p] {
+			parts[i] = "{id}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Stats returns latency statistics for the responses received so far.
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// basePath returns the path component of the client's base URL.
+func (c *Client) basePath() string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
@@ -0,0 +1,88 @@
+// internal/client/stats_test.go
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats_PerEndpointCounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/organizations":
+			_, _ = w.Write([]byte(`{"organizations":[]}`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	ctx := rCtx(t)
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetLatestReportInfo(ctx, id, ""); err != nil {
+				t.Errorf("GetLatestReportInfo(%s): %v", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := c.GetOrganizations(ctx); err != nil {
+		t.Fatalf("GetOrganizations: %v", err)
+	}
+
+	stats := c.Stats()
+	if stats.Overall.Count != 5 {
+		t.Errorf("overall count = %d, want 5", stats.Overall.Count)
+	}
+	counts := map[string]int{}
+	for _, e := range stats.Endpoints {
+		counts[e.Endpoint] = e.Count
+		if e.Min <= 0 || e.Min > e.P50 || e.P50 > e.P95 || e.P95 > e.Max {
+			t.Errorf("%s: want 0 < min <= p50 <= p95 <= max, got %+v", e.Endpoint, e)
+		}
+	}
+	if counts["reports/applications/{id}"] != 4 || counts["organizations"] != 1 || len(counts) != 2 {
+		t.Errorf("endpoint counts = %v", counts)
+	}
+}
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/api/v2/organizations":                        "organizations",
+		"/api/v2/organizations/org-1":                  "organizations/{id}",
+		"/api/v2/applications/organization/org-1":      "applications/organization/{id}",
+		"/api/v2/applications/app-1/reports/r1/policy": "applications/{id}/reports/{id}/policy",
+		"/api/v2/vulnerabilities/CVE-2021-44228":       "vulnerabilities/{id}",
+	}
+	for path, want := range tests {
+		if got := endpointTemplate("/api/v2", path); got != want {
+			t.Errorf("endpointTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSummarizeDurations(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	got := summarizeDurations("x", samples)
+	want := EndpointStats{Endpoint: "x", Count: 100, Min: time.Millisecond, Max: 100 * time.Millisecond,
+		P50: 50 * time.Millisecond, P95: 95 * time.Millisecond}
+	if got != want {
+		t.Errorf("summarizeDurations = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+// internal/client/threat.go
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ThreatLevel is a policy threat level (0-10). IQ Server sends it as a JSON number, but some
+// versions and proxies quote it; both forms decode. Fractional values such as 7.5 are kept;
+// ViolationRow.Threat truncates them and ViolationRow.ThreatRaw preserves them.
+type ThreatLevel float64
+
+// UnmarshalJSON accepts 7, 7.0, "7" and "7.0"; null and "" decode to 0.
+func (t *ThreatLevel) UnmarshalJSON(b []byte) error {
+	s := string(bytes.TrimSpace(b))
+	if s == "null" {
+		*t = 0
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		var quoted string
+		if err := json.Unmarshal(b, &quoted); err != nil {
+			return fmt.Errorf("threat level %s: %w", s, err)
+		}
+		if s = strings.TrimSpace(quoted); s == "" {
+			*t = 0
+			return nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("threat level %s is not a number", string(b))
+	}
+	*t = ThreatLevel(f)
+	return nil
+}
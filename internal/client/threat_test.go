@@ -0,0 +1,37 @@
+// internal/client/threat_test.go
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestViolation_UnmarshalThreatLevel(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want ThreatLevel
+	}{
+		{`7`, 7},
+		{`"7"`, 7},
+		{`7.0`, 7},
+		{`7.5`, 7.5},
+		{`" 8.0 "`, 8},
+		{`null`, 0},
+		{`""`, 0},
+	}
+	for _, tt := range tests {
+		var v Violation
+		if err := json.Unmarshal([]byte(`{"policyName":"Security-High","policyThreatLevel":`+tt.raw+`}`), &v); err != nil {
+			t.Errorf("policyThreatLevel %s: %v", tt.raw, err)
+			continue
+		}
+		if v.PolicyThreatLevel != tt.want {
+			t.Errorf("policyThreatLevel %s = %v, want %v", tt.raw, v.PolicyThreatLevel, tt.want)
+		}
+	}
+
+	var v Violation
+	if err := json.Unmarshal([]byte(`{"policyThreatLevel":"high"}`), &v); err == nil {
+		t.Error("expected error for non-numeric threat level")
+	}
+}
@@ -0,0 +1,28 @@
+// internal/client/tls.go
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WithTLSServerName verifies IQ Server's certificate against name instead of the host of
+// the server URL, and sends it as the SNI name. It suits a load balancer whose certificate
+// is valid for another name; unlike skipping verification, the chain and name are still
+// checked. Empty keeps the URL's host.
+func WithTLSServerName(name string) Option {
+	return func(o *clientOptions) { o.tlsServerName = name }
+}
+
+// applyTLSServerName sets name as the server name of t's TLS config, if name is set.
+func applyTLSServerName(t *http.Transport, name string) {
+	if name == "" {
+		return
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		t.TLSClientConfig = t.TLSClientConfig.Clone()
+	}
+	t.TLSClientConfig.ServerName = name
+}
@@ -0,0 +1,61 @@
+// internal/client/tls_test.go
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient_TLSServerNameApplied(t *testing.T) {
+	iqClient, err := NewClient("https://10.0.0.5", "u", "p", newTestLogger(),
+		WithTLSServerName("iq.example.com"))
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	cfg := iqClient.transport.TLSClientConfig
+	if cfg == nil || cfg.ServerName != "iq.example.com" {
+		t.Fatalf("transport TLS config = %+v, want ServerName iq.example.com", cfg)
+	}
+	if cfg.InsecureSkipVerify {
+		t.Error("a server name must not disable certificate verification")
+	}
+
+	plain, err := NewClient("https://10.0.0.5", "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	if cfg := plain.transport.TLSClientConfig; cfg != nil && cfg.ServerName != "" {
+		t.Errorf("ServerName = %q without the option, want the URL's host", cfg.ServerName)
+	}
+}
+
+func TestNewClient_TLSServerNameVerifies(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"organizations":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	// The test certificate is valid for example.com, so only that name verifies
+	for name, wantOK := range map[string]bool{"example.com": true, "iq.example.org": false} {
+		iqClient, err := NewClient(srv.URL, "u", "p", newTestLogger(), WithTLSServerName(name))
+		if err != nil {
+			t.Fatalf("NewClient error = %v", err)
+		}
+		iqClient.transport.TLSClientConfig.RootCAs = roots
+
+		err = iqClient.Ping(context.Background())
+		var certErr x509.HostnameError
+		switch {
+		case wantOK && err != nil:
+			t.Errorf("%s: Ping error = %v, want the certificate accepted", name, err)
+		case !wantOK && !errors.As(err, &certErr):
+			t.Errorf("%s: Ping error = %v, want a hostname mismatch", name, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// internal/client/trace.go
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog"
+)
+
+// WithHTTPTrace logs full request and response headers and bodies at debug level.
+// Credentials are redacted, but bodies are logged as-is, so keep it off outside debugging.
+func WithHTTPTrace(enabled bool) Option {
+	return func(o *clientOptions) { o.httpTrace = enabled }
+}
+
+// redactedHeaders are replaced with "REDACTED" in traces.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redact returns a copy of h with credential headers, and the extra ones, masked.
+func redact(h http.Header, extra ...string) http.Header {
+	out := h.Clone()
+	for _, name := range slices.Concat(redactedHeaders, extra) {
+		if out.Get(name) != "" {
+			out.Set(name, "REDACTED")
+		}
+	}
+	return out
+}
+
+// traceResponse logs the wire-level request (as sent, including the auth header resty adds
+// after user hooks run) and the response received for it. Custom request headers (see
+// WithHeaders) often carry gateway tokens, so their values are masked too.
+func traceResponse(logger zerolog.Logger, resp *resty.Response, custom []string) {
+	ev := logger.Debug().
+		Str("method", resp.Request.Method).
+		Int("status", resp.StatusCode()).
+		Str("responseBody", resp.String()).
+		Interface("responseHeaders", redact(resp.Header()))
+	if raw := resp.Request.RawRequest; raw != nil {
+		ev = ev.Str("url", raw.URL.String()).Interface("requestHeaders", redact(raw.Header, custom...))
+	}
+	if body := resp.Request.Body; body != nil {
+		ev = ev.Str("requestBody", fmt.Sprint(body))
+	}
+	ev.Msg("HTTP trace")
+}
@@ -0,0 +1,42 @@
+// internal/client/trace_test.go
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestHTTPTrace_RedactsAuthorization(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"organizations":[{"id":"org-1","name":"traced-org"}]}`))
+	}))
+	defer srv.Close()
+
+	for _, enabled := range []bool{true, false} {
+		var buf bytes.Buffer
+		c, err := NewClient(srv.URL, "user", "hunter2", zerolog.New(&buf), WithHTTPTrace(enabled))
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		if _, err := c.GetOrganizations(rCtx(t)); err != nil {
+			t.Fatalf("GetOrganizations: %v", err)
+		}
+
+		out := buf.String()
+		if strings.Contains(out, "Basic ") {
+			t.Errorf("trace=%v: credentials leaked into logs:\n%s", enabled, out)
+		}
+		traced := strings.Contains(out, "HTTP trace")
+		if traced != enabled {
+			t.Errorf("trace=%v: traced = %v", enabled, traced)
+		}
+		if enabled && (!strings.Contains(out, `"Authorization":["REDACTED"]`) || !strings.Contains(out, "traced-org")) {
+			t.Errorf("trace should show the redacted header and the response body:\n%s", out)
+		}
+	}
+}
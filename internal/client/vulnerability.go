@@ -0,0 +1,78 @@
+// internal/client/vulnerability.go
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// VulnerabilityDetail is the subset of IQ's vulnerability details used to enrich report rows.
+type VulnerabilityDetail struct {
+	ID          string
+	Severity    string // critical, high, medium, low or none, from the CVSS score
+	CVSSScore   float64
+	CVSSVector  string
+	Description string
+}
+
+// vulnerabilityResponse mirrors the vulnerability details API payload.
+type vulnerabilityResponse struct {
+	Identifier   string `json:"identifier"`
+	Description  string `json:"description"`
+	MainSeverity struct {
+		Score  float64 `json:"score"`
+		Vector string  `json:"vector"`
+	} `json:"mainSeverity"`
+}
+
+// GetVulnerabilityDetail fetches severity, CVSS and description for a vulnerability
+// identifier such as a CVE ID.
+func (c *Client) GetVulnerabilityDetail(ctx context.Context, cveID string) (*VulnerabilityDetail, error) {
+	endpoint := fmt.Sprintf("vulnerabilities/%s", url.PathEscape(cveID))
+	c.logger.Debug().Str("cve", cveID).Msg("Fetching vulnerability detail")
+
+	var raw vulnerabilityResponse
+	resp, err := c.http.R().
+		SetContext(ctx).
+		SetResult(&raw).
+		Get(endpoint)
+	if err != nil {
+		return nil, transportError(endpoint, err)
+	}
+	if resp.IsError() {
+		c.logger.Warn().
+			Str("cve", cveID).
+			Int("status", resp.StatusCode()).
+			Msg("Failed to fetch vulnerability detail")
+		return nil, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode(), Message: resp.Status()}
+	}
+
+	id := raw.Identifier
+	if id == "" {
+		id = cveID
+	}
+	return &VulnerabilityDetail{
+		ID:          id,
+		Severity:    cvssSeverity(raw.MainSeverity.Score),
+		CVSSScore:   raw.MainSeverity.Score,
+		CVSSVector:  raw.MainSeverity.Vector,
+		Description: raw.Description,
+	}, nil
+}
+
+// cvssSeverity maps a CVSS v3 base score to its qualitative rating.
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,52 @@
+// internal/client/vulnerability_test.go
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetVulnerabilityDetail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/vulnerabilities/CVE-2021-44228" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"identifier":  "CVE-2021-44228",
+			"description": "Log4Shell remote code execution",
+			"mainSeverity": map[string]any{
+				"source": "cve",
+				"score":  10.0,
+				"vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+			},
+		})
+	}))
+	defer srv.Close()
+
+	cl, err := NewClient(srv.URL, "u", "p", newTestLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	got, err := cl.GetVulnerabilityDetail(rCtx(t), "CVE-2021-44228")
+	if err != nil {
+		t.Fatalf("GetVulnerabilityDetail: %v", err)
+	}
+	want := VulnerabilityDetail{
+		ID:          "CVE-2021-44228",
+		Severity:    "critical",
+		CVSSScore:   10,
+		CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+		Description: "Log4Shell remote code execution",
+	}
+	if *got != want {
+		t.Errorf("detail = %+v, want %+v", *got, want)
+	}
+
+	if _, err := cl.GetVulnerabilityDetail(rCtx(t), "CVE-0000-0000"); err == nil {
+		t.Error("expected error for unknown CVE")
+	}
+}
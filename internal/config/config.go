@@ -2,39 +2,533 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/upload"
 	"github.com/caarlos0/env/v11"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
 )
 
 type Config struct {
 	// IQ Server config
-	IQServerURL string `env:"IQ_SERVER_URL,required" validate:"required,url"`
-	IQUsername  string `env:"IQ_USERNAME,required" validate:"required"`
-	IQPassword  string `env:"IQ_PASSWORD,required" validate:"required"`
+	IQServerURL string `env:"IQ_SERVER_URL" validate:"required_without=IQServers,omitempty,url"`
+	IQUsername  string `env:"IQ_USERNAME" validate:"required_without=IQServers"`
+	// IQPassword is the password or user token passcode; alternatively IQPasswordFile names a
+	// file holding it ("-" reads stdin). Exactly one of the two must be set.
+	IQPassword     string `env:"IQ_PASSWORD" validate:"required_without=IQServers"`
+	IQPasswordFile string `env:"IQ_PASSWORD_FILE"`
+	// IQServers lists several IQ Servers as a JSON array of {name, url, username, password};
+	// when set, the report merges all of them and IQ_SERVER_URL and its credentials are unused.
+	IQServers string `env:"IQ_SERVERS"`
+	servers   []services.Instance
+	// APIBasePath is appended to IQ_SERVER_URL unless the URL already ends in it.
+	APIBasePath string `env:"API_BASE_PATH" envDefault:"/api/v2" validate:"startswith=/"`
+	// IQStrictAPIPath rejects an IQ_SERVER_URL whose path is neither empty nor ending in API_BASE_PATH.
+	IQStrictAPIPath bool `env:"IQ_STRICT_API_PATH" envDefault:"false"`
+	// HTTPTrace logs full request/response bodies with credentials redacted; off by default
+	// because reports contain sensitive data.
+	HTTPTrace bool `env:"HTTP_TRACE" envDefault:"false"`
+	// HTTPUserAgent overrides the User-Agent header (default iqfetch/<version>).
+	HTTPUserAgent string `env:"HTTP_USER_AGENT"`
+	// HTTPHeaders adds headers to every request, as Key:Value pairs separated by commas or newlines.
+	HTTPHeaders string `env:"HTTP_HEADERS"`
+	headers     map[string]string
+	// TLSServerName verifies the IQ Server certificate against this name instead of the
+	// IQ_SERVER_URL host, without disabling verification.
+	TLSServerName string `env:"TLS_SERVER_NAME" validate:"omitempty,hostname_rfc1123"`
+	// RecordDir saves API responses for replay; ReplayDir serves them instead of the network.
+	RecordDir string `env:"RECORD_DIR" validate:"excluded_with=ReplayDir"`
+	ReplayDir string `env:"REPLAY_DIR"`
 
 	// Task config
 	OrganizationID string `env:"ORGANIZATION_ID" validate:"omitempty"`
+	// OrganizationIDs restricts the run to several organizations; OrganizationIDsFile adds the
+	// IDs of a newline-delimited file (# comments allowed). Either excludes OrganizationID.
+	OrganizationIDs     []string `env:"ORGANIZATION_IDS"`
+	OrganizationIDsFile string   `env:"ORGANIZATION_IDS_FILE"`
+	// OrgNamesOptional writes organization IDs instead of failing when the name lookup fails.
+	OrgNamesOptional bool `env:"ORG_NAMES_OPTIONAL" envDefault:"false"`
+	// OnDuplicateApp handles an application listed twice: keep the first listing, or fail.
+	OnDuplicateApp string `env:"ON_DUPLICATE_APP" envDefault:"first" validate:"oneof=first error"`
+	// OnMissingOrg handles an application whose organization cannot be resolved.
+	OnMissingOrg    string `env:"ON_MISSING_ORG" envDefault:"fallback" validate:"oneof=fallback fetch error"`
+	AppIncludeRegex string `env:"APP_INCLUDE_REGEX" validate:"omitempty,regexp"`
+	AppExcludeRegex string `env:"APP_EXCLUDE_REGEX" validate:"omitempty,regexp"`
+	// AppNameQuery finds applications by name on the server instead of listing them all.
+	AppNameQuery string `env:"APP_NAME_QUERY"`
+	ReportStage  string `env:"REPORT_STAGE" validate:"omitempty,oneof=source build stage-release release operate"`
+	// ReportID fetches this report instead of the latest; the application filters must
+	// select a single application.
+	ReportID string `env:"REPORT_ID"`
+	// AppFetchChunkSize lists applications per organization, N organizations at a time, fetching
+	// each chunk's reports before listing the next (0 = one request).
+	AppFetchChunkSize int `env:"APP_FETCH_CHUNK_SIZE" envDefault:"0" validate:"min=0"`
+	// ReportSelection chooses among an application's reports: latest evaluation date, or
+	// the first stage in ReportStageOrder.
+	ReportSelection  string   `env:"REPORT_SELECTION" envDefault:"latest" validate:"oneof=latest stage"`
+	ReportStageOrder []string `env:"REPORT_STAGE_ORDER" envDefault:"operate,release,stage-release,build,source" validate:"dive,oneof=source build stage-release release operate"`
+	MaxConcurrency   int      `env:"MAX_CONCURRENCY" envDefault:"10" validate:"min=1"`
+	// HTTP keep-alive pool; 0 idle limits scale with MAX_CONCURRENCY.
+	HTTPMaxIdleConns           int `env:"HTTP_MAX_IDLE_CONNS" envDefault:"0" validate:"min=0"`
+	HTTPMaxIdleConnsPerHost    int `env:"HTTP_MAX_IDLE_CONNS_PER_HOST" envDefault:"0" validate:"min=0"`
+	HTTPIdleConnTimeoutSeconds int `env:"HTTP_IDLE_CONN_TIMEOUT_SECONDS" envDefault:"90" validate:"min=0"`
+	// Since is an RFC3339 timestamp or a duration (e.g. 24h) before now; apps whose
+	// latest report is older are skipped. Parsed into since by Load.
+	Since string `env:"SINCE"`
+	since time.Time
+
+	// StaleAfter warns about applications whose latest report was evaluated longer ago
+	// (e.g. 2160h); they are still reported. 0 disables the check.
+	StaleAfter time.Duration `env:"STALE_AFTER"`
+	// RequestsPerSecond caps IQ Server requests across all workers; 0 means unlimited.
+	RequestsPerSecond float64 `env:"REQUESTS_PER_SECOND" envDefault:"0" validate:"min=0"`
+	// CircuitBreakerThreshold consecutive failures within the window open the breaker for the
+	// cooldown, sparing a failing IQ Server from every remaining request (0 = disabled).
+	CircuitBreakerThreshold       int `env:"CIRCUIT_BREAKER_THRESHOLD" envDefault:"5" validate:"min=0"`
+	CircuitBreakerWindowSeconds   int `env:"CIRCUIT_BREAKER_WINDOW_SECONDS" envDefault:"10" validate:"min=1"`
+	CircuitBreakerCooldownSeconds int `env:"CIRCUIT_BREAKER_COOLDOWN_SECONDS" envDefault:"30" validate:"min=1"`
+	// HTTPMaxRetries retries failed requests (network errors, 429, 5xx) with jittered backoff (0 = none).
+	HTTPMaxRetries           int `env:"HTTP_MAX_RETRIES" envDefault:"0" validate:"min=0"`
+	HTTPRetryBaseWaitSeconds int `env:"HTTP_RETRY_BASE_WAIT_SECONDS" envDefault:"1" validate:"min=1"`
+	HTTPRetryMaxWaitSeconds  int `env:"HTTP_RETRY_MAX_WAIT_SECONDS" envDefault:"30" validate:"min=1"`
+	// AppTimeoutSeconds bounds each application's fetches (0 = no per-application limit).
+	AppTimeoutSeconds int `env:"APP_TIMEOUT_SECONDS" envDefault:"15" validate:"min=0"`
+	// RunTimeoutSeconds bounds the whole run (0 = no limit); WritePartialOnTimeout still writes
+	// the applications finished when it fires.
+	RunTimeoutSeconds     int  `env:"RUN_TIMEOUT_SECONDS" envDefault:"30" validate:"min=0"`
+	WritePartialOnTimeout bool `env:"WRITE_PARTIAL_ON_TIMEOUT" envDefault:"false"`
+	// ProgressLogEvery / ProgressLogSeconds log progress with throughput and ETA every N apps
+	// or T seconds, whichever comes first (0 disables either).
+	ProgressLogEvery   int `env:"PROGRESS_LOG_EVERY" envDefault:"100" validate:"min=0"`
+	ProgressLogSeconds int `env:"PROGRESS_LOG_SECONDS" envDefault:"30" validate:"min=0"`
+	// ScheduleInterval repeats the run on this interval (e.g. 6h) until the process is
+	// signaled to stop; empty runs once.
+	ScheduleInterval time.Duration `env:"SCHEDULE_INTERVAL"`
 
 	// IO config
-	OutputDir string `validate:"required"`
+	OutputDir string `env:"OUTPUT_DIR" envDefault:"reports_output" validate:"required"`
+	// OutputDirAllowAbsolute permits an absolute OUTPUT_DIR; relative paths may never escape the working dir.
+	OutputDirAllowAbsolute bool   `env:"OUTPUT_DIR_ALLOW_ABSOLUTE" envDefault:"false"`
+	OutputFilenameTemplate string `env:"OUTPUT_FILENAME_TEMPLATE" envDefault:"{date}_{time}.{format}" validate:"required,excludesall=/\\"`
+	OutputFormat           string `env:"OUTPUT_FORMAT" envDefault:"csv"`
+	OutputMode             string `env:"OUTPUT_MODE" envDefault:"detailed" validate:"oneof=detailed summary count"`
+	// FailOnThreat makes count mode exit with code 2 when any violation has at least this threat level (0 = never).
+	FailOnThreat int `env:"FAIL_ON_THREAT" envDefault:"0" validate:"min=0,max=10"`
+	// Gate fails count mode with a gate-specific exit code when a comparison such as threat>=8,
+	// count>0 or apps==0 holds (see services.ParseGate).
+	Gate string `env:"GATE"`
+	// OutputColumns selects and orders the detailed columns (comma-separated headers or JSON keys).
+	OutputColumns []string `env:"OUTPUT_COLUMNS"`
+	OutputDest    string   `env:"OUTPUT_DEST" envDefault:"file" validate:"oneof=file stdout"`
+	OutputGzip    bool     `env:"OUTPUT_GZIP" envDefault:"false"`
+	// ExtraColumns adds optional columns, e.g. License,Purl, to the default ones.
+	ExtraColumns []string `env:"EXTRA_COLUMNS"`
+	// OutputFileMode and OutputDirMode are octal permissions for written reports and created
+	// directories, parsed into fileMode and dirMode by Load.
+	OutputFileMode string `env:"OUTPUT_FILE_MODE" envDefault:"0644"`
+	OutputDirMode  string `env:"OUTPUT_DIR_MODE" envDefault:"0755"`
+	// TempFileMaxAgeMinutes sweeps temp files of interrupted writes older than this (0 = never).
+	TempFileMaxAgeMinutes int `env:"TEMP_FILE_MAX_AGE_MINUTES" envDefault:"60" validate:"min=0"`
+	fileMode              os.FileMode
+	dirMode               os.FileMode
+	// SplitByOrg writes one report per organization; the filename template must contain {org}.
+	SplitByOrg bool `env:"SPLIT_BY_ORG" envDefault:"false"`
+	// StreamOutput writes detailed CSV rows as applications finish, in application order.
+	StreamOutput bool `env:"STREAM_OUTPUT" envDefault:"false"`
+	// StreamBuffer is how many applications may be fetched ahead of the writer (0 = MaxConcurrency).
+	StreamBuffer int `env:"STREAM_BUFFER" envDefault:"0" validate:"min=0"`
+	// MaxRows caps the report size, keeping the highest-threat rows (0 = unlimited).
+	MaxRows                int `env:"MAX_ROWS" envDefault:"0" validate:"min=0"`
+	MarkdownConditionWidth int `env:"MARKDOWN_CONDITION_WIDTH" envDefault:"80" validate:"min=0"`
+	// CSVDelimiter is a single character; CSVWriteBOM prefixes the UTF-8 BOM for Excel.
+	CSVDelimiter string `env:"CSV_DELIMITER" envDefault:"," validate:"len=1,excludesall=\"\r\n"`
+	CSVWriteBOM  bool   `env:"CSV_WRITE_BOM" envDefault:"false"`
+	// ThreatAsFloat writes the CSV Threat column as reported (e.g. 7.5) instead of truncated.
+	ThreatAsFloat bool `env:"THREAT_AS_FLOAT" envDefault:"false"`
+	// PolicyInclude/PolicyExclude filter violations by policy name (case-insensitive globs).
+	PolicyInclude []string `env:"POLICY_INCLUDE"`
+	PolicyExclude []string `env:"POLICY_EXCLUDE"`
+	// PolicyFilterStrict fails the run when a policy pattern matches no policy on the server.
+	PolicyFilterStrict bool `env:"POLICY_FILTER_STRICT" envDefault:"false"`
+	// PolicyPageSize is the components per page when IQ paginates the policy violations report.
+	PolicyPageSize int `env:"POLICY_PAGE_SIZE" envDefault:"500" validate:"min=1"`
+	// ConditionSeparator joins a constraint's condition summaries in the Condition column.
+	ConditionSeparator string `env:"CONDITION_SEPARATOR" envDefault:" | " validate:"required"`
+	// IncludeCleanComponents lists components without violations as Clean rows.
+	IncludeCleanComponents bool `env:"INCLUDE_CLEAN_COMPONENTS" envDefault:"false"`
+	// MarkCleanApps writes a Clean marker row for each application whose report has no rows.
+	MarkCleanApps bool `env:"MARK_CLEAN_APPS" envDefault:"false"`
+	// IncludeRemediation fills Recommended Version from IQ's component remediation API.
+	IncludeRemediation bool `env:"INCLUDE_REMEDIATION" envDefault:"false"`
+	// IncludeReportURL fills Report URL with a link to each application's report in the IQ UI.
+	IncludeReportURL bool `env:"INCLUDE_REPORT_URL" envDefault:"false"`
+	// IncludeReasons adds a Reasons column with the findings behind each row's conditions.
+	IncludeReasons bool `env:"INCLUDE_REASONS" envDefault:"false"`
+	// Redact replaces application and organization names with pseudonyms keyed by RedactSalt;
+	// RedactKeyFile writes the mapping next to the report.
+	Redact        bool   `env:"REDACT" envDefault:"false"`
+	RedactSalt    string `env:"REDACT_SALT"`
+	RedactKeyFile bool   `env:"REDACT_KEY_FILE" envDefault:"false"`
+	// EnrichCVE adds severity, CVSS score/vector and description for each row's CVE.
+	EnrichCVE bool `env:"ENRICH_CVE" envDefault:"false"`
+	// WriteErrorReport writes <report>-errors.csv listing applications that could not be scanned.
+	WriteErrorReport bool `env:"WRITE_ERROR_REPORT" envDefault:"false"`
+	// ErrorReportIncludeAccessDenied also lists applications skipped with HTTP 403 there.
+	ErrorReportIncludeAccessDenied bool `env:"ERROR_REPORT_INCLUDE_ACCESS_DENIED" envDefault:"false"`
+	// WriteLatest keeps OUTPUT_DIR/latest.<ext> pointing at the most recent report.
+	WriteLatest bool `env:"WRITE_LATEST" envDefault:"false"`
+	// Resume keeps a checkpoint under OUTPUT_DIR/.checkpoint and continues an interrupted run.
+	Resume bool `env:"RESUME" envDefault:"false"`
+
+	// Metrics config
+	MetricsPushgatewayURL string `env:"METRICS_PUSHGATEWAY_URL" validate:"omitempty,url"`
+	// NotifyWebhookURL receives a JSON run summary (Slack/Teams incoming webhook compatible).
+	NotifyWebhookURL string `env:"NOTIFY_WEBHOOK_URL" validate:"omitempty,url"`
+	// OutputS3URI (s3://bucket/prefix) uploads the written report files to S3.
+	OutputS3URI string `env:"OUTPUT_S3_URI"`
 }
 
 func Load() (*Config, error) {
-	_ = godotenv.Load("config/.env")
+	return LoadFile("")
+}
+
+// DefaultConfigFile is the dotenv file read when no explicit path is given.
+const DefaultConfigFile = "config/.env"
+
+// LoadFile loads configuration from the dotenv file at path and the environment.
+// An empty path falls back to $CONFIG_FILE, then to DefaultConfigFile. An explicit
+// path (argument or CONFIG_FILE) must exist; a missing default file is ignored so
+// configuration can come purely from the environment.
+func LoadFile(path string) (*Config, error) {
+	return LoadWith(LoadOptions{EnvFile: path})
+}
+
+// LoadOptions selects the files Load reads in addition to the environment.
+type LoadOptions struct {
+	// EnvFile is a dotenv file; see LoadFile for the fallbacks when empty.
+	EnvFile string
+	// JSONFile is a JSON config object keyed by camelCase env names (iqServerUrl);
+	// empty falls back to $CONFIG_JSON. Environment variables override its values.
+	JSONFile string
+}
+
+// LoadWith loads configuration with precedence environment (including the dotenv
+// file) > JSON file > defaults, then validates it.
+func LoadWith(opts LoadOptions) (*Config, error) {
+	path := opts.EnvFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := godotenv.Load(path); err != nil {
+			return nil, fmt.Errorf("load config file %s: %w", path, err)
+		}
+	} else if err := godotenv.Load(DefaultConfigFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load config file %s: %w", DefaultConfigFile, err)
+	}
+
+	environment := env.ToMap(os.Environ())
+	jsonPath := opts.JSONFile
+	if jsonPath == "" {
+		jsonPath = os.Getenv("CONFIG_JSON")
+	}
+	if jsonPath != "" {
+		fileEnv, err := jsonEnvironment(jsonPath)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(fileEnv, environment)
+		environment = fileEnv
+	}
 
 	cfg := &Config{}
-	if err := env.Parse(cfg); err != nil {
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: environment, FuncMap: envParsers}); err != nil {
+		return nil, err
+	}
+
+	outputDir, err := cleanOutputDir(cfg.OutputDir, cfg.OutputDirAllowAbsolute)
+	if err != nil {
 		return nil, err
 	}
+	cfg.OutputDir = outputDir
 
-	cfg.OutputDir = "reports_output"
+	if cfg.since, err = parseSince(cfg.Since, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if _, err := report.ParseColumns(cfg.OutputColumns); err != nil {
+		return nil, fmt.Errorf("OUTPUT_COLUMNS: %w", err)
+	}
+	if _, err := report.ParseColumns(cfg.ExtraColumns); err != nil {
+		return nil, fmt.Errorf("EXTRA_COLUMNS: %w", err)
+	}
+
+	if gate, err := services.ParseGate(cfg.Gate); err != nil {
+		return nil, fmt.Errorf("GATE: %w", err)
+	} else if gate != nil && cfg.OutputMode != services.OutputModeCount {
+		return nil, fmt.Errorf("GATE requires OUTPUT_MODE=%s", services.OutputModeCount)
+	}
+
+	if cfg.ReportID != "" && cfg.AppIncludeRegex == "" && cfg.AppNameQuery == "" {
+		return nil, fmt.Errorf("REPORT_ID requires APP_INCLUDE_REGEX or APP_NAME_QUERY to select its application")
+	}
+
+	if _, err := services.ParseOutputFormats(cfg.OutputFormat); err != nil {
+		return nil, fmt.Errorf("OUTPUT_FORMAT: %w", err)
+	}
+	if cfg.StaleAfter < 0 {
+		return nil, fmt.Errorf("STALE_AFTER must be a positive duration, got %s", cfg.StaleAfter)
+	}
+	if cfg.ScheduleInterval < 0 {
+		return nil, fmt.Errorf("SCHEDULE_INTERVAL must be a positive duration, got %s", cfg.ScheduleInterval)
+	}
+	if cfg.ScheduleInterval > 0 && cfg.OutputMode != services.OutputModeCount && cfg.OutputDest == services.OutputDestFile &&
+		!strings.Contains(cfg.OutputFilenameTemplate, "{date}") && !strings.Contains(cfg.OutputFilenameTemplate, "{time}") {
+		return nil, fmt.Errorf("SCHEDULE_INTERVAL requires {date} or {time} in OUTPUT_FILENAME_TEMPLATE so runs do not overwrite each other")
+	}
+
+	if cfg.OutputS3URI != "" {
+		if _, _, err := upload.ParseS3URI(cfg.OutputS3URI); err != nil {
+			return nil, fmt.Errorf("OUTPUT_S3_URI: %w", err)
+		}
+	}
+
+	if cfg.fileMode, err = parseFileMode("OUTPUT_FILE_MODE", cfg.OutputFileMode); err != nil {
+		return nil, err
+	}
+	if cfg.dirMode, err = parseFileMode("OUTPUT_DIR_MODE", cfg.OutputDirMode); err != nil {
+		return nil, err
+	}
+
+	if cfg.OrganizationIDsFile != "" {
+		fileIDs, err := readIDFile("ORGANIZATION_IDS_FILE", cfg.OrganizationIDsFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OrganizationIDs = mergeIDs(cfg.OrganizationIDs, fileIDs)
+	}
+	if cfg.OrganizationID != "" && len(cfg.OrganizationIDs) > 0 {
+		return nil, fmt.Errorf("set ORGANIZATION_ID or ORGANIZATION_IDS/ORGANIZATION_IDS_FILE, not both")
+	}
+	if cfg.AppFetchChunkSize > 0 && (cfg.OrganizationID != "" || cfg.AppNameQuery != "" || cfg.ReportID != "") {
+		return nil, fmt.Errorf("APP_FETCH_CHUNK_SIZE cannot be combined with ORGANIZATION_ID, APP_NAME_QUERY or REPORT_ID")
+	}
+
+	if cfg.headers, err = parseHeaders(cfg.HTTPHeaders); err != nil {
+		return nil, err
+	}
+
+	if cfg.IQServers != "" {
+		if cfg.servers, err = parseServers(cfg.IQServers); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.IQServers == "" || cfg.IQPassword != "" || cfg.IQPasswordFile != "" {
+		if cfg.IQPassword, err = resolveSecret("IQ_PASSWORD", cfg.IQPassword, cfg.IQPasswordFile); err != nil {
+			return nil, err
+		}
+	}
 
 	// Validate the config
 	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
+	if err := validate.RegisterValidation("regexp", validateRegexp); err != nil {
 		return nil, err
 	}
+	if err := validate.Struct(cfg); err != nil {
+		return nil, friendlyValidationError(err)
+	}
 
 	return cfg, nil
 }
+
+// ServiceOptions converts the env-derived config into the service's plain Options.
+func (c *Config) ServiceOptions(logger zerolog.Logger) services.Options {
+	return services.Options{
+		ServerURL:               c.IQServerURL,
+		Username:                c.IQUsername,
+		Password:                c.IQPassword,
+		Instances:               c.servers,
+		HTTPHeaders:             c.headers,
+		TLSServerName:           c.TLSServerName,
+		APIBasePath:             c.APIBasePath,
+		HTTPTrace:               c.HTTPTrace,
+		UserAgent:               c.HTTPUserAgent,
+		StrictAPIPath:           c.IQStrictAPIPath,
+		RecordDir:               c.RecordDir,
+		ReplayDir:               c.ReplayDir,
+		OrganizationID:          c.OrganizationID,
+		OrganizationIDs:         c.OrganizationIDs,
+		OnMissingOrg:            c.OnMissingOrg,
+		OrgNamesOptional:        c.OrgNamesOptional,
+		OnDuplicateApp:          c.OnDuplicateApp,
+		AppIncludeRegex:         c.AppIncludeRegex,
+		AppExcludeRegex:         c.AppExcludeRegex,
+		AppNameQuery:            c.AppNameQuery,
+		AppFetchChunkSize:       c.AppFetchChunkSize,
+		ReportStage:             c.ReportStage,
+		ReportID:                c.ReportID,
+		ReportSelection:         c.ReportSelection,
+		ReportStageOrder:        c.ReportStageOrder,
+		Since:                   c.since,
+		StaleAfter:              c.StaleAfter,
+		MaxConcurrency:          c.MaxConcurrency,
+		HTTPMaxIdleConns:        c.HTTPMaxIdleConns,
+		HTTPMaxIdleConnsPerHost: c.HTTPMaxIdleConnsPerHost,
+		HTTPIdleConnTimeout:     time.Duration(c.HTTPIdleConnTimeoutSeconds) * time.Second,
+		RequestsPerSecond:       c.RequestsPerSecond,
+		BreakerThreshold:        c.CircuitBreakerThreshold,
+		BreakerWindow:           time.Duration(c.CircuitBreakerWindowSeconds) * time.Second,
+		BreakerCooldown:         time.Duration(c.CircuitBreakerCooldownSeconds) * time.Second,
+		ProgressLogEvery:        c.ProgressLogEvery,
+		ProgressLogInterval:     time.Duration(c.ProgressLogSeconds) * time.Second,
+		Retries:                 c.HTTPMaxRetries,
+		RetryBaseWait:           time.Duration(c.HTTPRetryBaseWaitSeconds) * time.Second,
+		RetryMaxWait:            time.Duration(c.HTTPRetryMaxWaitSeconds) * time.Second,
+		AppTimeout:              time.Duration(c.AppTimeoutSeconds) * time.Second,
+		RunTimeout:              time.Duration(c.RunTimeoutSeconds) * time.Second,
+		WritePartialOnTimeout:   c.WritePartialOnTimeout,
+		OutputDir:               c.OutputDir,
+		OutputFilenameTemplate:  c.OutputFilenameTemplate,
+		OutputFormat:            c.OutputFormat,
+		OutputMode:              c.OutputMode,
+		OutputColumns:           c.OutputColumns,
+		ExtraColumns:            c.ExtraColumns,
+		OutputDest:              c.OutputDest,
+		SplitByOrg:              c.SplitByOrg,
+		StreamOutput:            c.StreamOutput,
+		StreamBuffer:            c.StreamBuffer,
+		OutputGzip:              c.OutputGzip,
+		OutputFileMode:          c.fileMode,
+		OutputDirMode:           c.dirMode,
+		TempFileMaxAge:          time.Duration(c.TempFileMaxAgeMinutes) * time.Minute,
+		MaxRows:                 c.MaxRows,
+		MarkdownConditionWidth:  c.MarkdownConditionWidth,
+		CSVDelimiter:            firstRune(c.CSVDelimiter),
+		CSVWriteBOM:             c.CSVWriteBOM,
+		ThreatAsFloat:           c.ThreatAsFloat,
+		PolicyInclude:           c.PolicyInclude,
+		PolicyExclude:           c.PolicyExclude,
+		PolicyFilterStrict:      c.PolicyFilterStrict,
+		PolicyPageSize:          c.PolicyPageSize,
+		ConditionSeparator:      c.ConditionSeparator,
+		IncludeCleanComponents:  c.IncludeCleanComponents,
+		MarkCleanApps:           c.MarkCleanApps,
+		IncludeRemediation:      c.IncludeRemediation,
+		IncludeReportURL:        c.IncludeReportURL,
+		IncludeReasons:          c.IncludeReasons,
+		Redact:                  c.Redact,
+		RedactSalt:              c.RedactSalt,
+		RedactKeyFile:           c.RedactKeyFile,
+		EnrichCVE:               c.EnrichCVE,
+		WriteErrorReport:        c.WriteErrorReport,
+		ErrorReportAccessDenied: c.ErrorReportIncludeAccessDenied,
+		WriteLatest:             c.WriteLatest,
+		Resume:                  c.Resume,
+		MetricsPushgatewayURL:   c.MetricsPushgatewayURL,
+		NotifyWebhookURL:        c.NotifyWebhookURL,
+		Logger:                  logger,
+	}
+}
+
+// firstRune returns the first character of s, or 0 when s is empty.
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+// stdin is where a "-" secret file is read from; tests replace it.
+var stdin io.Reader = os.Stdin
+
+// resolveSecret returns the secret named name from exactly one source: the plain value or
+// the file at path ("-" for stdin, read once at startup). A trailing newline is trimmed.
+func resolveSecret(name, value, path string) (string, error) {
+	switch {
+	case value != "" && path != "":
+		return "", fmt.Errorf("set only one of %s and %s_FILE", name, name)
+	case path == "":
+		if value == "" {
+			return "", fmt.Errorf("%s or %s_FILE is required", name, name)
+		}
+		return value, nil
+	}
+
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE %s: %w", name, path, err)
+	}
+	secret := strings.TrimRight(string(b), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s_FILE %s is empty", name, path)
+	}
+	return secret, nil
+}
+
+// parseFileMode parses an octal permission string such as "0600" or "0o600" for the env var name.
+func parseFileMode(name, s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%s must be an octal permission like 0640, got %q", name, s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// parseSince converts SINCE into an absolute cutoff: RFC3339 timestamps are used as-is,
+// durations are subtracted from now. An empty value means no cutoff.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("SINCE %q is neither an RFC3339 timestamp nor a positive duration", s)
+	}
+	return now.Add(-d), nil
+}
+
+// cleanOutputDir normalizes dir and rejects traversal outside the working directory.
+// Absolute paths are only accepted when allowAbsolute is set.
+func cleanOutputDir(dir string, allowAbsolute bool) (string, error) {
+	cleaned := filepath.Clean(dir)
+	if filepath.IsAbs(cleaned) {
+		if !allowAbsolute {
+			return "", fmt.Errorf("OUTPUT_DIR %q is absolute; set OUTPUT_DIR_ALLOW_ABSOLUTE=true to allow it", dir)
+		}
+		return cleaned, nil
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("OUTPUT_DIR %q escapes the working directory", dir)
+	}
+	return cleaned, nil
+}
+
+// validateRegexp reports whether the field holds a compilable regular expression.
+func validateRegexp(fl validator.FieldLevel) bool {
+	_, err := regexp.Compile(fl.Field().String())
+	return err == nil
+}
@@ -3,7 +3,14 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+	"github.com/rs/zerolog"
 )
 
 func TestLoad_WithEnvVars_Succeeds(t *testing.T) {
@@ -26,6 +33,23 @@ func TestLoad_WithEnvVars_Succeeds(t *testing.T) {
 	if cfg.OutputDir != "reports_output" {
 		t.Errorf("OutputDir = %q", cfg.OutputDir)
 	}
+	if cfg.OutputFilenameTemplate != "{date}_{time}.{format}" {
+		t.Errorf("OutputFilenameTemplate = %q", cfg.OutputFilenameTemplate)
+	}
+
+	opts := cfg.ServiceOptions(zerolog.Nop())
+	if opts.ServerURL != cfg.IQServerURL || opts.Password != "pass" || opts.MaxConcurrency != 10 {
+		t.Errorf("ServiceOptions not mapped: %#v", opts)
+	}
+}
+
+func TestLoad_FilenameTemplateWithSeparator_Fails(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OUTPUT_FILENAME_TEMPLATE", "../{date}.csv")
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for template containing a path separator")
+	}
 }
 
 func TestLoad_MissingRequired_Fails(t *testing.T) {
@@ -37,3 +61,645 @@ func TestLoad_MissingRequired_Fails(t *testing.T) {
 		t.Fatalf("expected error for missing required env")
 	}
 }
+
+func TestLoad_InvalidAppRegex_Fails(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_EXCLUDE_REGEX", "([")
+
+	if _, err := Load(); err == nil {
+		t.Fatalf("expected error for invalid APP_EXCLUDE_REGEX")
+	}
+}
+
+func TestLoad_OutputDirValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		dir           string
+		allowAbsolute string
+		want          string
+		wantErr       bool
+	}{
+		{"relative cleaned", "./reports//out/", "false", filepath.Join("reports", "out"), false},
+		{"traversal rejected", "../../etc", "false", "", true},
+		{"absolute rejected by default", "/var/reports", "false", "", true},
+		{"absolute allowed", "/var/reports/", "true", "/var/reports", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("OUTPUT_DIR", tt.dir)
+			t.Setenv("OUTPUT_DIR_ALLOW_ABSOLUTE", tt.allowAbsolute)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for OUTPUT_DIR %q", tt.dir)
+				}
+				if !strings.Contains(err.Error(), "OUTPUT_DIR") {
+					t.Errorf("error should name OUTPUT_DIR: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.OutputDir != tt.want {
+				t.Errorf("OutputDir = %q, want %q", cfg.OutputDir, tt.want)
+			}
+		})
+	}
+}
+
+// setRequiredEnv sets the mandatory IQ connection variables for a test.
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("IQ_SERVER_URL", "http://example.com/api/v2")
+	t.Setenv("IQ_USERNAME", "user")
+	t.Setenv("IQ_PASSWORD", "pass")
+}
+
+func TestLoadFile_ExplicitPresent(t *testing.T) {
+	clearRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "custom.env")
+	content := "IQ_SERVER_URL=http://iq.example.com\nIQ_USERNAME=fileuser\nIQ_PASSWORD=filepass\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.IQUsername != "fileuser" {
+		t.Errorf("IQUsername = %q, want fileuser", cfg.IQUsername)
+	}
+}
+
+func TestLoadFile_ExplicitMissing_Fails(t *testing.T) {
+	setRequiredEnv(t)
+	missing := filepath.Join(t.TempDir(), "nope.env")
+
+	if _, err := LoadFile(missing); err == nil {
+		t.Fatal("expected error for missing explicit config file")
+	}
+
+	t.Setenv("CONFIG_FILE", missing)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for missing CONFIG_FILE")
+	}
+}
+
+func TestLoadFile_Default(t *testing.T) {
+	clearRequiredEnv(t)
+	t.Setenv("CONFIG_FILE", "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// Without config/.env, loading falls back to the environment alone.
+	if _, err := LoadFile(""); err == nil {
+		t.Fatal("expected missing-required error with no config file and empty env")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "IQ_SERVER_URL=http://iq.example.com\nIQ_USERNAME=defaultuser\nIQ_PASSWORD=pass\n"
+	if err := os.WriteFile(filepath.Join(dir, DefaultConfigFile), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.IQUsername != "defaultuser" {
+		t.Errorf("IQUsername = %q, want defaultuser", cfg.IQUsername)
+	}
+}
+
+// clearRequiredEnv unsets the required variables for the duration of the test,
+// so values loaded from dotenv files do not leak between tests.
+func clearRequiredEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"IQ_SERVER_URL", "IQ_USERNAME", "IQ_PASSWORD"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "24h", want: now.Add(-24 * time.Hour)},
+		{in: "2024-05-01T00:00:00Z", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "-1h", wantErr: true},
+		{in: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{in: "0600", want: 0o600},
+		{in: "640", want: 0o640},
+		{in: "0o750", want: 0o750},
+		{in: "", wantErr: true},
+		{in: "0800", wantErr: true},
+		{in: "01777", wantErr: true},
+		{in: "rw-r-----", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFileMode("OUTPUT_FILE_MODE", tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFileMode(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseFileMode(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_CSVDelimiter(t *testing.T) {
+	tests := []struct {
+		delim   string
+		want    rune
+		wantErr bool
+	}{
+		{delim: ";", want: ';'},
+		{delim: "\t", want: '\t'},
+		{delim: ";;", wantErr: true},
+		{delim: `"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.delim, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("CSV_DELIMITER", tt.delim)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if got := cfg.ServiceOptions(zerolog.Nop()).CSVDelimiter; got != tt.want {
+					t.Errorf("CSVDelimiter = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadWith_JSONFile(t *testing.T) {
+	writeConfigJSON := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("file only", func(t *testing.T) {
+		clearRequiredEnv(t)
+		path := writeConfigJSON(t, `{
+			"iqServerUrl": "http://iq.example.com",
+			"iqUsername": "jsonuser",
+			"iqPassword": "jsonpass",
+			"maxConcurrency": 4,
+			"outputGzip": true
+		}`)
+		cfg, err := LoadWith(LoadOptions{JSONFile: path})
+		if err != nil {
+			t.Fatalf("LoadWith: %v", err)
+		}
+		if cfg.IQUsername != "jsonuser" || cfg.MaxConcurrency != 4 || !cfg.OutputGzip {
+			t.Errorf("cfg = %+v", cfg)
+		}
+	})
+
+	t.Run("env only", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("CONFIG_JSON", "")
+		cfg, err := LoadWith(LoadOptions{})
+		if err != nil {
+			t.Fatalf("LoadWith: %v", err)
+		}
+		if cfg.IQUsername != "user" {
+			t.Errorf("IQUsername = %q, want user", cfg.IQUsername)
+		}
+	})
+
+	t.Run("env overrides file", func(t *testing.T) {
+		clearRequiredEnv(t)
+		path := writeConfigJSON(t, `{"iqServerUrl": "http://iq.example.com", "iqUsername": "jsonuser", "maxConcurrency": 4}`)
+		t.Setenv("CONFIG_JSON", path)
+		t.Setenv("IQ_PASSWORD", "secret-from-env")
+		t.Setenv("MAX_CONCURRENCY", "7")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if cfg.IQPassword != "secret-from-env" || cfg.MaxConcurrency != 7 || cfg.IQUsername != "jsonuser" {
+			t.Errorf("cfg = %+v", cfg)
+		}
+	})
+
+	t.Run("server list block", func(t *testing.T) {
+		clearRequiredEnv(t)
+		t.Setenv("IQ_SERVERS", "")
+		os.Unsetenv("IQ_SERVERS") // an empty env var would still override the file
+		path := writeConfigJSON(t, `{"iqServers": [
+			{"name": "prod", "url": "https://iq.example.com", "username": "u", "password": "p"}
+		]}`)
+		cfg, err := LoadWith(LoadOptions{JSONFile: path})
+		if err != nil {
+			t.Fatalf("LoadWith: %v", err)
+		}
+		if len(cfg.servers) != 1 || cfg.servers[0].Name != "prod" {
+			t.Errorf("servers = %+v", cfg.servers)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		setRequiredEnv(t)
+		path := writeConfigJSON(t, `{"iqServerURL": "http://typo.example.com"}`)
+		if _, err := LoadWith(LoadOptions{JSONFile: path}); err == nil || !strings.Contains(err.Error(), `unknown key "iqServerURL"`) {
+			t.Fatalf("expected unknown key error, got %v", err)
+		}
+	})
+}
+
+func TestLoad_RecordAndReplayExclusive(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("RECORD_DIR", "cassettes")
+	t.Setenv("REPLAY_DIR", "cassettes")
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error when both RECORD_DIR and REPLAY_DIR are set")
+	}
+}
+
+func TestLoad_ReportSelection(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("REPORT_SELECTION", "stage")
+	t.Setenv("REPORT_STAGE_ORDER", "build,release")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if cfg.ReportSelection != "stage" || !slices.Equal(cfg.ReportStageOrder, []string{"build", "release"}) {
+		t.Errorf("selection = %q order = %v", cfg.ReportSelection, cfg.ReportStageOrder)
+	}
+
+	t.Setenv("REPORT_STAGE_ORDER", "build,prod")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for unknown stage in REPORT_STAGE_ORDER")
+	}
+}
+
+func TestLoad_PasswordFile(t *testing.T) {
+	setRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "iq-password")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		t.Setenv("IQ_PASSWORD", "")
+		t.Setenv("IQ_PASSWORD_FILE", path)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.IQPassword != "s3cret" {
+			t.Errorf("IQPassword = %q, want trailing newline trimmed", cfg.IQPassword)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		old := stdin
+		t.Cleanup(func() { stdin = old })
+		stdin = strings.NewReader("from-stdin\r\n")
+		t.Setenv("IQ_PASSWORD", "")
+		t.Setenv("IQ_PASSWORD_FILE", "-")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.IQPassword != "from-stdin" {
+			t.Errorf("IQPassword = %q", cfg.IQPassword)
+		}
+	})
+
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("IQ_PASSWORD_FILE", path)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "only one") {
+			t.Errorf("Load() error = %v, want only-one error", err)
+		}
+	})
+
+	t.Run("missing all", func(t *testing.T) {
+		t.Setenv("IQ_PASSWORD", "")
+		t.Setenv("IQ_PASSWORD_FILE", "")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "IQ_PASSWORD_FILE") {
+			t.Errorf("Load() error = %v, want missing-password error", err)
+		}
+	})
+}
+
+func TestLoad_OrganizationIDsFile(t *testing.T) {
+	setRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "orgs.txt")
+	content := "# managed by the platform team\norg-b\n\n  org-c  # sandbox\norg-a\r\norg-b\n   \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORGANIZATION_IDS", "org-a, org-d")
+	t.Setenv("ORGANIZATION_IDS_FILE", path)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"org-a", "org-d", "org-b", "org-c"}
+	if !slices.Equal(cfg.OrganizationIDs, want) {
+		t.Errorf("OrganizationIDs = %q, want %q", cfg.OrganizationIDs, want)
+	}
+	if got := cfg.ServiceOptions(zerolog.Nop()).OrganizationIDs; !slices.Equal(got, want) {
+		t.Errorf("ServiceOptions().OrganizationIDs = %q, want %q", got, want)
+	}
+
+	t.Setenv("ORGANIZATION_ID", "org-a")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("Load() error = %v, want ORGANIZATION_ID conflict", err)
+	}
+
+	t.Setenv("ORGANIZATION_ID", "")
+	t.Setenv("ORGANIZATION_IDS_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ORGANIZATION_IDS_FILE") {
+		t.Errorf("Load() error = %v, want missing file error", err)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: nil},
+		{in: " , ,", want: nil},
+		{in: "a", want: []string{"a"}},
+		{in: "a,b,", want: []string{"a", "b"}},
+		{in: " a , b ", want: []string{"a", "b"}},
+		{in: "a,,b", want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := parseList(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("parseList(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_ListFieldsTrimmed(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("POLICY_INCLUDE", " Security-*, License,")
+	t.Setenv("POLICY_EXCLUDE", ",")
+	t.Setenv("REPORT_STAGE_ORDER", "build, release ,")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if !slices.Equal(cfg.PolicyInclude, []string{"Security-*", "License"}) || cfg.PolicyExclude != nil {
+		t.Errorf("include = %q exclude = %q", cfg.PolicyInclude, cfg.PolicyExclude)
+	}
+	if !slices.Equal(cfg.ReportStageOrder, []string{"build", "release"}) {
+		t.Errorf("stage order = %q", cfg.ReportStageOrder)
+	}
+}
+
+func TestLoad_OutputColumns(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OUTPUT_COLUMNS", "CVE, Component,Application")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if !slices.Equal(cfg.OutputColumns, []string{"CVE", "Component", "Application"}) {
+		t.Errorf("OutputColumns = %q", cfg.OutputColumns)
+	}
+
+	t.Setenv("OUTPUT_COLUMNS", "Application,Owner")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "Owner") {
+		t.Errorf("err = %v, want unknown column Owner", err)
+	}
+
+	t.Setenv("OUTPUT_COLUMNS", "")
+	t.Setenv("EXTRA_COLUMNS", "Owner")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "EXTRA_COLUMNS") {
+		t.Errorf("err = %v, want unknown extra column Owner", err)
+	}
+}
+
+func TestLoad_Gate(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OUTPUT_MODE", "count")
+	t.Setenv("GATE", "threat>=8")
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+
+	t.Setenv("GATE", "threat>=high")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "GATE") {
+		t.Errorf("err = %v, want an invalid GATE", err)
+	}
+
+	t.Setenv("GATE", "count>0")
+	t.Setenv("OUTPUT_MODE", "detailed")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "OUTPUT_MODE=count") {
+		t.Errorf("err = %v, want GATE to require count mode", err)
+	}
+}
+
+func TestLoad_ReportID(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("REPORT_ID", "rpt-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "REPORT_ID") {
+		t.Errorf("err = %v, want REPORT_ID to require an application filter", err)
+	}
+
+	t.Setenv("APP_INCLUDE_REGEX", "^my-app$")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if got := cfg.ServiceOptions(zerolog.Nop()).ReportID; got != "rpt-1" {
+		t.Errorf("ReportID = %q, want rpt-1", got)
+	}
+}
+
+func TestLoad_AppFetchChunkSize(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_FETCH_CHUNK_SIZE", "10")
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	t.Setenv("ORGANIZATION_ID", "org-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "APP_FETCH_CHUNK_SIZE") {
+		t.Errorf("err = %v, want APP_FETCH_CHUNK_SIZE rejected with ORGANIZATION_ID", err)
+	}
+}
+
+func TestLoad_ScheduleInterval(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if cfg.ScheduleInterval != 0 {
+		t.Errorf("default ScheduleInterval = %s, want 0 (run once)", cfg.ScheduleInterval)
+	}
+
+	t.Setenv("SCHEDULE_INTERVAL", "6h")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if cfg.ScheduleInterval != 6*time.Hour {
+		t.Errorf("ScheduleInterval = %s, want 6h", cfg.ScheduleInterval)
+	}
+
+	t.Setenv("SCHEDULE_INTERVAL", "-1h")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SCHEDULE_INTERVAL") {
+		t.Errorf("err = %v, want a negative SCHEDULE_INTERVAL rejected", err)
+	}
+
+	t.Setenv("SCHEDULE_INTERVAL", "1h")
+	t.Setenv("OUTPUT_FILENAME_TEMPLATE", "latest.{format}")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "OUTPUT_FILENAME_TEMPLATE") {
+		t.Errorf("err = %v, want a fixed filename rejected", err)
+	}
+}
+
+func TestLoad_OutputFormats(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OUTPUT_FORMAT", "CSV, json")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if got := cfg.ServiceOptions(zerolog.Nop()).OutputFormat; got != "CSV, json" {
+		t.Errorf("OutputFormat = %q, want the list passed through", got)
+	}
+
+	t.Setenv("OUTPUT_FORMAT", "csv,xml")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "OUTPUT_FORMAT") {
+		t.Errorf("err = %v, want an unknown format rejected", err)
+	}
+}
+
+func TestLoad_TLSServerName(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("TLS_SERVER_NAME", "iq.internal.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load error = %v", err)
+	}
+	if got := cfg.ServiceOptions(zerolog.Nop()).TLSServerName; got != "iq.internal.example.com" {
+		t.Errorf("TLSServerName = %q, want iq.internal.example.com", got)
+	}
+
+	t.Setenv("TLS_SERVER_NAME", "https://iq.example.com")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TLS_SERVER_NAME must be a host name") {
+		t.Errorf("err = %v, want a URL rejected as server name", err)
+	}
+}
+
+func TestLoad_IQServers(t *testing.T) {
+	for _, k := range []string{"IQ_SERVER_URL", "IQ_USERNAME", "IQ_PASSWORD", "IQ_PASSWORD_FILE"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("IQ_SERVERS", `[
+		{"name": "staging", "url": "https://iq-staging.example.com", "username": "u1", "password": "p1"},
+		{"url": "https://iq.example.com:8443", "username": "u2", "password": "p2"}
+	]`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := cfg.ServiceOptions(zerolog.Nop()).Instances
+	want := []services.Instance{
+		{Name: "staging", ServerURL: "https://iq-staging.example.com", Username: "u1", Password: "p1"},
+		{Name: "iq.example.com", ServerURL: "https://iq.example.com:8443", Username: "u2", Password: "p2"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Instances = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{
+		`{"url": "https://iq.example.com"}`,
+		`[{"url": "iq.example.com", "username": "u", "password": "p"}]`,
+		`[{"url": "https://iq.example.com", "username": "u"}]`,
+		`[{"url": "https://a.example.com", "username": "u", "password": "p", "name": "x"},
+		  {"url": "https://b.example.com", "username": "u", "password": "p", "name": "x"}]`,
+		`[{"url": "https://iq.example.com", "username": "u", "password": "p", "token": "t"}]`,
+	} {
+		t.Setenv("IQ_SERVERS", bad)
+		if _, err := Load(); err == nil {
+			t.Errorf("IQ_SERVERS=%s: expected error", bad)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		{in: "", want: nil},
+		{in: "X-Gateway-Token: abc", want: map[string]string{"X-Gateway-Token": "abc"}},
+		{in: "x-gateway-token:abc, X-Team:sec:ops\n\nX-Env: prod ", want: map[string]string{"X-Gateway-Token": "abc", "X-Team": "sec:ops", "X-Env": "prod"}},
+		{in: "X-Gateway-Token", wantErr: "not a Key:Value pair"},
+		{in: "Bad Name: x", wantErr: "invalid header name"},
+		{in: "authorization: Bearer t", wantErr: "cannot be overridden"},
+		{in: "X-A: 1, x-a: 2", wantErr: "listed twice"},
+	}
+	for _, tt := range tests {
+		got, err := parseHeaders(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseHeaders(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseHeaders(%q) error = %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseHeaders(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("parseHeaders(%q)[%s] = %q, want %q", tt.in, k, got[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// internal/config/headers.go
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+)
+
+// parseHeaders decodes HTTP_HEADERS: Key:Value pairs separated by commas or newlines, split
+// at the first colon, with surrounding spaces and empty entries ignored. Names are
+// canonicalized; a name given twice, a malformed pair or Authorization is an error.
+func parseHeaders(s string) (map[string]string, error) {
+	entries := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' })
+	var headers map[string]string
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("HTTP_HEADERS: %q is not a Key:Value pair", strings.TrimSpace(entry))
+		}
+		name, value = http.CanonicalHeaderKey(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if err := client.CheckHeader(name, value); err != nil {
+			return nil, fmt.Errorf("HTTP_HEADERS: %w", err)
+		}
+		if _, dup := headers[name]; dup {
+			return nil, fmt.Errorf("HTTP_HEADERS: header %s listed twice", name)
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
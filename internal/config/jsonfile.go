@@ -0,0 +1,114 @@
+// internal/config/jsonfile.go
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// jsonEnvironment reads a flat JSON config object and returns its values keyed by env
+// var name, ready to be layered under the real environment. Keys are the camelCase form
+// of the env var names (IQ_SERVER_URL -> iqServerUrl); unknown keys are rejected so
+// typos do not silently fall back to defaults.
+func jsonEnvironment(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config json %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parse config json %s: %w", path, err)
+	}
+
+	names := envNamesByJSONKey()
+	out := make(map[string]string, len(raw))
+	for key, v := range raw {
+		name, ok := names[key]
+		if !ok {
+			return nil, fmt.Errorf("config json %s: unknown key %q", path, key)
+		}
+		if v == nil {
+			continue
+		}
+		s, err := jsonScalar(v)
+		if err != nil {
+			return nil, fmt.Errorf("config json %s: key %q: %w", path, key, err)
+		}
+		out[name] = s
+	}
+	return out, nil
+}
+
+// envNamesByJSONKey maps the JSON key of every env-backed Config field to its env var name.
+func envNamesByJSONKey() map[string]string {
+	t := reflect.TypeFor[Config]()
+	names := make(map[string]string, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		names[jsonKey(name)] = name
+	}
+	return names
+}
+
+// jsonKey converts an env var name to its camelCase JSON key, e.g. IQ_SERVER_URL -> iqServerUrl.
+func jsonKey(envName string) string {
+	parts := strings.Split(strings.ToLower(envName), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// jsonScalar renders a decoded JSON value in the string form env parsing expects.
+// Arrays become comma-separated lists; arrays of objects (such as iqServers) are kept
+// as JSON text.
+func jsonScalar(v any) (string, error) {
+	if items, ok := v.([]any); ok && slices.ContainsFunc(items, isJSONObject) {
+		b, err := json.Marshal(items)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := jsonScalar(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// isJSONObject reports whether a decoded JSON value is an object.
+func isJSONObject(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
@@ -0,0 +1,26 @@
+// internal/config/list.go
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// parseList splits a comma-separated value, trimming whitespace and dropping empty entries,
+// so "a, b,," and " a,b " both yield [a b]. An empty or blank value yields nil.
+func parseList(s string) []string {
+	var out []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// envParsers decodes every []string Config field with parseList instead of env's plain split.
+var envParsers = map[reflect.Type]env.ParserFunc{
+	reflect.TypeFor[[]string](): func(v string) (any, error) { return parseList(v), nil },
+}
@@ -0,0 +1,40 @@
+// internal/config/orgids.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// readIDFile reads a newline-delimited list of IDs, ignoring blank lines and anything
+// after a #, so both whole-line and trailing comments are allowed.
+func readIDFile(name, path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var ids []string
+	for line := range strings.Lines(string(b)) {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+	return ids, nil
+}
+
+// mergeIDs appends the IDs of more to ids that are not already listed, keeping the
+// first occurrence of each.
+func mergeIDs(ids, more []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range slices.Concat(ids, more) {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
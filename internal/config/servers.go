@@ -0,0 +1,56 @@
+// internal/config/servers.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/services"
+)
+
+// iqServer is one entry of IQ_SERVERS.
+type iqServer struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// parseServers decodes IQ_SERVERS, a JSON array of {"name", "url", "username", "password"}
+// objects. The name defaults to the URL's host and must be unique.
+func parseServers(s string) ([]services.Instance, error) {
+	var servers []iqServer
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&servers); err != nil {
+		return nil, fmt.Errorf("IQ_SERVERS: expected a JSON array of {name, url, username, password}: %w", err)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("IQ_SERVERS: no servers listed")
+	}
+
+	instances := make([]services.Instance, 0, len(servers))
+	var names []string
+	for i, srv := range servers {
+		u, err := url.Parse(srv.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("IQ_SERVERS[%d]: url %q must be an absolute URL", i, srv.URL)
+		}
+		if srv.Username == "" || srv.Password == "" {
+			return nil, fmt.Errorf("IQ_SERVERS[%d]: username and password are required", i)
+		}
+		name := srv.Name
+		if name == "" {
+			name = u.Hostname()
+		}
+		if slices.Contains(names, name) {
+			return nil, fmt.Errorf("IQ_SERVERS[%d]: name %q is used twice", i, name)
+		}
+		names = append(names, name)
+		instances = append(instances, services.Instance{Name: name, ServerURL: srv.URL, Username: srv.Username, Password: srv.Password})
+	}
+	return instances, nil
+}
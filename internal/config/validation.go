@@ -0,0 +1,96 @@
+// internal/config/validation.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError lists the settings that failed validation, each as a sentence naming
+// the environment variable and what it expects, e.g. "IQ_SERVER_URL must be a valid URL".
+type ValidationError struct {
+	Problems []string
+	// Err is the underlying validator error.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// validationHints says what each validation tag expects; %s is replaced by the tag's
+// parameter, with field names (required_without, excluded_with) turned into env names.
+var validationHints = map[string]string{
+	"required":         "is required",
+	"required_without": "is required unless %s is set",
+	"excluded_with":    "cannot be combined with %s",
+	"url":              "must be a valid URL such as https://iq.example.com",
+	"oneof":            "must be one of: %s",
+	"min":              "must be at least %s",
+	"max":              "must be at most %s",
+	"len":              "must be exactly %s character(s) long",
+	"startswith":       "must start with %s",
+	"excludesall":      "must not contain any of %q",
+	"regexp":           "must be a valid Go regular expression",
+	"hostname_rfc1123": "must be a host name such as iq.example.com",
+}
+
+// friendlyValidationError turns the error of validate.Struct(cfg) into a *ValidationError;
+// other errors are returned unchanged.
+func friendlyValidationError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	problems := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		problems = append(problems, validationProblem(fe))
+	}
+	return &ValidationError{Problems: problems, Err: err}
+}
+
+// validationProblem describes one failed check.
+func validationProblem(fe validator.FieldError) string {
+	field, entry, isEntry := strings.Cut(fe.StructField(), "[")
+	name := envName(field)
+	if isEntry {
+		// A dive into a list reports the zero-based entry, e.g. ReportStageOrder[1]
+		if i, err := strconv.Atoi(strings.TrimSuffix(entry, "]")); err == nil {
+			name = fmt.Sprintf("%s entry %d", name, i+1)
+		}
+	}
+
+	hint, ok := validationHints[fe.Tag()]
+	if !ok {
+		return fmt.Sprintf("%s failed the %q check", name, fe.Tag())
+	}
+	if strings.Contains(hint, "%") {
+		param := fe.Param()
+		if fe.Tag() == "required_without" || fe.Tag() == "excluded_with" {
+			param = envName(param)
+		}
+		hint = fmt.Sprintf(hint, param)
+	}
+	if strings.HasPrefix(fe.Tag(), "required") {
+		return name + " " + hint
+	}
+	return fmt.Sprintf("%s %s (got %q)", name, hint, fmt.Sprint(fe.Value()))
+}
+
+// envName returns the environment variable of the Config field called field, or field
+// itself when it has none.
+func envName(field string) string {
+	if f, ok := reflect.TypeFor[Config]().FieldByName(field); ok {
+		if name := f.Tag.Get("env"); name != "" {
+			return name
+		}
+	}
+	return field
+}
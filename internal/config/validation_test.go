@@ -0,0 +1,60 @@
+// internal/config/validation_test.go
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoad_ValidationMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "missing URL",
+			env:  map[string]string{"IQ_SERVER_URL": ""},
+			want: "IQ_SERVER_URL is required unless IQ_SERVERS is set",
+		},
+		{
+			name: "invalid URL",
+			env:  map[string]string{"IQ_SERVER_URL": "iq.example.com"},
+			want: `IQ_SERVER_URL must be a valid URL such as https://iq.example.com (got "iq.example.com")`,
+		},
+		{
+			name: "out of range",
+			env:  map[string]string{"MAX_CONCURRENCY": "0"},
+			want: `MAX_CONCURRENCY must be at least 1 (got "0")`,
+		},
+		{
+			name: "list entry",
+			env:  map[string]string{"REPORT_STAGE_ORDER": "build,prod"},
+			want: `REPORT_STAGE_ORDER entry 2 must be one of: source build stage-release release operate (got "prod")`,
+		},
+		{
+			name: "exclusive settings",
+			env:  map[string]string{"RECORD_DIR": "rec", "REPLAY_DIR": "rep"},
+			want: `RECORD_DIR cannot be combined with REPLAY_DIR (got "rec")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want a *ValidationError", err)
+			}
+			if len(verr.Problems) != 1 || verr.Problems[0] != tt.want {
+				t.Errorf("problems = %q, want [%q]", verr.Problems, tt.want)
+			}
+			if got, want := err.Error(), "invalid configuration: "+tt.want; got != want {
+				t.Errorf("Error() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// internal/metrics/push.go
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// jobName is the Pushgateway job label for all metrics pushed by this tool.
+const jobName = "iqfetch"
+
+// RunMetrics summarizes a single report run.
+type RunMetrics struct {
+	// Org is the organization filter used for the run ("all" when unfiltered).
+	Org string
+	// ApplicationsScanned is the number of applications in scope for the run.
+	ApplicationsScanned int
+	// ViolationRows is the total number of rows in the report.
+	ViolationRows int
+	// ByThreat counts rows per threat bucket (e.g. "critical", "high").
+	ByThreat map[string]int
+}
+
+// Push sends the run metrics as gauges to the Pushgateway at url, grouped by org.
+// An empty url is a no-op.
+func Push(ctx context.Context, url string, httpClient *http.Client, m RunMetrics) error {
+	if url == "" {
+		return nil
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	org := m.Org
+	if org == "" {
+		org = "all"
+	}
+
+	apps := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "iqfetch_applications_scanned",
+		Help: "Applications in scope for the last report run.",
+	})
+	apps.Set(float64(m.ApplicationsScanned))
+
+	rows := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "iqfetch_violation_rows",
+		Help: "Policy violation rows in the last report run.",
+	})
+	rows.Set(float64(m.ViolationRows))
+
+	byThreat := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "iqfetch_violations_by_threat",
+		Help: "Policy violation rows in the last report run, by threat bucket.",
+	}, []string{"threat"})
+	for bucket, n := range m.ByThreat {
+		byThreat.WithLabelValues(bucket).Set(float64(n))
+	}
+
+	lastRun := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "iqfetch_last_run_timestamp_seconds",
+		Help: "Unix time the last report run completed.",
+	})
+	lastRun.SetToCurrentTime()
+
+	err := push.New(url, jobName).
+		Client(httpClient).
+		Grouping("org", org).
+		Collector(apps).
+		Collector(rows).
+		Collector(byThreat).
+		Collector(lastRun).
+		PushContext(ctx)
+	if err != nil {
+		return fmt.Errorf("push metrics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// internal/metrics/push_test.go
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPush_SendsExpectedMetrics(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := Push(ctx, srv.URL, srv.Client(), RunMetrics{
+		Org:                 "org-1",
+		ApplicationsScanned: 3,
+		ViolationRows:       5,
+		ByThreat:            map[string]int{"high": 4, "low": 1},
+	})
+	if err != nil {
+		t.Fatalf("Push error = %v", err)
+	}
+
+	if gotPath != "/metrics/job/iqfetch/org/org-1" {
+		t.Errorf("path = %q", gotPath)
+	}
+	for _, name := range []string{
+		"iqfetch_applications_scanned",
+		"iqfetch_violation_rows",
+		"iqfetch_violations_by_threat",
+		"iqfetch_last_run_timestamp_seconds",
+	} {
+		if !bytes.Contains(gotBody, []byte(name)) {
+			t.Errorf("metric %s not pushed", name)
+		}
+	}
+}
+
+func TestPush_EmptyURLIsNoop(t *testing.T) {
+	if err := Push(context.Background(), "", nil, RunMetrics{}); err != nil {
+		t.Errorf("Push with empty URL error = %v", err)
+	}
+}
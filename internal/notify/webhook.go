@@ -0,0 +1,106 @@
+// internal/notify/webhook.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Run statuses reported in Payload.Status.
+const (
+	StatusSuccess = "success"
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// maxTextLen caps Payload.Text so chat webhooks accept the message.
+const maxTextLen = 1000
+
+// Payload is the JSON body posted to the webhook after a run. Text carries a one-line
+// summary, which Slack and Teams incoming webhooks display as the message.
+type Payload struct {
+	Text   string `json:"text"`
+	Status string `json:"status"`
+	// Org is the organization filter of the run, empty when unfiltered.
+	Org                string         `json:"org,omitempty"`
+	Applications       int            `json:"applications"`
+	AppsFailed         int            `json:"appsFailed"`
+	AppsWithViolations int            `json:"appsWithViolations"`
+	TotalRows          int            `json:"totalRows"`
+	ByThreat           map[string]int `json:"byThreat"`
+	// Paths lists the written report files; empty for stdout output.
+	Paths []string `json:"paths"`
+	Error string   `json:"error,omitempty"`
+}
+
+// threatOrder lists report.Severity buckets from most to least severe.
+var threatOrder = []string{"critical", "high", "medium", "low"}
+
+// Summarize fills p.Text from the other fields, e.g.
+// "iqfetch run success: 12 applications, 30 rows (critical 2, high 5), report reports_output/x.csv".
+func (p *Payload) Summarize() {
+	var b strings.Builder
+	fmt.Fprintf(&b, "iqfetch run %s: %d applications, %d rows", p.Status, p.Applications, p.TotalRows)
+	var threats []string
+	for _, t := range threatOrder {
+		if n := p.ByThreat[t]; n > 0 {
+			threats = append(threats, fmt.Sprintf("%s %d", t, n))
+		}
+	}
+	if len(threats) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(threats, ", "))
+	}
+	if p.AppsFailed > 0 {
+		fmt.Fprintf(&b, ", %d applications failed", p.AppsFailed)
+	}
+	if len(p.Paths) > 0 {
+		fmt.Fprintf(&b, ", report %s", strings.Join(p.Paths, ", "))
+	}
+	if p.Error != "" {
+		fmt.Fprintf(&b, ": %s", p.Error)
+	}
+	p.Text = truncate(b.String(), maxTextLen)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// Send posts p as JSON to url. A non-2xx response is an error. An empty url is a no-op.
+func Send(ctx context.Context, url string, httpClient *http.Client, p Payload) error {
+	if url == "" {
+		return nil
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// internal/notify/webhook_test.go
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend_PostsJSON(t *testing.T) {
+	var got map[string]any
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	p := Payload{Status: StatusSuccess, Applications: 2, TotalRows: 3, ByThreat: map[string]int{"high": 2, "critical": 1}, Paths: []string{"out/report.csv"}}
+	p.Summarize()
+	if err := Send(ctx, srv.URL, srv.Client(), p); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	want := "iqfetch run success: 2 applications, 3 rows (critical 1, high 2), report out/report.csv"
+	if got["text"] != want || got["status"] != StatusSuccess || got["totalRows"] != float64(3) {
+		t.Errorf("payload = %v, want text %q", got, want)
+	}
+}
+
+func TestSend_Non2xxFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := Send(context.Background(), srv.URL, srv.Client(), Payload{Status: StatusFailed})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("err = %v, want unexpected status 400", err)
+	}
+}
+
+func TestSummarize_TruncatesText(t *testing.T) {
+	p := Payload{Status: StatusFailed, Error: strings.Repeat("x", 2*maxTextLen)}
+	p.Summarize()
+	if n := len([]rune(p.Text)); n != maxTextLen || !strings.HasSuffix(p.Text, "…") {
+		t.Errorf("text has %d runes, want %d ending in an ellipsis", n, maxTextLen)
+	}
+}
@@ -0,0 +1,181 @@
+// internal/report/columns.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// column names a detailed report column: its header in tables and its key in JSON.
+type column struct {
+	header string
+	key    string
+}
+
+// allColumns lists the detailed report columns in their default order; csvRecord
+// produces the cells in the same order.
+var allColumns = []column{
+	{"No.", "no"},
+	{"Application", "application"},
+	{"Organization", "organization"},
+	{"Policy", "policy"},
+	{"Format", "format"},
+	{"Component", "component"},
+	{"Threat", "threat"},
+	{"Policy/Action", "policyAction"},
+	{"Constraint Name", "constraintName"},
+	{"Condition", "condition"},
+	{"CVE", "cve"},
+	{"Stage", "stage"},
+	{"Evaluated At", "evaluatedAt"},
+	{"Group", "group"},
+	{"Name", "name"},
+	{"Version", "version"},
+	{"CVE Severity", "cveSeverity"},
+	{"CVSS Score", "cvssScore"},
+	{"CVSS Vector", "cvssVector"},
+	{"CVE Description", "cveDescription"},
+	{"ID", "id"},
+	{"Clean", "clean"},
+	{"Severity", "severity"},
+	{"Recommended Version", "recommendedVersion"},
+	{"License", "license"},
+	{"Instance", "instance"},
+	{"Report URL", "reportUrl"},
+	{"Purl", "purl"},
+	{"Occurrence Count", "occurrenceCount"},
+	{"Reasons", "reasons"},
+}
+
+// optionalColumns are left out of the default columns because they stay empty unless a
+// feature fills them (e.g. CVE enrichment), or widen the output for few readers (License,
+// Purl, Occurrence Count, Reasons); Options.ExtraColumns adds them back.
+var optionalColumns = columnIndexes("cveSeverity", "cvssScore", "cvssVector", "cveDescription", "recommendedVersion",
+	"license", "instance", "reportUrl", "purl", "occurrenceCount", "reasons")
+
+// columnIndexes returns the positions of the named columns, which must exist.
+func columnIndexes(names ...string) Columns {
+	cols := make(Columns, len(names))
+	for i, name := range names {
+		if cols[i] = columnIndex(name); cols[i] < 0 {
+			panic("report: unknown column " + name)
+		}
+	}
+	return cols
+}
+
+// Columns selects and orders the detailed report columns by index into the default
+// ordering. Nil means the default columns: every column but the optional ones, in the
+// default order.
+type Columns []int
+
+// defaultColumns returns the default columns plus the optional columns in extra, in the
+// default order.
+func defaultColumns(extra Columns) Columns {
+	var cols Columns
+	for i := range allColumns {
+		if !slices.Contains(optionalColumns, i) || slices.Contains(extra, i) {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// Selects reports whether the column called name (see ParseColumns) is written.
+func (c Columns) Selects(name string) bool {
+	if c == nil {
+		c = defaultColumns(nil)
+	}
+	return slices.Contains(c, columnIndex(name))
+}
+
+// ParseColumns resolves column names, matched case-insensitively against either the
+// table header ("Constraint Name") or the JSON key ("constraintName"), in the given
+// order. Unknown and repeated names are errors; no names yields nil (the default columns).
+func ParseColumns(names []string) (Columns, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	cols := make(Columns, 0, len(names))
+	seen := make(map[int]bool, len(names))
+	for _, name := range names {
+		idx := columnIndex(strings.TrimSpace(name))
+		switch {
+		case idx < 0:
+			return nil, fmt.Errorf("unknown output column %q (valid: %s)", name, strings.Join(csvHeaders(), ", "))
+		case seen[idx]:
+			return nil, fmt.Errorf("output column %q listed twice", name)
+		}
+		seen[idx] = true
+		cols = append(cols, idx)
+	}
+	return cols, nil
+}
+
+// columnIndex returns the position of the column called name, or -1.
+func columnIndex(name string) int {
+	for i, c := range allColumns {
+		if strings.EqualFold(name, c.header) || strings.EqualFold(name, c.key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// headers returns the selected table headers.
+func (c Columns) headers() []string {
+	return c.project(csvHeaders())
+}
+
+// project picks the selected cells of a full record, in order.
+func (c Columns) project(cells []string) []string {
+	if c == nil {
+		c = defaultColumns(nil)
+	}
+	out := make([]string, len(c))
+	for i, idx := range c {
+		out[i] = cells[idx]
+	}
+	return out
+}
+
+// jsonRow encodes the selected fields of a row as a JSON object in column order.
+type jsonRow struct {
+	n    int
+	row  Row
+	cols Columns
+}
+
+// MarshalJSON writes the selected keys with the values Row's own encoding would use, and null
+// for a key that encoding leaves out.
+func (r jsonRow) MarshalJSON() ([]byte, error) {
+	full, err := json.Marshal(r.row)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return nil, err
+	}
+	fields["no"] = json.RawMessage(fmt.Sprint(r.n))
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, idx := range r.cols {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(allColumns[idx].key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, ok := fields[allColumns[idx].key]
+		if !ok {
+			value = json.RawMessage("null")
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
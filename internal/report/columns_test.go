@@ -0,0 +1,99 @@
+// internal/report/columns_test.go
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAllColumnsMatchRecord(t *testing.T) {
+	if got := len(csvRecord(1, Row{})); got != len(allColumns) {
+		t.Errorf("csvRecord has %d cells, allColumns %d", got, len(allColumns))
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns([]string{"cve", " Component ", "application"})
+	if err != nil {
+		t.Fatalf("ParseColumns error = %v", err)
+	}
+	if want := []string{"CVE", "Component", "Application"}; !slices.Equal(cols.headers(), want) {
+		t.Errorf("headers = %v, want %v", cols.headers(), want)
+	}
+	if cols, err := ParseColumns(nil); err != nil || cols != nil {
+		t.Errorf("ParseColumns(nil) = %v, %v; want all columns", cols, err)
+	}
+	for _, bad := range [][]string{{"Application", "Owner"}, {"CVE", "cve"}} {
+		if _, err := ParseColumns(bad); err == nil {
+			t.Errorf("ParseColumns(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWrite_ColumnSubset(t *testing.T) {
+	cols, err := ParseColumns([]string{"CVE", "Component", "Application"})
+	if err != nil {
+		t.Fatalf("ParseColumns error = %v", err)
+	}
+	rows := []Row{
+		{Application: "app-1", Component: "comp-a", CVE: "CVE-2024-1", Organization: "org"},
+		{Application: "app-2", Component: "comp-b"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, rows, Options{Format: FormatCSV, Columns: cols}, zerolog.New(io.Discard)); err != nil {
+		t.Fatalf("Write csv error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := [][]string{
+		{"CVE", "Component", "Application"},
+		{"CVE-2024-1", "comp-a", "app-1"},
+		{"", "comp-b", "app-2"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %q, want %q", records, want)
+	}
+	for i := range want {
+		if !slices.Equal(records[i], want[i]) {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+
+	buf.Reset()
+	if err := Write(&buf, rows[:1], Options{Format: FormatJSON, Columns: cols}, zerolog.New(io.Discard)); err != nil {
+		t.Fatalf("Write json error = %v", err)
+	}
+	wantJSON := "\"rows\": [\n    {\n      \"cve\": \"CVE-2024-1\",\n      \"component\": \"comp-a\",\n      \"application\": \"app-1\"\n    }\n  ]\n}\n"
+	if !strings.HasSuffix(buf.String(), wantJSON) {
+		t.Errorf("json = %s, want %s", buf.String(), wantJSON)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, rows[:1], Options{Format: FormatMarkdown, Columns: cols}, zerolog.New(io.Discard)); err != nil {
+		t.Fatalf("Write markdown error = %v", err)
+	}
+	if first := strings.SplitN(buf.String(), "\n", 2)[0]; first != "| CVE | Component | Application |" {
+		t.Errorf("markdown header = %q", first)
+	}
+}
+
+func TestJSONRow_OmittedKey(t *testing.T) {
+	// Reasons is left out of Row's encoding when nil
+	b, err := json.Marshal(jsonRow{n: 1, row: Row{Application: "app-1"}, cols: columnIndexes("application", "reasons")})
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	if got, want := string(b), `{"application":"app-1","reasons":null}`; got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+}
@@ -4,116 +4,181 @@ package report
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog"
 )
 
-// Row represents a single policy violation row for CSV output.
+// Row represents a single policy violation row for report output.
 type Row struct {
-	Application    string
-	Organization   string
-	Policy         string
-	Format         string
-	Component      string
-	Threat         int
-	PolicyAction   string
-	ConstraintName string
-	Condition      string
-	CVE            string
+	Application    string `json:"application"`
+	Organization   string `json:"organization"`
+	Policy         string `json:"policy"`
+	Format         string `json:"format"`
+	Component      string `json:"component"`
+	Threat         int    `json:"threat"`
+	PolicyAction   string `json:"policyAction"`
+	ConstraintName string `json:"constraintName"`
+	Condition      string `json:"condition"`
+	// Conditions holds the individual condition summaries Condition joins; JSON output
+	// carries them as an array so consumers need not split on the separator.
+	Conditions []string `json:"conditions"`
+	// CVE joins the referenced CVE IDs with "; "; JSON output also carries them as CVEs.
+	CVE         string   `json:"cve"`
+	CVEs        []string `json:"cves"`
+	Stage       string   `json:"stage"`
+	EvaluatedAt string   `json:"evaluatedAt"` // RFC3339
+	Group       string   `json:"group"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	// CVE details, filled when CVE enrichment is enabled
+	CVESeverity    string  `json:"cveSeverity"`
+	CVSSScore      float64 `json:"cvssScore"`
+	CVSSVector     string  `json:"cvssVector"`
+	CVEDescription string  `json:"cveDescription"`
+	// ID is a stable key for the violation across runs, see ViolationID.
+	ID string `json:"id"`
+	// Clean marks a component listed without violations (policy fields empty, threat 0).
+	Clean bool `json:"clean"`
+	// Severity is the threat level's label (Critical, High, Medium, Low, None), see SeverityLabel.
+	Severity string `json:"severity"`
+	// RecommendedVersion is IQ's nearest remediating version, filled when remediation lookup is enabled.
+	RecommendedVersion string `json:"recommendedVersion"`
+	// License lists the license IDs matched by a license-type violation, comma separated.
+	License string `json:"license"`
+	// Instance names the IQ Server the row came from in multi-instance runs.
+	Instance string `json:"instance"`
+	// ReportURL links to the application's report in the IQ UI, filled when enabled.
+	ReportURL string `json:"reportUrl"`
+	// Purl is the component's package URL, empty when it cannot be determined.
+	Purl string `json:"purl"`
+	// ThreatRaw is the threat level as IQ reported it, which may be fractional (e.g. 7.5);
+	// Threat truncates it.
+	ThreatRaw float64 `json:"threatRaw"`
+	// Occurrences counts the conditions merged into Condition (the Occurrence Count column).
+	Occurrences int `json:"occurrenceCount"`
+	// Reasons lists the findings behind the conditions, joined with "; " in the Reasons
+	// column. It is nil, and left out of JSON, unless the reasons were asked for.
+	Reasons []string `json:"reasons,omitzero"`
 }
 
 // csvHeaders returns the CSV header row in the required order.
 func csvHeaders() []string {
+	headers := make([]string, len(allColumns))
+	for i, c := range allColumns {
+		headers[i] = c.header
+	}
+	return headers
+}
+
+// csvRecord returns the cells for row r numbered n, in csvHeaders order (see allColumns).
+func csvRecord(n int, r Row) []string {
 	return []string{
-		"No.",
-		"Application",
-		"Organization",
-		"Policy",
-		"Format",
-		"Component",
-		"Threat",
-		"Policy/Action",
-		"Constraint Name",
-		"Condition",
-		"CVE",
+		strconv.Itoa(n),
+		r.Application,
+		r.Organization,
+		r.Policy,
+		r.Format,
+		r.Component,
+		strconv.Itoa(r.Threat),
+		r.PolicyAction,
+		r.ConstraintName,
+		r.Condition,
+		r.CVE,
+		r.Stage,
+		r.EvaluatedAt,
+		r.Group,
+		r.Name,
+		r.Version,
+		r.CVESeverity,
+		formatScore(r.CVSSScore),
+		r.CVSSVector,
+		r.CVEDescription,
+		r.ID,
+		strconv.FormatBool(r.Clean),
+		r.Severity,
+		r.RecommendedVersion,
+		r.License,
+		r.Instance,
+		r.ReportURL,
+		r.Purl,
+		strconv.Itoa(r.Occurrences),
+		strings.Join(r.Reasons, "; "),
 	}
 }
 
+// threatColumn is the index of the Threat cell in csvHeaders.
+const threatColumn = 6
+
+// csvCells is csvRecord with the CSV-only options applied: threatAsFloat writes the Threat
+// cell from ThreatRaw, unless the row's ThreatRaw does not match its Threat (e.g. rows
+// built without it).
+func csvCells(n int, r Row, threatAsFloat bool) []string {
+	cells := csvRecord(n, r)
+	if threatAsFloat && int(r.ThreatRaw) == r.Threat {
+		cells[threatColumn] = strconv.FormatFloat(r.ThreatRaw, 'f', -1, 64)
+	}
+	return cells
+}
+
+// formatScore renders a CVSS score with one decimal, or empty when unset.
+func formatScore(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
 // WriteCSV writes rows to a CSV file at path, ensuring the directory exists
 // and performing an atomic rename for safety.
 func WriteCSV(path string, rows []Row, logger zerolog.Logger) error {
-	dir := filepath.Dir(path)
-	logger.Debug().Str("dir", dir).Msg("preparing output directory")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		logger.Error().Err(err).Str("dir", dir).Msg("failed to create output dir")
-		return fmt.Errorf("prepare output dir: %w", err)
-	}
+	return WriteFile(path, rows, Options{Format: FormatCSV}, logger)
+}
+
+// utf8BOM lets Excel detect UTF-8 when opening a CSV file.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// encodeCSV writes the header and one record per row to w, limited to opts.Columns, using
+// opts.CSVDelimiter (comma when zero) and prefixing the UTF-8 BOM when opts.CSVWriteBOM is set.
+func encodeCSV(w io.Writer, rows []Row, opts Options, logger zerolog.Logger) error {
+	cols := opts.tableColumns()
+	return writeCSVTable(w, cols.headers(), len(rows), func(i int) []string { return cols.project(csvCells(i+1, rows[i], opts.ThreatAsFloat)) }, opts, logger)
+}
 
-	tmp, err := os.CreateTemp(dir, ".tmp-*.csv")
-	if err != nil {
-		logger.Error().Err(err).Str("dir", dir).Msg("create temp file failed")
-		return fmt.Errorf("create temp file: %w", err)
+// writeCSVTable writes headers and n records produced by record to w with the CSV options.
+func writeCSVTable(w io.Writer, headers []string, n int, record func(i int) []string, opts Options, logger zerolog.Logger) error {
+	if opts.CSVWriteBOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			logger.Error().Err(err).Msg("write BOM failed")
+			return fmt.Errorf("write BOM: %w", err)
+		}
 	}
-	tmpPath := tmp.Name()
-	logger.Debug().Str("tmp", tmpPath).Msg("created temp file")
-	defer func() {
-		_ = tmp.Close()
-		_ = os.Remove(tmpPath)
-	}()
 
-	w := csv.NewWriter(tmp)
+	cw := csv.NewWriter(w)
+	if opts.CSVDelimiter != 0 {
+		cw.Comma = opts.CSVDelimiter
+	}
 
 	// header
-	if err := w.Write(csvHeaders()); err != nil {
+	if err := cw.Write(headers); err != nil {
 		logger.Error().Err(err).Msg("write header failed")
 		return fmt.Errorf("write header: %w", err)
 	}
 
 	// rows
-	for i, r := range rows {
-		record := []string{
-			strconv.Itoa(i + 1),
-			r.Application,
-			r.Organization,
-			r.Policy,
-			r.Format,
-			r.Component,
-			strconv.Itoa(r.Threat),
-			r.PolicyAction,
-			r.ConstraintName,
-			r.Condition,
-			r.CVE,
-		}
-		if err := w.Write(record); err != nil {
+	for i := range n {
+		if err := cw.Write(record(i)); err != nil {
 			logger.Error().Err(err).Int("row", i+1).Msg("write row failed")
 			return fmt.Errorf("write row %d: %w", i+1, err)
 		}
 	}
 
-	w.Flush()
-	if err := w.Error(); err != nil {
+	cw.Flush()
+	if err := cw.Error(); err != nil {
 		logger.Error().Err(err).Msg("csv flush error")
 		return fmt.Errorf("flush csv: %w", err)
 	}
-	if err := tmp.Sync(); err != nil {
-		logger.Error().Err(err).Str("tmp", tmpPath).Msg("fsync temp file failed")
-		return fmt.Errorf("fsync temp: %w", err)
-	}
-	if err := tmp.Close(); err != nil {
-		logger.Error().Err(err).Str("tmp", tmpPath).Msg("close temp file failed")
-		return fmt.Errorf("close temp: %w", err)
-	}
-	if err := os.Rename(tmpPath, path); err != nil {
-		logger.Error().Err(err).Str("tmp", tmpPath).Str("dest", path).Msg("atomic rename failed")
-		return fmt.Errorf("atomic rename: %w", err)
-	}
-	if err := os.Chmod(path, 0o644); err != nil {
-		logger.Warn().Err(err).Str("path", path).Msg("chmod failed")
-		return fmt.Errorf("chmod: %w", err)
-	}
-	logger.Info().Str("path", path).Int("rows", len(rows)).Msg("csv file written successfully")
 	return nil
 }
@@ -0,0 +1,36 @@
+// internal/report/document.go
+package report
+
+import (
+	"net/url"
+	"time"
+)
+
+// Metadata describes the run that produced a report, so archived JSON reports are
+// self-describing.
+type Metadata struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// IQServerURL is the server's scheme and host only; see ServerOrigin.
+	IQServerURL string `json:"iqServerUrl"`
+	// OrganizationIDs lists the organizations whose applications the report covers.
+	OrganizationIDs []string `json:"organizationIds"`
+	ToolVersion     string   `json:"toolVersion"`
+}
+
+// ReportDocument is the JSON envelope of a detailed report: the run metadata followed
+// by the rows.
+type ReportDocument struct {
+	Metadata
+	RowCount int   `json:"rowCount"`
+	Rows     []Row `json:"rows"`
+}
+
+// ServerOrigin reduces a server URL to scheme://host[:port], dropping any credentials,
+// path and query; unparsable input yields "".
+func ServerOrigin(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
@@ -0,0 +1,54 @@
+// internal/report/errorsreport.go
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrorRow records an application that could not be scanned.
+type ErrorRow struct {
+	Application string `json:"application"`
+	Endpoint    string `json:"endpoint"`
+	// Status is the HTTP status code, 0 when no response was received.
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// WriteErrorsCSV encodes rows as CSV with a header line.
+func WriteErrorsCSV(w io.Writer, rows []ErrorRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Application", "Endpoint", "HTTP Status", "Error"}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for i, r := range rows {
+		status := ""
+		if r.Status != 0 {
+			status = strconv.Itoa(r.Status)
+		}
+		if err := cw.Write([]string{r.Application, r.Endpoint, status, r.Error}); err != nil {
+			return fmt.Errorf("write row %d: %w", i+1, err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return nil
+}
+
+// WriteErrorsFile atomically writes the error report CSV to path with perm's modes.
+func WriteErrorsFile(path string, rows []ErrorRow, perm Permissions, logger zerolog.Logger) error {
+	err := writeAtomic(path, string(FormatCSV), perm, logger, func(w io.Writer) error {
+		return WriteErrorsCSV(w, rows)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("path", path).Int("rows", len(rows)).Msg("error report written successfully")
+	return nil
+}
@@ -0,0 +1,94 @@
+// internal/report/html.go
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// htmlTemplate renders a standalone, sortable report page. Cell content is escaped by html/template.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>IQ Policy Violations</title>
+<style>
+body { font-family: sans-serif; margin: 1rem; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { border: 1px solid #ccc; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; cursor: pointer; user-select: none; }
+th[aria-sort="ascending"]::after { content: " \25B2"; }
+th[aria-sort="descending"]::after { content: " \25BC"; }
+tr.threat-critical td, tr.threat-high td { background: #f8d7da; }
+tr.threat-medium td { background: #fff3cd; }
+</style>
+</head>
+<body>
+<h1>IQ Policy Violations</h1>
+<p>{{len .Rows}} rows</p>
+<table id="report">
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr class="threat-{{.Severity}}">{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("report");
+  var headers = table.tHead.rows[0].cells;
+  Array.prototype.forEach.call(headers, function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = th.getAttribute("aria-sort") !== "ascending";
+      Array.prototype.forEach.call(headers, function (h) { h.removeAttribute("aria-sort"); });
+      th.setAttribute("aria-sort", asc ? "ascending" : "descending");
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[col].textContent, y = b.cells[col].textContent;
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var cmp = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+        return asc ? cmp : -cmp;
+      });
+      rows.forEach(function (r) { body.appendChild(r); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+type htmlRow struct {
+	Severity string
+	Cells    []string
+}
+
+// WriteHTML renders rows as a self-contained HTML document with a click-to-sort table.
+// Rows are color coded by threat: red for high and critical, amber for medium.
+func WriteHTML(w io.Writer, rows []Row) error {
+	return writeHTMLColumns(w, rows, nil)
+}
+
+// writeHTMLColumns is WriteHTML limited to cols.
+func writeHTMLColumns(w io.Writer, rows []Row, cols Columns) error {
+	table := make([]htmlRow, len(rows))
+	for i, r := range rows {
+		table[i] = htmlRow{Severity: Severity(r.Threat), Cells: cols.project(csvRecord(i+1, r))}
+	}
+	return writeHTMLTable(w, cols.headers(), table)
+}
+
+// writeHTMLTable renders headers and rows into the standalone report page.
+func writeHTMLTable(w io.Writer, headers []string, rows []htmlRow) error {
+	data := struct {
+		Headers []string
+		Rows    []htmlRow
+	}{Headers: headers, Rows: rows}
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("write html: %w", err)
+	}
+	return nil
+}
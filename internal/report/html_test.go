@@ -0,0 +1,37 @@
+// internal/report/html_test.go
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML_TableAndEscaping(t *testing.T) {
+	rows := []Row{
+		{Application: "app-1", Component: `<script>alert("x")</script>`, Threat: 8},
+		{Application: "app-2", Component: "lib-b", Threat: 5},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, rows); err != nil {
+		t.Fatalf("WriteHTML error = %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") {
+		t.Errorf("missing doctype")
+	}
+	if !strings.Contains(out, `<table id="report">`) || !strings.Contains(out, "<th>Application</th>") {
+		t.Errorf("table/header missing:\n%s", out)
+	}
+	if strings.Contains(out, `<script>alert`) {
+		t.Errorf("component name not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;") {
+		t.Errorf("escaped component name missing:\n%s", out)
+	}
+	if !strings.Contains(out, `<tr class="threat-high">`) || !strings.Contains(out, `<tr class="threat-medium">`) {
+		t.Errorf("threat color classes missing:\n%s", out)
+	}
+}
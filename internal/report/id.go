@@ -0,0 +1,35 @@
+// internal/report/id.go
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ViolationID returns a stable key for a violation row: the first 16 hex characters of
+// the SHA-256 over its application, component coordinates, policy, constraint and
+// condition, plus its instance in multi-instance runs (so single-instance IDs are unchanged).
+// Fields are NUL-separated so adjacent values cannot run together. When the
+// individual Conditions are known they are joined with " | ", so the ID does not depend
+// on the configured condition separator.
+func ViolationID(r Row) string {
+	condition := r.Condition
+	if r.Conditions != nil {
+		condition = strings.Join(r.Conditions, " | ")
+	}
+	h := sha256.New()
+	if r.Instance != "" {
+		h.Write([]byte(r.Instance))
+		h.Write([]byte{0})
+	}
+	for _, part := range []string{
+		r.Application,
+		r.Format, r.Group, r.Name, r.Version, r.Component,
+		r.Policy, r.ConstraintName, condition,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
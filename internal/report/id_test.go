@@ -0,0 +1,48 @@
+// internal/report/id_test.go
+package report
+
+import "testing"
+
+func TestViolationID(t *testing.T) {
+	base := Row{
+		Application:    "app-1",
+		Format:         "maven",
+		Group:          "commons-io",
+		Name:           "commons-io",
+		Version:        "2.4",
+		Policy:         "Security-High",
+		ConstraintName: "High risk CVSS score",
+		Condition:      "Security Vulnerability Severity >= 7",
+		Threat:         9,
+	}
+	same := base
+	same.Threat = 8 // not part of the identity
+
+	if ViolationID(base) != ViolationID(same) {
+		t.Errorf("identical violations got different IDs: %s vs %s", ViolationID(base), ViolationID(same))
+	}
+	if got := ViolationID(base); len(got) != 16 {
+		t.Errorf("ID length = %d, want 16", len(got))
+	}
+
+	changed := base
+	changed.Condition = "Security Vulnerability Severity >= 9"
+	if ViolationID(base) == ViolationID(changed) {
+		t.Error("changed condition produced the same ID")
+	}
+
+	// The condition separator is presentation only and must not change the ID.
+	split := base
+	split.Condition = "Security Vulnerability Severity >= 7; x"
+	split.Conditions = []string{"Security Vulnerability Severity >= 7", "x"}
+	joined := base
+	joined.Condition = "Security Vulnerability Severity >= 7 | x"
+	if ViolationID(split) != ViolationID(joined) {
+		t.Error("condition separator changed the ID")
+	}
+
+	// Pinned so the key stays stable across releases and platforms.
+	if got, want := ViolationID(base), "cfd898583951c049"; got != want {
+		t.Errorf("ViolationID = %s, want %s", got, want)
+	}
+}
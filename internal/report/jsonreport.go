@@ -0,0 +1,49 @@
+// internal/report/jsonreport.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteJSON encodes rows as an indented ReportDocument, with meta's run metadata, to w.
+func WriteJSON(w io.Writer, rows []Row, meta Metadata) error {
+	if rows == nil {
+		rows = []Row{}
+	}
+	if meta.OrganizationIDs == nil {
+		meta.OrganizationIDs = []string{}
+	}
+	return encodeDocument(w, ReportDocument{Metadata: meta, RowCount: len(rows), Rows: rows})
+}
+
+// writeJSONColumns encodes rows like WriteJSON, keeping only the cols fields of each row,
+// keyed like Row's JSON and in column order; nil cols writes full rows.
+func writeJSONColumns(w io.Writer, rows []Row, cols Columns, meta Metadata) error {
+	if cols == nil {
+		return WriteJSON(w, rows, meta)
+	}
+	if meta.OrganizationIDs == nil {
+		meta.OrganizationIDs = []string{}
+	}
+	out := make([]jsonRow, len(rows))
+	for i, r := range rows {
+		out[i] = jsonRow{n: i + 1, row: r, cols: cols}
+	}
+	return encodeDocument(w, struct {
+		Metadata
+		RowCount int       `json:"rowCount"`
+		Rows     []jsonRow `json:"rows"`
+	}{meta, len(rows), out})
+}
+
+// encodeDocument writes doc as indented JSON.
+func encodeDocument(w io.Writer, doc any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return nil
+}
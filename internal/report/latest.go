@@ -0,0 +1,65 @@
+// internal/report/latest.go
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LatestName is the stable name, e.g. latest.csv.gz, of the most recent report written with
+// extension ext (see Options.Extension).
+func LatestName(ext string) string {
+	return "latest." + ext
+}
+
+// symlink creates a symbolic link; tests replace it to exercise the copy fallback.
+var symlink = os.Symlink
+
+// WriteLatest points LatestName(ext) in path's directory at the report at path and returns
+// its path. It is a relative symlink where the platform supports one, otherwise (on Windows
+// or when the link cannot be created) a copy. Either is created under a temporary name and
+// renamed over the previous one, so readers always find a complete file.
+func WriteLatest(path, ext string, perm Permissions, logger zerolog.Logger) (string, error) {
+	dir := filepath.Dir(path)
+	latest := filepath.Join(dir, LatestName(ext))
+	if filepath.Base(path) == LatestName(ext) {
+		return latest, nil // the report itself already has the stable name
+	}
+
+	if runtime.GOOS != "windows" {
+		tmpLink := filepath.Join(dir, tempPrefix+"latest-"+strconv.FormatInt(time.Now().UnixNano(), 36)+"."+ext)
+		err := symlink(filepath.Base(path), tmpLink)
+		if err == nil {
+			if err = os.Rename(tmpLink, latest); err == nil {
+				logger.Info().Str("path", latest).Str("target", path).Msg("latest report link updated")
+				return latest, nil
+			}
+			_ = os.Remove(tmpLink)
+		}
+		logger.Debug().Err(err).Str("path", latest).Msg("cannot link latest report, copying it instead")
+	}
+
+	err := writeAtomic(latest, ext, perm, logger, func(w io.Writer) error {
+		src, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open report: %w", err)
+		}
+		defer src.Close()
+		if _, err := io.Copy(w, src); err != nil {
+			return fmt.Errorf("copy report: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	logger.Info().Str("path", latest).Str("source", path).Msg("latest report copy updated")
+	return latest, nil
+}
@@ -0,0 +1,63 @@
+// internal/report/latest_test.go
+package report
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWriteLatest(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		noLinks bool
+	}{
+		{"symlink", false},
+		{"copy fallback", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !tc.noLinks && runtime.GOOS == "windows" {
+				t.Skip("Windows always copies")
+			}
+			if tc.noLinks {
+				orig := symlink
+				symlink = func(string, string) error { return errors.New("operation not permitted") }
+				t.Cleanup(func() { symlink = orig })
+			}
+			dir := t.TempDir()
+			for _, name := range []string{"first.csv.gz", "second.csv.gz"} {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				latest, err := WriteLatest(path, "csv.gz", Permissions{}, zerolog.New(io.Discard))
+				if err != nil {
+					t.Fatalf("WriteLatest(%s): %v", name, err)
+				}
+				if latest != filepath.Join(dir, "latest.csv.gz") {
+					t.Errorf("latest path = %s", latest)
+				}
+				if b, err := os.ReadFile(latest); err != nil || string(b) != name {
+					t.Errorf("latest = %q, %v; want the contents of %s", b, err, name)
+				}
+			}
+
+			info, err := os.Lstat(filepath.Join(dir, "latest.csv.gz"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink == tc.noLinks {
+				t.Errorf("latest is a symlink = %v, want %v", isLink, !tc.noLinks)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 3 {
+				t.Errorf("dir holds %d entries, want the two reports and latest", len(entries))
+			}
+		})
+	}
+}
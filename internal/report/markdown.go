@@ -0,0 +1,77 @@
+// internal/report/markdown.go
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// conditionColumn is the index of the Condition cell in csvHeaders.
+const conditionColumn = 9
+
+// WriteMarkdown renders rows as a GitHub-flavored markdown table with the CSV columns.
+// Condition cells longer than conditionWidth runes are truncated with an ellipsis;
+// a width <= 0 disables truncation.
+func WriteMarkdown(w io.Writer, rows []Row, conditionWidth int) error {
+	return writeMarkdownColumns(w, rows, conditionWidth, nil)
+}
+
+// writeMarkdownColumns is WriteMarkdown limited to cols.
+func writeMarkdownColumns(w io.Writer, rows []Row, conditionWidth int, cols Columns) error {
+	return writeMarkdownTable(w, cols.headers(), len(rows), func(i int) []string {
+		cells := csvRecord(i+1, rows[i])
+		cells[conditionColumn] = truncate(cells[conditionColumn], conditionWidth)
+		return cols.project(cells)
+	})
+}
+
+// writeMarkdownTable writes headers and n records produced by record as a markdown table.
+func writeMarkdownTable(w io.Writer, headers []string, n int, record func(i int) []string) error {
+	bw := bufio.NewWriter(w)
+
+	writeMarkdownLine(bw, headers)
+	sep := make([]string, len(headers))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeMarkdownLine(bw, sep)
+
+	for i := range n {
+		cells := record(i)
+		for j, c := range cells {
+			cells[j] = escapeMarkdownCell(c)
+		}
+		writeMarkdownLine(bw, cells)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+	return nil
+}
+
+// writeMarkdownLine writes one pipe-delimited table line.
+func writeMarkdownLine(w *bufio.Writer, cells []string) {
+	_, _ = w.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// escapeMarkdownCell keeps a value inside its table cell.
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// truncate shortens s to at most width runes, ending in an ellipsis when cut.
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
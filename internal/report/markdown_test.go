@@ -0,0 +1,37 @@
+// internal/report/markdown_test.go
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown_SeparatorEscapingAndTruncation(t *testing.T) {
+	rows := []Row{{
+		Application: "app|1",
+		Threat:      9,
+		Condition:   "Security Vulnerability Severity >= 7",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, rows, 12); err != nil {
+		t.Fatalf("WriteMarkdown error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "| No. | Application |") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if want := "|" + strings.Repeat(" --- |", len(Columns(nil).headers())); lines[1] != want {
+		t.Errorf("separator = %q, want %q", lines[1], want)
+	}
+	if !strings.Contains(lines[2], `| app\|1 |`) {
+		t.Errorf("pipe not escaped: %q", lines[2])
+	}
+	if !strings.Contains(lines[2], "| Security Vu… |") {
+		t.Errorf("condition not truncated: %q", lines[2])
+	}
+}
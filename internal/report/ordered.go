@@ -0,0 +1,56 @@
+// internal/report/ordered.go
+package report
+
+import "container/heap"
+
+// OrderedBuffer restores submission order for results that complete out of order.
+// Each batch of rows carries the sequence number it was submitted with (0, 1, 2, ...);
+// batches are held in a min-heap until every earlier sequence has arrived, then the
+// contiguous prefix is handed to emit in order. Memory is bounded by how far results
+// run ahead of the oldest outstanding one. It is not safe for concurrent use.
+type OrderedBuffer struct {
+	emit    func(seq int, rows []Row) error
+	next    int
+	pending seqHeap
+}
+
+// NewOrderedBuffer returns a buffer passing batches to emit in sequence order.
+func NewOrderedBuffer(emit func(seq int, rows []Row) error) *OrderedBuffer {
+	return &OrderedBuffer{emit: emit}
+}
+
+// Add records the batch for seq and emits every batch that is now in order.
+// An error from emit stops the flush and is returned.
+func (b *OrderedBuffer) Add(seq int, rows []Row) error {
+	heap.Push(&b.pending, seqBatch{seq: seq, rows: rows})
+	for len(b.pending) > 0 && b.pending[0].seq == b.next {
+		batch := heap.Pop(&b.pending).(seqBatch)
+		if err := b.emit(batch.seq, batch.rows); err != nil {
+			return err
+		}
+		b.next++
+	}
+	return nil
+}
+
+// Pending returns the number of batches waiting for an earlier sequence.
+func (b *OrderedBuffer) Pending() int { return len(b.pending) }
+
+type seqBatch struct {
+	seq  int
+	rows []Row
+}
+
+// seqHeap is a container/heap min-heap of batches by sequence number.
+type seqHeap []seqBatch
+
+func (h seqHeap) Len() int           { return len(h) }
+func (h seqHeap) Less(i, j int) bool { return h[i].seq < h[j].seq }
+func (h seqHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *seqHeap) Push(x any)        { *h = append(*h, x.(seqBatch)) }
+func (h *seqHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,42 @@
+// internal/report/ordered_test.go
+package report
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestOrderedBuffer_EmitsInSequence(t *testing.T) {
+	var got []string
+	b := NewOrderedBuffer(func(seq int, rows []Row) error {
+		for _, r := range rows {
+			got = append(got, r.Application)
+		}
+		return nil
+	})
+
+	for _, seq := range []int{2, 0, 3, 1, 4} {
+		rows := []Row{{Application: string(rune('a' + seq))}}
+		if seq == 3 {
+			rows = nil // a skipped application still advances the sequence
+		}
+		if err := b.Add(seq, rows); err != nil {
+			t.Fatalf("Add(%d): %v", seq, err)
+		}
+		if seq == 2 && (len(got) != 0 || b.Pending() != 1) {
+			t.Fatalf("seq 2 emitted before seq 0: got %v, pending %d", got, b.Pending())
+		}
+	}
+	if want := []string{"a", "b", "c", "e"}; !slices.Equal(got, want) || b.Pending() != 0 {
+		t.Errorf("emitted %v (pending %d), want %v", got, b.Pending(), want)
+	}
+}
+
+func TestOrderedBuffer_EmitError(t *testing.T) {
+	boom := errors.New("boom")
+	b := NewOrderedBuffer(func(int, []Row) error { return boom })
+	if err := b.Add(0, nil); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+}
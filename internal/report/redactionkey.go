@@ -0,0 +1,48 @@
+// internal/report/redactionkey.go
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// RedactionKeyRow maps a pseudonym written in place of a name back to the name.
+type RedactionKeyRow struct {
+	// Kind is the column the name appeared in, e.g. "Application".
+	Kind      string `json:"kind"`
+	Pseudonym string `json:"pseudonym"`
+	Name      string `json:"name"`
+}
+
+// WriteRedactionKeyCSV encodes rows as CSV with a header line.
+func WriteRedactionKeyCSV(w io.Writer, rows []RedactionKeyRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Kind", "Pseudonym", "Name"}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for i, r := range rows {
+		if err := cw.Write([]string{r.Kind, r.Pseudonym, r.Name}); err != nil {
+			return fmt.Errorf("write row %d: %w", i+1, err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return nil
+}
+
+// WriteRedactionKeyFile atomically writes the redaction key CSV to path with perm's modes.
+func WriteRedactionKeyFile(path string, rows []RedactionKeyRow, perm Permissions, logger zerolog.Logger) error {
+	err := writeAtomic(path, string(FormatCSV), perm, logger, func(w io.Writer) error {
+		return WriteRedactionKeyCSV(w, rows)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("path", path).Int("rows", len(rows)).Msg("redaction key written successfully")
+	return nil
+}
@@ -0,0 +1,27 @@
+// internal/report/severity.go
+package report
+
+import "strings"
+
+// SeverityLabel returns the human-readable severity for a threat level: Critical, High,
+// Medium, Low or None, using the same ranges as Severity.
+func SeverityLabel(threat int) string {
+	s := Severity(threat)
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Severity returns the bucket name for an IQ policy threat level (0-10).
+func Severity(threat int) string {
+	switch {
+	case threat >= 9:
+		return "critical"
+	case threat >= 7:
+		return "high"
+	case threat >= 4:
+		return "medium"
+	case threat >= 1:
+		return "low"
+	default:
+		return "none"
+	}
+}
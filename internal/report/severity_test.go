@@ -0,0 +1,12 @@
+// internal/report/severity_test.go
+package report
+
+import "testing"
+
+func TestSeverityLabel(t *testing.T) {
+	for threat, want := range map[int]string{10: "Critical", 9: "Critical", 7: "High", 4: "Medium", 1: "Low", 0: "None"} {
+		if got := SeverityLabel(threat); got != want {
+			t.Errorf("SeverityLabel(%d) = %q, want %q", threat, got, want)
+		}
+	}
+}
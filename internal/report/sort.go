@@ -0,0 +1,23 @@
+// internal/report/sort.go
+package report
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SortRows orders rows by threat, highest first, then by application, component, policy,
+// constraint, condition and ID, so the same rows always come out in the same order.
+func SortRows(rows []Row) {
+	slices.SortStableFunc(rows, func(a, b Row) int {
+		return cmp.Or(
+			cmp.Compare(b.Threat, a.Threat),
+			cmp.Compare(a.Application, b.Application),
+			cmp.Compare(a.Component, b.Component),
+			cmp.Compare(a.Policy, b.Policy),
+			cmp.Compare(a.ConstraintName, b.ConstraintName),
+			cmp.Compare(a.Condition, b.Condition),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+}
@@ -0,0 +1,25 @@
+// internal/report/sort_test.go
+package report
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortRows(t *testing.T) {
+	rows := []Row{
+		{Application: "b", Threat: 5},
+		{Application: "a", Threat: 5, Component: "z"},
+		{Application: "c", Threat: 9},
+		{Application: "a", Threat: 5, Component: "y"},
+	}
+	SortRows(rows)
+
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Application+r.Component)
+	}
+	if want := []string{"c", "ay", "az", "b"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+// internal/report/stream.go
+package report
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// CSVStream writes detailed CSV rows incrementally, numbering them across calls.
+type CSVStream struct {
+	cw     *csv.Writer
+	cols   Columns
+	n      int
+	logger zerolog.Logger
+	// threatAsFloat is Options.ThreatAsFloat.
+	threatAsFloat bool
+}
+
+// NewCSVStream writes the BOM (if configured) and header to w and returns a stream for the rows.
+func NewCSVStream(w io.Writer, opts Options, logger zerolog.Logger) (*CSVStream, error) {
+	if opts.CSVWriteBOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, fmt.Errorf("write BOM: %w", err)
+		}
+	}
+	cw := csv.NewWriter(w)
+	if opts.CSVDelimiter != 0 {
+		cw.Comma = opts.CSVDelimiter
+	}
+	cols := opts.tableColumns()
+	if err := cw.Write(cols.headers()); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	return &CSVStream{cw: cw, cols: cols, logger: logger, threatAsFloat: opts.ThreatAsFloat}, nil
+}
+
+// Write appends rows and flushes them to the underlying writer.
+func (s *CSVStream) Write(rows []Row) error {
+	for _, r := range rows {
+		s.n++
+		if err := s.cw.Write(s.cols.project(csvCells(s.n, r, s.threatAsFloat))); err != nil {
+			s.logger.Error().Err(err).Int("row", s.n).Msg("write row failed")
+			return fmt.Errorf("write row %d: %w", s.n, err)
+		}
+	}
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return nil
+}
+
+// Rows returns the number of rows written so far.
+func (s *CSVStream) Rows() int { return s.n }
+
+// StreamFile writes a detailed CSV report to path while fill produces rows, calling
+// write as often as needed. Like WriteFile it writes a temp file and renames it into
+// place only if fill and every write succeed, so a failed run leaves no partial report.
+func StreamFile(path string, opts Options, logger zerolog.Logger, fill func(write func([]Row) error) error) error {
+	if opts.Format != FormatCSV || opts.Mode == ModeSummary {
+		return fmt.Errorf("streaming supports detailed csv output only, not %s/%s", opts.Format, opts.Mode)
+	}
+	var rows int
+	err := writeAtomic(path, opts.Extension(), opts.Perm, logger, func(w io.Writer) error {
+		var zw *gzip.Writer
+		if opts.Gzip {
+			zw = gzip.NewWriter(w)
+			w = zw
+		}
+		stream, err := NewCSVStream(w, opts, logger)
+		if err != nil {
+			return err
+		}
+		if err := fill(stream.Write); err != nil {
+			return err
+		}
+		rows = stream.Rows()
+		if zw != nil {
+			if err := zw.Close(); err != nil {
+				return fmt.Errorf("close gzip: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("path", path).Bool("gzip", opts.Gzip).Int("rows", rows).Msg("streamed report file written successfully")
+	return nil
+}
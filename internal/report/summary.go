@@ -0,0 +1,111 @@
+// internal/report/summary.go
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Mode selects between one row per violation and one row per application.
+type Mode string
+
+const (
+	ModeDetailed Mode = "detailed"
+	ModeSummary  Mode = "summary"
+)
+
+// SummaryRow aggregates the violation rows of one application.
+type SummaryRow struct {
+	Application  string   `json:"application"`
+	Organization string   `json:"organization"`
+	MaxThreat    int      `json:"maxThreat"`
+	Violations   int      `json:"violations"`
+	Policies     []string `json:"policies"` // distinct, sorted
+}
+
+// Summarize aggregates rows into one SummaryRow per application, ordered by highest
+// threat first and then by application. Applications without rows do not appear; Clean
+// rows list an application without counting as violations.
+func Summarize(rows []Row) []SummaryRow {
+	byApp := make(map[string]*SummaryRow)
+	var order []string
+	for _, r := range rows {
+		s, ok := byApp[r.Application]
+		if !ok {
+			s = &SummaryRow{Application: r.Application, Organization: r.Organization}
+			byApp[r.Application] = s
+			order = append(order, r.Application)
+		}
+		if r.Clean {
+			continue
+		}
+		s.Violations++
+		s.MaxThreat = max(s.MaxThreat, r.Threat)
+		if !slices.Contains(s.Policies, r.Policy) {
+			s.Policies = append(s.Policies, r.Policy)
+		}
+	}
+
+	out := make([]SummaryRow, 0, len(order))
+	for _, app := range order {
+		s := byApp[app]
+		sort.Strings(s.Policies)
+		out = append(out, *s)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].MaxThreat != out[j].MaxThreat {
+			return out[i].MaxThreat > out[j].MaxThreat
+		}
+		return out[i].Application < out[j].Application
+	})
+	return out
+}
+
+func summaryHeaders() []string {
+	return []string{"Application", "Organization", "Max Threat", "Violations", "Policies"}
+}
+
+func summaryRecord(s SummaryRow) []string {
+	return []string{
+		s.Application,
+		s.Organization,
+		strconv.Itoa(s.MaxThreat),
+		strconv.Itoa(s.Violations),
+		strings.Join(s.Policies, "; "),
+	}
+}
+
+// encodeSummary aggregates rows per application and writes them in opts.Format.
+func encodeSummary(w io.Writer, rows []Row, opts Options, logger zerolog.Logger) error {
+	summary := Summarize(rows)
+	record := func(i int) []string { return summaryRecord(summary[i]) }
+
+	switch opts.Format {
+	case FormatCSV:
+		return writeCSVTable(w, summaryHeaders(), len(summary), record, opts, logger)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+		return nil
+	case FormatMarkdown:
+		return writeMarkdownTable(w, summaryHeaders(), len(summary), record)
+	case FormatHTML:
+		table := make([]htmlRow, len(summary))
+		for i, s := range summary {
+			table[i] = htmlRow{Severity: Severity(s.MaxThreat), Cells: record(i)}
+		}
+		return writeHTMLTable(w, summaryHeaders(), table)
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
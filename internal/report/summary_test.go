@@ -0,0 +1,28 @@
+// internal/report/summary_test.go
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	rows := []Row{
+		{Application: "app-a", Organization: "org", Policy: "Security-Medium", Threat: 7},
+		{Application: "app-b", Organization: "org", Policy: "License", Threat: 3},
+		{Application: "app-a", Organization: "org", Policy: "Security-High", Threat: 9},
+		{Application: "app-a", Organization: "org", Policy: "Security-Medium", Threat: 5},
+		{Application: "app-a", Organization: "org", Clean: true},
+		{Application: "app-c", Organization: "org", Clean: true},
+	}
+
+	got := Summarize(rows)
+	want := []SummaryRow{
+		{Application: "app-a", Organization: "org", MaxThreat: 9, Violations: 3, Policies: []string{"Security-High", "Security-Medium"}},
+		{Application: "app-b", Organization: "org", MaxThreat: 3, Violations: 1, Policies: []string{"License"}},
+		{Application: "app-c", Organization: "org"}, // clean rows list the app without counting
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize =\n%+v\nwant\n%+v", got, want)
+	}
+}
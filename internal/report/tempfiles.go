@@ -0,0 +1,72 @@
+// internal/report/tempfiles.go
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// tempPrefix starts the name of every in-progress file writeAtomic creates, so
+// SweepTempFiles can tell them from reports.
+const tempPrefix = ".tmp-"
+
+// maxTempNameLen bounds the part of the target's name copied into a temp file name, keeping
+// it within file name limits once the prefix and random part are added.
+const maxTempNameLen = 100
+
+// tempPattern is the os.CreateTemp pattern for path's temp file: tempPrefix, the target's
+// name (so a leftover shows which report it belonged to) and a random part unique to the
+// writer, so concurrent writes to different paths cannot share a temp file.
+func tempPattern(path, ext string) string {
+	name := strings.TrimSuffix(filepath.Base(path), "."+ext)
+	if len(name) > maxTempNameLen {
+		name = name[:maxTempNameLen]
+		for !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return tempPrefix + name + "-*." + ext
+}
+
+// SweepTempFiles removes temp files that writes left in dir when the process was killed
+// between creating and renaming them, if last modified more than maxAge ago; younger ones
+// may belong to a write still in progress. It returns how many were removed. A missing
+// dir is not an error.
+func SweepTempFiles(dir string, maxAge time.Duration, logger zerolog.Logger) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read output dir: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	var errs []error
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !strings.HasPrefix(e.Name(), tempPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove stale temp file: %w", err))
+			continue
+		}
+		logger.Info().Str("path", path).Time("modified", info.ModTime()).Msg("Removed stale temp file from an interrupted write")
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+// internal/report/tempfiles_test.go
+package report
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSweepTempFiles_RemovesOnlyStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	files := map[string]time.Time{
+		".tmp-123.csv":    old,        // orphaned by a killed run
+		".tmp-456.csv.gz": old,        // any format
+		".tmp-789.csv":    time.Now(), // a write in progress
+		"report.csv":      old,        // a finished report
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := SweepTempFiles(dir, time.Hour, zerolog.New(io.Discard))
+	if err != nil || removed != 2 {
+		t.Fatalf("SweepTempFiles = %d, %v; want 2 removed", removed, err)
+	}
+	for name, wantKept := range map[string]bool{
+		".tmp-123.csv": false, ".tmp-456.csv.gz": false, ".tmp-789.csv": true, "report.csv": true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept = %v, want %v", name, kept, wantKept)
+		}
+	}
+
+	if n, err := SweepTempFiles(filepath.Join(dir, "missing"), time.Hour, zerolog.New(io.Discard)); n != 0 || err != nil {
+		t.Errorf("missing dir = %d, %v; want 0, nil", n, err)
+	}
+}
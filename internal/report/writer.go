@@ -0,0 +1,220 @@
+// internal/report/writer.go
+package report
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Format identifies an output encoding.
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "md"
+	FormatHTML     Format = "html"
+)
+
+// Options controls how rows are encoded and written.
+type Options struct {
+	Format Format
+	// Mode selects detailed rows (default) or one summary row per application.
+	Mode Mode
+	// Gzip compresses the encoded output.
+	Gzip bool
+	// MarkdownConditionWidth truncates markdown Condition cells to this many runes (0 = no limit).
+	MarkdownConditionWidth int
+	// CSVDelimiter separates CSV fields; zero means comma.
+	CSVDelimiter rune
+	// CSVWriteBOM prefixes CSV output with the UTF-8 byte order mark.
+	CSVWriteBOM bool
+	// ThreatAsFloat writes the CSV Threat column from Row.ThreatRaw (e.g. 7.5) instead of Row.Threat.
+	ThreatAsFloat bool
+	// Columns selects and orders the detailed columns in every format (nil = the default
+	// columns, see ParseColumns).
+	Columns Columns
+	// ExtraColumns adds optional columns, such as the CVE details or Reasons, to the table
+	// formats when Columns is nil.
+	ExtraColumns Columns
+	// Perm sets the permissions of written files and created directories.
+	Perm Permissions
+	// Metadata is the run information wrapped around detailed JSON rows.
+	Metadata Metadata
+}
+
+// Default permissions used when Permissions fields are zero.
+const (
+	DefaultFileMode os.FileMode = 0o644
+	DefaultDirMode  os.FileMode = 0o755
+)
+
+// Permissions are the modes applied to written report files and the directories created
+// for them; zero fields use DefaultFileMode and DefaultDirMode.
+type Permissions struct {
+	File os.FileMode
+	Dir  os.FileMode
+}
+
+// FileMode returns the file mode, defaulting to DefaultFileMode.
+func (p Permissions) FileMode() os.FileMode {
+	if p.File == 0 {
+		return DefaultFileMode
+	}
+	return p.File
+}
+
+// DirMode returns the directory mode, defaulting to DefaultDirMode.
+func (p Permissions) DirMode() os.FileMode {
+	if p.Dir == 0 {
+		return DefaultDirMode
+	}
+	return p.Dir
+}
+
+// Extension returns the file extension (without leading dot) for the options, e.g. "csv.gz".
+func (o Options) Extension() string {
+	if o.Gzip {
+		return string(o.Format) + ".gz"
+	}
+	return string(o.Format)
+}
+
+// Write encodes rows according to opts to w.
+func Write(w io.Writer, rows []Row, opts Options, logger zerolog.Logger) error {
+	if !opts.Gzip {
+		return encode(w, rows, opts, logger)
+	}
+	zw := gzip.NewWriter(w)
+	if err := encode(zw, rows, opts, logger); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		logger.Error().Err(err).Msg("gzip close failed")
+		return fmt.Errorf("close gzip: %w", err)
+	}
+	return nil
+}
+
+// encode writes rows in opts.Format and opts.Mode to w.
+func encode(w io.Writer, rows []Row, opts Options, logger zerolog.Logger) error {
+	if opts.Mode == ModeSummary {
+		return encodeSummary(w, rows, opts, logger)
+	}
+	switch opts.Format {
+	case FormatCSV:
+		return encodeCSV(w, rows, opts, logger)
+	case FormatJSON:
+		// Full rows carry every field (Reasons only when collected), so nil needs no widening
+		return writeJSONColumns(w, rows, opts.Columns, opts.Metadata)
+	case FormatMarkdown:
+		return writeMarkdownColumns(w, rows, opts.MarkdownConditionWidth, opts.tableColumns())
+	case FormatHTML:
+		return writeHTMLColumns(w, rows, opts.tableColumns())
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
+
+// tableColumns returns the columns of the table formats: Columns, or the default columns
+// plus ExtraColumns when it is nil.
+func (o Options) tableColumns() Columns {
+	if o.Columns == nil {
+		return defaultColumns(o.ExtraColumns)
+	}
+	return o.Columns
+}
+
+// ValidateFilename rejects names that are empty or would escape their directory.
+func ValidateFilename(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("filename is empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("%q is not a file name", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%q contains a path separator", name)
+	}
+	return nil
+}
+
+// JoinOutputPath joins dir and a bare filename, refusing filenames that contain
+// path separators or traversal elements.
+func JoinOutputPath(dir, filename string) (string, error) {
+	if err := ValidateFilename(filename); err != nil {
+		return "", fmt.Errorf("unsafe output filename: %w", err)
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// WriteFile writes rows according to opts to a file at path, ensuring the directory
+// exists and performing an atomic rename for safety. Concurrent calls for different paths
+// are safe; concurrent calls for the same path are unsupported (the last rename wins).
+func WriteFile(path string, rows []Row, opts Options, logger zerolog.Logger) error {
+	err := writeAtomic(path, opts.Extension(), opts.Perm, logger, func(w io.Writer) error {
+		return Write(w, rows, opts, logger)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("path", path).Str("format", string(opts.Format)).Bool("gzip", opts.Gzip).Int("rows", len(rows)).Msg("report file written successfully")
+	return nil
+}
+
+// chmodFile sets an open file's mode; tests replace it to simulate failures.
+var chmodFile = (*os.File).Chmod
+
+// writeAtomic creates path's directory, streams encode's output into a temp file
+// next to path and renames it into place once fully written and synced, with perm's modes.
+// The mode is set on the temp file before the rename, so any failure leaves path as it was.
+// A process killed mid-write leaves the temp file behind; see SweepTempFiles. Every call
+// gets its own temp file (see tempPattern), so writers of different paths never collide.
+func writeAtomic(path, ext string, perm Permissions, logger zerolog.Logger, encode func(io.Writer) error) error {
+	dir := filepath.Dir(path)
+	logger.Debug().Str("dir", dir).Msg("preparing output directory")
+	if err := os.MkdirAll(dir, perm.DirMode()); err != nil {
+		logger.Error().Err(err).Str("dir", dir).Msg("failed to create output dir")
+		return fmt.Errorf("prepare output dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPattern(path, ext))
+	if err != nil {
+		logger.Error().Err(err).Str("dir", dir).Msg("create temp file failed")
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	logger.Debug().Str("tmp", tmpPath).Msg("created temp file")
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	if err := encode(tmp); err != nil {
+		return err
+	}
+
+	if err := chmodFile(tmp, perm.FileMode()); err != nil {
+		logger.Error().Err(err).Str("tmp", tmpPath).Msg("chmod temp file failed")
+		return fmt.Errorf("chmod temp: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		logger.Error().Err(err).Str("tmp", tmpPath).Msg("fsync temp file failed")
+		return fmt.Errorf("fsync temp: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		logger.Error().Err(err).Str("tmp", tmpPath).Msg("close temp file failed")
+		return fmt.Errorf("close temp: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		logger.Error().Err(err).Str("tmp", tmpPath).Str("dest", path).Msg("atomic rename failed")
+		return fmt.Errorf("atomic rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// internal/report/writer_test.go
+package report
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWriteFile_GzipCSV(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "out.csv.gz")
+	rows := []Row{{Application: "app-1", Threat: 7}}
+
+	opts := Options{Format: FormatCSV, Gzip: true}
+	if got := opts.Extension(); got != "csv.gz" {
+		t.Errorf("Extension() = %q", got)
+	}
+	if err := WriteFile(dest, rows, opts, zerolog.New(io.Discard)); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	f, err := os.Open(dest)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	records, err := csv.NewReader(zr).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(records))
+	}
+	if records[0][0] != "No." || records[0][1] != "Application" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	if records[1][1] != "app-1" {
+		t.Errorf("unexpected row: %v", records[1])
+	}
+}
+
+func TestWriteFile_Permissions(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "private", "out.csv")
+	opts := Options{Format: FormatCSV, Perm: Permissions{File: 0o600, Dir: 0o750}}
+	if err := WriteFile(dest, []Row{{Application: "app-1"}}, opts, zerolog.New(io.Discard)); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Errorf("file mode = %o, want 600", got)
+	}
+	dirInfo, err := os.Stat(filepath.Dir(dest))
+	if err != nil {
+		t.Fatalf("stat dir: %v", err)
+	}
+	// MkdirAll is subject to the umask, which may only clear bits
+	if got := dirInfo.Mode().Perm(); got&^0o750 != 0 {
+		t.Errorf("dir mode = %o, want at most 750", got)
+	}
+}
+
+func TestWriteFile_ChmodFailureKeepsPreviousFile(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(dest, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := chmodFile
+	chmodFile = func(*os.File, os.FileMode) error { return errors.New("operation not permitted") }
+	t.Cleanup(func() { chmodFile = orig })
+
+	err := WriteFile(dest, []Row{{Application: "app-1"}}, Options{Format: FormatCSV}, zerolog.New(io.Discard))
+	if err == nil || !strings.Contains(err.Error(), "chmod temp") {
+		t.Fatalf("WriteFile error = %v, want chmod failure", err)
+	}
+	if b, _ := os.ReadFile(dest); string(b) != "previous" {
+		t.Errorf("destination changed to %q", b)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestWriteFile_ConcurrentOrgFiles(t *testing.T) {
+	dir := t.TempDir()
+	orgs := []string{"acme", "globex", "initech", "umbrella", "hooli", "stark", "wayne", "wonka"}
+	var wg sync.WaitGroup
+	errs := make([]error, len(orgs))
+	for i, org := range orgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rows := make([]Row, 200)
+			for j := range rows {
+				rows[j] = Row{Application: org + "-app", Organization: org, Threat: j % 10}
+			}
+			errs[i] = WriteFile(filepath.Join(dir, "report_"+org+".csv"), rows, Options{Format: FormatCSV}, zerolog.New(io.Discard))
+		}()
+	}
+	wg.Wait()
+
+	for i, org := range orgs {
+		if errs[i] != nil {
+			t.Fatalf("write %s: %v", org, errs[i])
+		}
+		f, err := os.Open(filepath.Join(dir, "report_"+org+".csv"))
+		if err != nil {
+			t.Fatalf("open %s: %v", org, err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		_ = f.Close()
+		if err != nil || len(records) != 201 {
+			t.Fatalf("%s: %d records, err %v", org, len(records), err)
+		}
+		for _, rec := range records[1:] {
+			if rec[2] != org {
+				t.Fatalf("%s report holds a row of %s", org, rec[2])
+			}
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), tempPrefix) {
+			t.Errorf("leftover temp file %s", e.Name())
+		}
+	}
+	if len(entries) != len(orgs) {
+		t.Errorf("%d files in output dir, want %d", len(entries), len(orgs))
+	}
+}
+
+func TestTempPattern(t *testing.T) {
+	if got := tempPattern("/out/2024-05-01_acme.csv.gz", "csv.gz"); got != ".tmp-2024-05-01_acme-*.csv.gz" {
+		t.Errorf("tempPattern = %q", got)
+	}
+	long := tempPattern("/out/"+strings.Repeat("é", 80)+".csv", "csv")
+	if !utf8.ValidString(long) || len(long) > len(tempPrefix)+maxTempNameLen+len("-*.csv") {
+		t.Errorf("long name pattern = %q", long)
+	}
+}
+
+func TestJoinOutputPath_RejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../../etc/passwd", "..", `..\evil.csv`, "sub/out.csv", ""} {
+		if _, err := JoinOutputPath("reports_output", name); err == nil {
+			t.Errorf("JoinOutputPath(%q) expected error", name)
+		} else if !strings.Contains(err.Error(), "unsafe output filename") {
+			t.Errorf("JoinOutputPath(%q) error = %v", name, err)
+		}
+	}
+
+	got, err := JoinOutputPath("reports_output", "report.csv")
+	if err != nil {
+		t.Fatalf("JoinOutputPath error = %v", err)
+	}
+	if want := filepath.Join("reports_output", "report.csv"); got != want {
+		t.Errorf("JoinOutputPath = %q, want %q", got, want)
+	}
+}
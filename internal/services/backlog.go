@@ -0,0 +1,66 @@
+// internal/services/backlog.go
+package services
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Bottleneck names the side of a streamed run that limited throughput.
+const (
+	BottleneckFetch  = "fetch"
+	BottleneckWriter = "writer"
+)
+
+// BacklogStats describes the hand-off between fetch workers and the report writer in a
+// streamed run, sampled each time the writer takes a result. A writer that is mostly busy
+// with results queued behind it is the bottleneck; one that mostly waits is starved by fetching.
+type BacklogStats struct {
+	// Samples is the number of results the writer took.
+	Samples int
+	// MaxQueued and AvgQueued count finished results waiting in the channel for the writer.
+	MaxQueued int
+	AvgQueued float64
+	// MaxHeld is the most applications dispatched but not yet written (at most StreamBuffer).
+	MaxHeld int
+	// WriterIdle is the time the writer waited for results; WriterBusy the time it spent writing.
+	WriterIdle time.Duration
+	WriterBusy time.Duration
+}
+
+// observe records one hand-off: queued results still in the channel, applications held
+// in the streaming window, and how long the writer waited for this result.
+func (b *BacklogStats) observe(queued, held int, waited time.Duration) {
+	b.AvgQueued = (b.AvgQueued*float64(b.Samples) + float64(queued)) / float64(b.Samples+1)
+	b.Samples++
+	b.MaxQueued = max(b.MaxQueued, queued)
+	b.MaxHeld = max(b.MaxHeld, held)
+	b.WriterIdle += waited
+}
+
+// Bottleneck reports which side limited the run: the writer when it spent longer writing
+// than waiting, fetching otherwise. It is empty before any sample.
+func (b BacklogStats) Bottleneck() string {
+	switch {
+	case b.Samples == 0:
+		return ""
+	case b.WriterBusy > b.WriterIdle:
+		return BottleneckWriter
+	default:
+		return BottleneckFetch
+	}
+}
+
+// log writes the backlog statistics at the end of a streamed run.
+func (b BacklogStats) log(logger zerolog.Logger) {
+	logger.Info().
+		Int("samples", b.Samples).
+		Int("maxQueued", b.MaxQueued).
+		Float64("avgQueued", b.AvgQueued).
+		Int("maxHeld", b.MaxHeld).
+		Dur("writerIdle", b.WriterIdle).
+		Dur("writerBusy", b.WriterBusy).
+		Str("bottleneck", b.Bottleneck()).
+		Msg("Stream backlog")
+}
@@ -0,0 +1,132 @@
+// internal/services/checkpoint.go
+package services
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// checkpointDirName is the directory under OutputDir holding resumable run state.
+const checkpointDirName = ".checkpoint"
+
+// checkpointEntry is one completed application, stored as a JSON line.
+type checkpointEntry struct {
+	AppID    string       `json:"appId"`
+	NoReport bool         `json:"noReport,omitempty"`
+	Rows     []report.Row `json:"rows"`
+	// EvaluatedAt is the report's evaluation date, kept for Options.Since and Options.StaleAfter.
+	EvaluatedAt time.Time `json:"evaluatedAt,omitzero"`
+}
+
+// checkpoint appends completed application results to a file so an interrupted run
+// with the same parameters can skip them. It is only used from the aggregation goroutine.
+type checkpoint struct {
+	path string
+	f    *os.File
+	enc  *json.Encoder
+	done map[string]AppReportResult
+}
+
+// checkpointKey identifies a run by the parameters that determine its application set and rows,
+// including the column selection, which decides whether rows carry their reasons.
+func checkpointKey(opts Options) string {
+	h := sha256.New()
+	for _, part := range []string{
+		opts.ServerURL, opts.APIBasePath, opts.OrganizationID, strings.Join(opts.OrganizationIDs, ","),
+		opts.AppIncludeRegex, opts.AppExcludeRegex, opts.AppNameQuery, opts.ReportStage, opts.ReportID,
+		opts.ReportSelection, strings.Join(opts.ReportStageOrder, ","), opts.ConditionSeparator,
+		strconv.FormatBool(opts.IncludeCleanComponents), strconv.FormatBool(opts.MarkCleanApps), strconv.FormatBool(opts.IncludeRemediation),
+		strconv.FormatBool(opts.IncludeReportURL), strconv.FormatBool(opts.IncludeReasons), strconv.FormatBool(opts.Redact), opts.RedactSalt,
+		strings.Join(opts.PolicyInclude, ","), strings.Join(opts.PolicyExclude, ","),
+		strings.Join(opts.OutputColumns, ","), strings.Join(opts.ExtraColumns, ","),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// openCheckpoint loads any results recorded under dir for key and opens the file for appending,
+// creating it with perm's modes since it holds report rows.
+func openCheckpoint(dir, key string, perm report.Permissions) (*checkpoint, error) {
+	cpDir := filepath.Join(dir, checkpointDirName)
+	if err := os.MkdirAll(cpDir, perm.DirMode()); err != nil {
+		return nil, fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	path := filepath.Join(cpDir, key+".jsonl")
+
+	done, err := readCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, perm.FileMode())
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoint: %w", err)
+	}
+	return &checkpoint{path: path, f: f, enc: json.NewEncoder(f), done: done}, nil
+}
+
+// readCheckpoint parses recorded entries. A truncated trailing line (from a crash mid-write) is ignored.
+func readCheckpoint(path string) (map[string]AppReportResult, error) {
+	done := make(map[string]AppReportResult)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e checkpointEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			break
+		}
+		done[e.AppID] = AppReportResult{AppID: e.AppID, Rows: e.Rows, NoReport: e.NoReport, EvaluationDate: e.EvaluatedAt, fromCheckpoint: true}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	return done, nil
+}
+
+// record appends a successful application result.
+func (c *checkpoint) record(res AppReportResult) error {
+	if err := c.enc.Encode(checkpointEntry{AppID: res.AppID, NoReport: res.NoReport, Rows: res.Rows, EvaluatedAt: res.EvaluationDate}); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// close releases the file, keeping it for a later resume.
+func (c *checkpoint) close() error {
+	return c.f.Close()
+}
+
+// remove closes and deletes the checkpoint once the report has been written.
+func (c *checkpoint) remove() error {
+	_ = c.f.Close()
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
+}
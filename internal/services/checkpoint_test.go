@@ -0,0 +1,136 @@
+// internal/services/checkpoint_test.go
+package services
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+func TestGenerateLatestPolicyReport_ResumesFromCheckpoint(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-0", "publicId": "apid-0", "organizationId": "org-1"},
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+			},
+		})
+	}
+	// aid-0 was completed by the interrupted run; fetching it again would fail.
+	handlers["/api/v2/reports/applications/aid-0"] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "should not be fetched", http.StatusInternalServerError)
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.Resume = true })
+
+	// Simulate the partial run: aid-0 recorded, then the process died.
+	cp, err := openCheckpoint(svc.opts.OutputDir, checkpointKey(svc.opts), svc.permissions())
+	if err != nil {
+		t.Fatalf("openCheckpoint: %v", err)
+	}
+	if err := cp.record(AppReportResult{AppID: "aid-0", Rows: []report.Row{{Application: "apid-0", Policy: "Resumed-Policy"}}}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	_ = cp.close()
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.TotalRows != 2 || res.Summary.Applications != 2 {
+		t.Errorf("summary = %+v", res.Summary)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	content := string(b)
+	for _, want := range []string{"Resumed-Policy", "apid-1,personal,Security-Medium"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("output missing %q:\n%s", want, content)
+		}
+	}
+	if _, err := os.Stat(cp.path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint should be removed after a successful run, stat err = %v", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_ResumeAppliesSince(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-0", "publicId": "apid-0", "organizationId": "org-1"},
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-0"] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "should not be fetched", http.StatusInternalServerError)
+	}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.Resume = true
+		o.Since = since
+	})
+
+	// The interrupted run kept aid-0 under an earlier cutoff and stopped before aid-1
+	cp, err := openCheckpoint(svc.opts.OutputDir, checkpointKey(svc.opts), svc.permissions())
+	if err != nil {
+		t.Fatalf("openCheckpoint: %v", err)
+	}
+	if err := cp.record(AppReportResult{AppID: "aid-0", EvaluationDate: since.AddDate(0, -1, 0), Rows: []report.Row{{Application: "apid-0", Policy: "Resumed-Policy"}}}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	_ = cp.close()
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.AppsStale != 1 || res.Summary.AppsWithViolations != 1 || res.Summary.TotalRows != 1 {
+		t.Errorf("summary = %+v, want aid-0 skipped by the current cutoff and aid-1 fetched", res.Summary)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if strings.Contains(string(b), "Resumed-Policy") || !strings.Contains(string(b), "apid-1,personal,Security-Medium") {
+		t.Errorf("unexpected report:\n%s", b)
+	}
+}
+
+func TestReadCheckpoint_IgnoresTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.jsonl")
+	content := `{"appId":"a1","rows":[{"application":"x"}]}` + "\n" + `{"appId":"a2","ro`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	done, err := readCheckpoint(path)
+	if err != nil {
+		t.Fatalf("readCheckpoint: %v", err)
+	}
+	if len(done) != 1 || len(done["a1"].Rows) != 1 {
+		t.Errorf("unexpected entries: %#v", done)
+	}
+}
+
+func TestCheckpointKey_RowOptions(t *testing.T) {
+	base := Options{ServerURL: "https://iq.example.com", ConditionSeparator: " | "}
+	for name, mutate := range map[string]func(*Options){
+		"condition separator": func(o *Options) { o.ConditionSeparator = "; " },
+		"output columns":      func(o *Options) { o.OutputColumns = []string{"Application", "Reasons"} },
+		"extra columns":       func(o *Options) { o.ExtraColumns = []string{"Reasons"} },
+	} {
+		o := base
+		mutate(&o)
+		if checkpointKey(o) == checkpointKey(base) {
+			t.Errorf("%s: checkpoint key unchanged, a resume would mix rows of both settings", name)
+		}
+	}
+}
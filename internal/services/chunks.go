@@ -0,0 +1,80 @@
+// internal/services/chunks.go
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// appChunks lists the applications organization by organization instead of in one response,
+// Options.AppFetchChunkSize organizations at a time. The run lists a chunk only once the
+// reports of the previous one are handed to the output, so the application list is never
+// held whole. It is used from one goroutine at a time.
+type appChunks struct {
+	s      *IQReportService
+	logger zerolog.Logger
+	ids    []string
+	next   int
+}
+
+// newAppChunks prepares chunked listing of Options.OrganizationIDs, or else of every
+// organization on the server, whose names are then returned as well.
+func (s *IQReportService) newAppChunks(ctx context.Context, logger zerolog.Logger) (*appChunks, []client.Organization, error) {
+	var orgs []client.Organization
+	ids := s.opts.OrganizationIDs
+	if len(ids) == 0 {
+		all, err := s.cl.GetOrganizations(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get organizations: %w", err)
+		}
+		orgs = all
+		ids = make([]string, len(all))
+		for i, org := range all {
+			ids[i] = org.ID
+		}
+	}
+	return &appChunks{s: s, logger: logger, ids: ids}, orgs, nil
+}
+
+// done reports whether every organization has been listed.
+func (c *appChunks) done() bool {
+	return c.next >= len(c.ids)
+}
+
+// list fetches the applications of the next chunk; the organizations of a chunk are listed
+// concurrently.
+func (c *appChunks) list(ctx context.Context) ([]client.Application, error) {
+	size := c.s.opts.AppFetchChunkSize
+	start := c.next
+	chunk := c.ids[start:min(start+size, len(c.ids))]
+	c.next += len(chunk)
+
+	lists := make([][]client.Application, len(chunk))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, id := range chunk {
+		g.Go(func() error {
+			var err error
+			if lists[i], err = c.s.cl.GetApplications(gctx, &id); err != nil {
+				return fmt.Errorf("get applications of organization %s: %w", id, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	var apps []client.Application
+	for _, list := range lists {
+		apps = append(apps, list...)
+	}
+	c.logger.Debug().
+		Int("chunk", start/size+1).
+		Int("organizations", len(chunk)).
+		Int("applications", len(apps)).
+		Msg("Fetched application chunk")
+	return apps, nil
+}
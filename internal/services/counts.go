@@ -0,0 +1,49 @@
+// internal/services/counts.go
+package services
+
+import (
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// OutputModeCount makes GenerateLatestPolicyReport tally violations into Result.Counts
+// without writing a report, for use as a build gate.
+const OutputModeCount = "count"
+
+// Counts tallies the violations of a count-mode run (clean rows excluded).
+type Counts struct {
+	Total int `json:"total"`
+	// ByThreat counts violations per report.Severity bucket.
+	ByThreat map[string]int `json:"byThreat"`
+	// ByThreatLevel counts violations per threat level (0-10).
+	ByThreatLevel map[int]int `json:"byThreatLevel"`
+	// ByPolicy counts violations per policy name.
+	ByPolicy map[string]int `json:"byPolicy"`
+}
+
+func newCounts() *Counts {
+	return &Counts{ByThreat: make(map[string]int), ByThreatLevel: make(map[int]int), ByPolicy: make(map[string]int)}
+}
+
+// add tallies the violation rows.
+func (c *Counts) add(rows []report.Row) {
+	for _, r := range rows {
+		if r.Clean {
+			continue
+		}
+		c.Total++
+		c.ByThreat[report.Severity(r.Threat)]++
+		c.ByThreatLevel[r.Threat]++
+		c.ByPolicy[r.Policy]++
+	}
+}
+
+// AtOrAbove returns the number of violations with a threat level of at least threat.
+func (c *Counts) AtOrAbove(threat int) int {
+	n := 0
+	for level, count := range c.ByThreatLevel {
+		if level >= threat {
+			n += count
+		}
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+// internal/services/counts_test.go
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateLatestPolicyReport_CountMode(t *testing.T) {
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) {
+		o.OutputMode = OutputModeCount
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	want := &Counts{
+		Total:         1,
+		ByThreat:      map[string]int{"high": 1},
+		ByThreatLevel: map[int]int{7: 1},
+		ByPolicy:      map[string]int{"Security-Medium": 1},
+	}
+	if !reflect.DeepEqual(res.Counts, want) {
+		t.Errorf("counts = %+v, want %+v", res.Counts, want)
+	}
+	if res.Path != "" {
+		t.Errorf("count mode wrote a report: %s", res.Path)
+	}
+	if entries, _ := os.ReadDir(svc.opts.OutputDir); len(entries) != 0 {
+		t.Errorf("output dir has %d entries, want none", len(entries))
+	}
+	if got := res.Counts.AtOrAbove(7); got != 1 {
+		t.Errorf("AtOrAbove(7) = %d, want 1", got)
+	}
+	if got := res.Counts.AtOrAbove(8); got != 0 {
+		t.Errorf("AtOrAbove(8) = %d, want 0", got)
+	}
+}
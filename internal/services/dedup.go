@@ -0,0 +1,42 @@
+// internal/services/dedup.go
+package services
+
+import (
+	"fmt"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/rs/zerolog"
+)
+
+// dedupApplications drops applications whose internal ID was already listed, so an
+// application returned twice (e.g. under two organizations) is not fetched and counted
+// twice. With Options.OnDuplicateApp "first" the first listing wins and each dropped
+// duplicate is logged; with "error" any duplicate fails the run.
+func (s *IQReportService) dedupApplications(logger zerolog.Logger, apps []client.Application) ([]client.Application, error) {
+	seen := make(map[string]client.Application, len(apps))
+	kept := make([]client.Application, 0, len(apps))
+	for _, app := range apps {
+		first, dup := seen[app.ID]
+		if !dup {
+			seen[app.ID] = app
+			kept = append(kept, app)
+			continue
+		}
+		if s.opts.OnDuplicateApp == DuplicateAppError {
+			logger.Error().
+				Str("appID", app.ID).
+				Str("appPublicID", app.PublicID).
+				Str("orgID", app.OrganizationID).
+				Str("firstOrgID", first.OrganizationID).
+				Msg("application listed more than once")
+			return nil, fmt.Errorf("%w: %s (%s)", ErrDuplicateApplication, app.PublicID, app.ID)
+		}
+		logger.Warn().
+			Str("appID", app.ID).
+			Str("appPublicID", app.PublicID).
+			Str("orgID", app.OrganizationID).
+			Str("keptOrgID", first.OrganizationID).
+			Msg("dropped duplicate application")
+	}
+	return kept, nil
+}
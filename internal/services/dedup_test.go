@@ -0,0 +1,65 @@
+// internal/services/dedup_test.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGenerateLatestPolicyReport_DuplicateApps(t *testing.T) {
+	// aid-1 is listed under both organizations, as a shared application or a server bug would
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-2"},
+			},
+		})
+	}
+	var reportLookups atomic.Int32
+	listReports := handlers["/api/v2/reports/applications/aid-1"]
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		reportLookups.Add(1)
+		listReports(w, r)
+	}
+	baseURL := startStub(t, handlers)
+
+	t.Run(DuplicateAppFirst, func(t *testing.T) {
+		var logs bytes.Buffer
+		svc := newTestService(t, baseURL, func(o *Options) { o.Logger = zerolog.New(&logs) })
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("GenerateLatestPolicyReport: %v", err)
+		}
+		if res.Summary.Applications != 1 || res.Summary.TotalRows != 1 || reportLookups.Load() != 1 {
+			t.Errorf("applications = %d, rows = %d, report lookups = %d; want the duplicate dropped",
+				res.Summary.Applications, res.Summary.TotalRows, reportLookups.Load())
+		}
+		var warned bool
+		for _, line := range strings.Split(logs.String(), "\n") {
+			if strings.Contains(line, `"level":"warn"`) && strings.Contains(line, "dropped duplicate application") {
+				warned = true
+				if !strings.Contains(line, `"orgID":"org-2"`) || !strings.Contains(line, `"keptOrgID":"org-1"`) {
+					t.Errorf("warning should name the dropped and kept listings: %s", line)
+				}
+			}
+		}
+		if !warned {
+			t.Errorf("no warning for the duplicate; logs:\n%s", logs.String())
+		}
+	})
+
+	t.Run(DuplicateAppError, func(t *testing.T) {
+		svc := newTestService(t, baseURL, func(o *Options) { o.OnDuplicateApp = DuplicateAppError })
+		if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); !errors.Is(err, ErrDuplicateApplication) {
+			t.Errorf("err = %v, want ErrDuplicateApplication", err)
+		}
+	})
+}
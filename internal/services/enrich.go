@@ -0,0 +1,81 @@
+// internal/services/enrich.go
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+	"golang.org/x/sync/errgroup"
+)
+
+// enrichCVEs looks up every distinct CVE referenced by rows once, with at most
+// MaxConcurrency lookups in flight, and copies the details onto the rows. A row
+// naming several CVEs (semicolon separated) gets the highest-scoring one.
+// Lookup failures are logged and leave the row's CVE details empty.
+func (s *IQReportService) enrichCVEs(ctx context.Context, rows []report.Row) {
+	ids := make(map[string]struct{})
+	for _, r := range rows {
+		for _, id := range splitCVEs(r.CVE) {
+			ids[id] = struct{}{}
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	s.logger.Info().Int("cves", len(sorted)).Msg("Enriching CVE details")
+
+	var mu sync.Mutex
+	details := make(map[string]*client.VulnerabilityDetail, len(sorted))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.opts.MaxConcurrency)
+	for _, id := range sorted {
+		g.Go(func() error {
+			d, err := s.cl.GetVulnerabilityDetail(gctx, id)
+			if err != nil {
+				s.logger.Warn().Err(err).Str("cve", id).Msg("CVE lookup failed, leaving details empty")
+				return nil
+			}
+			mu.Lock()
+			details[id] = d
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i := range rows {
+		var best *client.VulnerabilityDetail
+		for _, id := range splitCVEs(rows[i].CVE) {
+			if d := details[id]; d != nil && (best == nil || d.CVSSScore > best.CVSSScore) {
+				best = d
+			}
+		}
+		if best == nil {
+			continue
+		}
+		rows[i].CVESeverity = best.Severity
+		rows[i].CVSSScore = best.CVSSScore
+		rows[i].CVSSVector = best.CVSSVector
+		rows[i].CVEDescription = best.Description
+	}
+}
+
+// splitCVEs returns the CVE IDs in a row's semicolon-separated CVE cell.
+func splitCVEs(cell string) []string {
+	var ids []string
+	for _, id := range strings.Split(cell, ";") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
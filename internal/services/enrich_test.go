@@ -0,0 +1,54 @@
+// internal/services/enrich_test.go
+package services
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+func TestEnrichCVEs_LooksUpEachCVEOnce(t *testing.T) {
+	var lookups atomic.Int32
+	handlers := map[string]http.HandlerFunc{
+		"/api/v2/vulnerabilities/CVE-2024-0001": func(w http.ResponseWriter, r *http.Request) {
+			lookups.Add(1)
+			writeJSON(w, map[string]any{
+				"identifier":   "CVE-2024-0001",
+				"description":  "low one",
+				"mainSeverity": map[string]any{"score": 3.1, "vector": "CVSS:3.1/low"},
+			})
+		},
+		"/api/v2/vulnerabilities/CVE-2024-0002": func(w http.ResponseWriter, r *http.Request) {
+			lookups.Add(1)
+			writeJSON(w, map[string]any{
+				"identifier":   "CVE-2024-0002",
+				"description":  "high one",
+				"mainSeverity": map[string]any{"score": 8.8, "vector": "CVSS:3.1/high"},
+			})
+		},
+	}
+	svc := newTestService(t, startStub(t, handlers), nil)
+
+	rows := []report.Row{
+		{CVE: "CVE-2024-0001"},
+		{CVE: "CVE-2024-0001; CVE-2024-0002"},
+		{CVE: "CVE-2024-9999"}, // lookup fails (404), row left unenriched
+		{},
+	}
+	svc.enrichCVEs(rCtx(t), rows)
+
+	if got := lookups.Load(); got != 2 {
+		t.Errorf("lookups = %d, want 2 (one per distinct CVE)", got)
+	}
+	if rows[0].CVESeverity != "low" || rows[0].CVSSScore != 3.1 {
+		t.Errorf("row 0 = %+v", rows[0])
+	}
+	if rows[1].CVESeverity != "high" || rows[1].CVSSVector != "CVSS:3.1/high" || rows[1].CVEDescription != "high one" {
+		t.Errorf("row 1 should take the highest-scoring CVE, got %+v", rows[1])
+	}
+	if rows[2].CVESeverity != "" || rows[3].CVESeverity != "" {
+		t.Errorf("rows without resolvable CVEs were enriched: %+v %+v", rows[2], rows[3])
+	}
+}
@@ -0,0 +1,82 @@
+// internal/services/eta.go
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// etaWindow is how many recent completions the throughput average covers, so the estimate
+// follows changes in speed (e.g. a slow stretch of large applications) without jittering.
+const etaWindow = 20
+
+// Estimate is a snapshot of run progress.
+type Estimate struct {
+	Done    int
+	Total   int
+	Elapsed time.Duration
+	// Rate is the moving-average throughput in applications per second (0 until measurable).
+	Rate float64
+	// Remaining is the estimated time until every application is done (0 when unknown or done).
+	Remaining time.Duration
+}
+
+// progressTracker turns completion counts into throughput and time-remaining estimates and
+// decides when they are due for logging: every `every` applications or `interval`, whichever
+// comes first (0 disables either trigger). It is safe for concurrent use.
+type progressTracker struct {
+	total    int
+	every    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	start    time.Time
+	recent   []time.Time // completion times, at most etaWindow, oldest first
+	lastDone int         // done count at the last due estimate
+	lastAt   time.Time   // time of the last due estimate
+}
+
+func newProgressTracker(total, every int, interval time.Duration, now func() time.Time) *progressTracker {
+	if now == nil {
+		now = time.Now
+	}
+	start := now()
+	return &progressTracker{total: total, every: every, interval: interval, now: now, start: start, lastAt: start}
+}
+
+// grow adds n applications to the total, for runs that list them in chunks.
+func (p *progressTracker) grow(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total += n
+}
+
+// observe records that done applications have finished and returns the current estimate,
+// and whether it is due for logging.
+func (p *progressTracker) observe(done int) (Estimate, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if len(p.recent) == etaWindow {
+		p.recent = append(p.recent[:0], p.recent[1:]...)
+	}
+	p.recent = append(p.recent, now)
+
+	est := Estimate{Done: done, Total: p.total, Elapsed: now.Sub(p.start)}
+	switch span := now.Sub(p.recent[0]); {
+	case len(p.recent) >= 2 && span > 0:
+		est.Rate = float64(len(p.recent)-1) / span.Seconds()
+	case est.Elapsed > 0:
+		est.Rate = float64(done) / est.Elapsed.Seconds()
+	}
+	if est.Rate > 0 && done < p.total {
+		est.Remaining = time.Duration(float64(p.total-done) / est.Rate * float64(time.Second))
+	}
+
+	due := (p.every > 0 && done-p.lastDone >= p.every) || (p.interval > 0 && now.Sub(p.lastAt) >= p.interval)
+	if due {
+		p.lastDone, p.lastAt = done, now
+	}
+	return est, due
+}
@@ -0,0 +1,104 @@
+// internal/services/eta_test.go
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for progressTracker.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestProgressTracker_Estimate(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	p := newProgressTracker(20, 0, 0, clock.now)
+
+	var est Estimate
+	for done := 1; done <= 10; done++ {
+		clock.advance(time.Second)
+		est, _ = p.observe(done)
+	}
+	if est.Rate < 0.9 || est.Rate > 1.1 {
+		t.Errorf("rate = %.2f apps/s, want about 1", est.Rate)
+	}
+	if est.Remaining < 9*time.Second || est.Remaining > 11*time.Second {
+		t.Errorf("remaining = %s, want about 10s", est.Remaining)
+	}
+	if est.Elapsed != 10*time.Second {
+		t.Errorf("elapsed = %s, want 10s", est.Elapsed)
+	}
+
+	// A speed-up shows in the moving average once the window has turned over.
+	for done := 11; done < 20; done++ {
+		clock.advance(100 * time.Millisecond)
+		est, _ = p.observe(done)
+	}
+	if est.Rate <= 1.1 {
+		t.Errorf("rate after speed-up = %.2f apps/s, want above 1", est.Rate)
+	}
+
+	clock.advance(time.Second)
+	if est, _ = p.observe(20); est.Remaining != 0 {
+		t.Errorf("remaining when done = %s, want 0", est.Remaining)
+	}
+}
+
+func TestProgressTracker_Due(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	p := newProgressTracker(100, 5, 10*time.Second, clock.now)
+
+	var due []int
+	for done := 1; done <= 12; done++ {
+		clock.advance(100 * time.Millisecond)
+		if _, ok := p.observe(done); ok {
+			due = append(due, done)
+		}
+	}
+	if len(due) != 2 || due[0] != 5 || due[1] != 10 {
+		t.Errorf("due at %v, want [5 10]", due)
+	}
+
+	// The interval fires on its own when applications are slow.
+	clock.advance(10 * time.Second)
+	if _, ok := p.observe(13); !ok {
+		t.Error("estimate not due after the interval elapsed")
+	}
+
+	// Both triggers disabled: never due.
+	quiet := newProgressTracker(100, 0, 0, clock.now)
+	for done := 1; done <= 10; done++ {
+		clock.advance(time.Minute)
+		if _, ok := quiet.observe(done); ok {
+			t.Fatalf("estimate due at %d with logging disabled", done)
+		}
+	}
+}
+
+func TestProgressTracker_Concurrent(t *testing.T) {
+	p := newProgressTracker(1000, 10, 0, nil)
+	var wg sync.WaitGroup
+	for i := range 1000 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.observe(i + 1)
+		}()
+	}
+	wg.Wait()
+}
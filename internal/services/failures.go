@@ -0,0 +1,101 @@
+// internal/services/failures.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// ErrNoApplications is returned when no application is in scope after filtering.
+var ErrNoApplications = errors.New("no applications found")
+
+// ErrOrganizationNotFound is returned when Options.OnMissingOrg is "error" and an
+// application's organization cannot be resolved.
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// ErrDuplicateApplication is returned when Options.OnDuplicateApp is "error" and the
+// application list holds an internal ID more than once.
+var ErrDuplicateApplication = errors.New("duplicate application")
+
+// ErrReportIDScope is returned when Options.ReportID is set and the filters leave other than
+// exactly one application.
+var ErrReportIDScope = errors.New("a report ID needs exactly one application in scope")
+
+// permissionError marks err, a failure to list the applications or organizations in scope,
+// with client.ErrPermissionDenied when IQ answered 403, naming orgID when the run is scoped
+// to one organization.
+func permissionError(err error, orgID string) error {
+	if !client.IsForbidden(err) || errors.Is(err, client.ErrPermissionDenied) {
+		return err
+	}
+	if orgID != "" {
+		return fmt.Errorf("%w for organization %s: %w", client.ErrPermissionDenied, orgID, err)
+	}
+	return fmt.Errorf("%w: %w", client.ErrPermissionDenied, err)
+}
+
+// AppFailure records an application whose report could not be fetched.
+type AppFailure struct {
+	AppID    string
+	PublicID string
+	// Endpoint is the API path that failed, empty when the failure was not an API call.
+	Endpoint string
+	// StatusCode is the HTTP status returned, 0 when no response was received.
+	StatusCode int
+	Err        error
+}
+
+// newAppFailure builds an AppFailure for app, extracting endpoint and status from
+// any client.APIError in err's chain.
+func newAppFailure(appID, publicID string, err error) AppFailure {
+	f := AppFailure{AppID: appID, PublicID: publicID, Err: err}
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		f.Endpoint = apiErr.Endpoint
+		f.StatusCode = apiErr.StatusCode
+	}
+	return f
+}
+
+// PartialFailureError is returned together with a written report when some
+// applications could not be scanned.
+type PartialFailureError struct {
+	Failures []AppFailure
+	// Total is the number of applications in scope.
+	Total int
+}
+
+func (e *PartialFailureError) Error() string {
+	ids := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		ids[i] = f.PublicID
+	}
+	return fmt.Sprintf("%d of %d applications failed: %s", len(e.Failures), e.Total, strings.Join(ids, ", "))
+}
+
+// Unwrap exposes the individual application errors to errors.Is and errors.As.
+func (e *PartialFailureError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// errorRows converts failures into error report rows.
+func errorRows(failures []AppFailure) []report.ErrorRow {
+	rows := make([]report.ErrorRow, len(failures))
+	for i, f := range failures {
+		rows[i] = report.ErrorRow{
+			Application: f.PublicID,
+			Endpoint:    f.Endpoint,
+			Status:      f.StatusCode,
+			Error:       f.Err.Error(),
+		}
+	}
+	return rows
+}
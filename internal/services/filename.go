@@ -0,0 +1,70 @@
+// internal/services/filename.go
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// FilenameTokens holds the values substituted into an output filename template.
+type FilenameTokens struct {
+	Now    time.Time
+	Org    string
+	Format string
+}
+
+// ExpandFilename replaces the {date}, {time}, {org} and {format} tokens in tmpl
+// and verifies the result is a plain filename (no directories, no traversal).
+func ExpandFilename(tmpl string, tokens FilenameTokens) (string, error) {
+	org := tokens.Org
+	if org == "" {
+		org = "all"
+	}
+
+	name := strings.NewReplacer(
+		"{date}", tokens.Now.Format("2006-01-02"),
+		"{time}", tokens.Now.Format("15-04-05"),
+		"{org}", sanitizeFilenamePart(org),
+		"{format}", tokens.Format,
+	).Replace(tmpl)
+
+	if err := report.ValidateFilename(name); err != nil {
+		return "", fmt.Errorf("filename template %q: %w", tmpl, err)
+	}
+	return name, nil
+}
+
+// errorsFilename derives the error report name from the main report filename,
+// e.g. "2024-01-02_10-00-00.csv.gz" becomes "2024-01-02_10-00-00-errors.csv".
+func errorsFilename(filename string) string {
+	return companionFilename(filename, "-errors.csv")
+}
+
+// redactionKeyFilename derives the redaction key file name from the main report filename,
+// e.g. "2024-01-02_10-00-00.json" becomes "2024-01-02_10-00-00-redaction-key.csv".
+func redactionKeyFilename(filename string) string {
+	return companionFilename(filename, "-redaction-key.csv")
+}
+
+// companionFilename replaces the extensions of the main report filename with suffix.
+func companionFilename(filename, suffix string) string {
+	base := strings.TrimSuffix(filename, ".gz")
+	if i := strings.LastIndex(base, "."); i > 0 {
+		base = base[:i]
+	}
+	return base + suffix
+}
+
+// sanitizeFilenamePart replaces characters that are unsafe inside a single path element.
+func sanitizeFilenamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, s)
+}
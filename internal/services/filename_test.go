@@ -0,0 +1,55 @@
+// internal/services/filename_test.go
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandFilename_Templates(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		tmpl   string
+		tokens FilenameTokens
+		want   string
+	}{
+		{"default", "{date}_{time}.{format}", FilenameTokens{Now: now, Format: "csv"}, "2024-03-09_14-05-07.csv"},
+		{"org stable", "violations-{org}.{format}", FilenameTokens{Now: now, Org: "Team A/B", Format: "csv"}, "violations-Team A_B.csv"},
+		{"no org", "{org}-{date}.csv", FilenameTokens{Now: now}, "all-2024-03-09.csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandFilename(tt.tmpl, tt.tokens)
+			if err != nil {
+				t.Fatalf("ExpandFilename error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandFilename = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandFilename_RejectsUnsafe(t *testing.T) {
+	for _, tmpl := range []string{"../{date}.csv", `sub\{date}.csv`, "..", "   "} {
+		if _, err := ExpandFilename(tmpl, FilenameTokens{Now: time.Now()}); err == nil {
+			t.Errorf("ExpandFilename(%q) expected error", tmpl)
+		}
+	}
+}
+
+func TestErrorsFilename(t *testing.T) {
+	tests := map[string]string{
+		"2024-01-02_10-00-00.csv":    "2024-01-02_10-00-00-errors.csv",
+		"2024-01-02_10-00-00.csv.gz": "2024-01-02_10-00-00-errors.csv",
+		"report.json":                "report-errors.csv",
+		"noext":                      "noext-errors.csv",
+	}
+	for in, want := range tests {
+		if got := errorsFilename(in); got != want {
+			t.Errorf("errorsFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
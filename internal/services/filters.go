@@ -0,0 +1,68 @@
+// internal/services/filters.go
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+)
+
+// appFilter keeps applications whose name matches include (when set)
+// and does not match exclude (when set). Exclude takes precedence.
+type appFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newAppFilter compiles the include/exclude patterns; empty patterns are disabled.
+func newAppFilter(include, exclude string) (appFilter, error) {
+	var f appFilter
+	var err error
+	if include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return appFilter{}, fmt.Errorf("invalid application include regex %q: %w", include, err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return appFilter{}, fmt.Errorf("invalid application exclude regex %q: %w", exclude, err)
+		}
+	}
+	return f, nil
+}
+
+// active reports whether any pattern is configured.
+func (f appFilter) active() bool {
+	return f.include != nil || f.exclude != nil
+}
+
+// keep reports whether app passes the filter. The application name is matched,
+// falling back to the public ID when IQ did not return a name.
+func (f appFilter) keep(app client.Application) bool {
+	name := app.Name
+	if name == "" {
+		name = app.PublicID
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	if f.include != nil && !f.include.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// apply returns the applications that pass the filter, preserving order.
+func (f appFilter) apply(apps []client.Application) []client.Application {
+	if !f.active() {
+		return apps
+	}
+	kept := make([]client.Application, 0, len(apps))
+	for _, app := range apps {
+		if f.keep(app) {
+			kept = append(kept, app)
+		}
+	}
+	return kept
+}
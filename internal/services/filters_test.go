@@ -0,0 +1,53 @@
+// internal/services/filters_test.go
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+)
+
+func TestAppFilter_IncludeExclude(t *testing.T) {
+	apps := []client.Application{
+		{PublicID: "team-foo-api"},
+		{PublicID: "team-foo-sandbox"},
+		{PublicID: "team-bar-api"},
+		{PublicID: "id-1", Name: "team-foo-web"},
+	}
+
+	tests := []struct {
+		name    string
+		include string
+		exclude string
+		want    []string
+	}{
+		{"include only", "^team-foo-", "", []string{"team-foo-api", "team-foo-sandbox", "id-1"}},
+		{"exclude only", "", "-sandbox$", []string{"team-foo-api", "team-bar-api", "id-1"}},
+		{"exclude wins", "^team-foo-", "-sandbox$", []string{"team-foo-api", "id-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newAppFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("newAppFilter error = %v", err)
+			}
+			var got []string
+			for _, a := range f.apply(apps) {
+				got = append(got, a.PublicID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppFilter_InvalidRegex(t *testing.T) {
+	if _, err := newAppFilter("([", ""); err == nil {
+		t.Errorf("expected error for invalid include regex")
+	}
+	if _, err := newAppFilter("", "*x"); err == nil {
+		t.Errorf("expected error for invalid exclude regex")
+	}
+}
@@ -0,0 +1,78 @@
+// internal/services/formats.go
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// outputFormats are the values Options.OutputFormat accepts.
+var outputFormats = []string{
+	string(report.FormatCSV), string(report.FormatJSON), string(report.FormatMarkdown), string(report.FormatHTML),
+}
+
+// ParseOutputFormats splits an output format list such as "csv,json" into its formats, in
+// order and without repeats. Empty entries are ignored; unknown formats are errors.
+func ParseOutputFormats(list string) ([]string, error) {
+	var formats []string
+	for f := range strings.SplitSeq(list, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch {
+		case f == "" || slices.Contains(formats, f):
+			continue
+		case !slices.Contains(outputFormats, f):
+			return nil, fmt.Errorf("unknown output format %q (valid: %s)", f, strings.Join(outputFormats, ", "))
+		}
+		formats = append(formats, f)
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no output format in %q", list)
+	}
+	return formats, nil
+}
+
+// validateFormats checks the formats of opts.OutputFormat; several need a file each, so
+// they require file output and a {format} token in the filename template, and cannot be
+// combined with split by organization or streaming.
+func validateFormats(opts Options) ([]string, error) {
+	formats, err := ParseOutputFormats(opts.OutputFormat)
+	if err != nil || len(formats) == 1 {
+		return formats, err
+	}
+	switch {
+	case opts.OutputDest != OutputDestFile:
+		return nil, fmt.Errorf("several output formats require file output, not %q", opts.OutputDest)
+	case !strings.Contains(opts.OutputFilenameTemplate, "{format}"):
+		return nil, fmt.Errorf("several output formats require {format} in the filename template %q", opts.OutputFilenameTemplate)
+	case opts.SplitByOrg, opts.StreamOutput:
+		return nil, fmt.Errorf("several output formats cannot be combined with split by organization or streaming output")
+	}
+	return formats, nil
+}
+
+// writeFormats writes rows once per output format, each to the file the template names for
+// it with org as the {org} token, and returns the file path, or every path when there are
+// several formats (both empty for stdout).
+func (s *IQReportService) writeFormats(now time.Time, org string, rows []report.Row, writeOpts report.Options) (string, []string, error) {
+	var paths []string
+	for _, format := range s.formats {
+		filename, err := s.reportFilename(now, org, format)
+		if err != nil {
+			return "", paths, err
+		}
+		writeOpts.Format = report.Format(format)
+		path, err := s.writeRows(filename, rows, writeOpts)
+		if err != nil {
+			return "", paths, err
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 1 {
+		return paths[0], nil, nil
+	}
+	return "", paths, nil
+}
@@ -0,0 +1,100 @@
+// internal/services/gate.go
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Gate metrics.
+const (
+	// GateThreat is the highest threat level among the violations (0 when there are none).
+	GateThreat = "threat"
+	// GateCount is the number of violations.
+	GateCount = "count"
+	// GateApps is the number of applications whose report was evaluated.
+	GateApps = "apps"
+)
+
+// gateOps lists the comparison operators, two-character ones first so ParseGate finds them
+// before their one-character prefixes.
+var gateOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Gate is a single comparison such as threat>=8 evaluated after a count-mode run; the run
+// fails the gate when the comparison holds.
+type Gate struct {
+	Metric string
+	Op     string
+	Value  int
+}
+
+// ParseGate parses "<metric><op><value>" with metric threat, count or apps, op one of
+// >= <= == != > < and a non-negative integer value; spaces are ignored. An empty expression
+// returns nil (no gate).
+func ParseGate(expr string) (*Gate, error) {
+	s := strings.Join(strings.Fields(expr), "")
+	if s == "" {
+		return nil, nil
+	}
+	for _, op := range gateOps {
+		metric, value, ok := strings.Cut(s, op)
+		if !ok {
+			continue
+		}
+		metric = strings.ToLower(metric)
+		switch metric {
+		case GateThreat, GateCount, GateApps:
+		default:
+			return nil, fmt.Errorf("gate %q: unknown metric %q (want threat, count or apps)", expr, metric)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("gate %q: value %q is not a non-negative integer", expr, value)
+		}
+		return &Gate{Metric: metric, Op: op, Value: n}, nil
+	}
+	return nil, fmt.Errorf("gate %q: no comparison operator (want one of %s)", expr, strings.Join(gateOps, " "))
+}
+
+// String returns the gate in its canonical form, e.g. threat>=8.
+func (g *Gate) String() string {
+	return g.Metric + g.Op + strconv.Itoa(g.Value)
+}
+
+// Measure returns the value of the gate's metric for a run.
+func (g *Gate) Measure(counts *Counts, summary Summary) int {
+	switch g.Metric {
+	case GateThreat:
+		highest := 0
+		for level, n := range counts.ByThreatLevel {
+			if n > 0 && level > highest {
+				highest = level
+			}
+		}
+		return highest
+	case GateCount:
+		return counts.Total
+	default:
+		return summary.AppsZeroViolations + summary.AppsWithViolations
+	}
+}
+
+// Fails reports whether the run fails the gate, i.e. the comparison holds.
+func (g *Gate) Fails(counts *Counts, summary Summary) bool {
+	v := g.Measure(counts, summary)
+	switch g.Op {
+	case ">=":
+		return v >= g.Value
+	case "<=":
+		return v <= g.Value
+	case "==":
+		return v == g.Value
+	case "!=":
+		return v != g.Value
+	case ">":
+		return v > g.Value
+	default:
+		return v < g.Value
+	}
+}
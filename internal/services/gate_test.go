@@ -0,0 +1,86 @@
+// internal/services/gate_test.go
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "", want: ""},
+		{in: "threat>=8", want: "threat>=8"},
+		{in: " Count > 0 ", want: "count>0"},
+		{in: "apps==0", want: "apps==0"},
+		{in: "threat<=3", want: "threat<=3"},
+		{in: "count!=0", want: "count!=0"},
+		{in: "apps<5", want: "apps<5"},
+		{in: "severity>=8", wantErr: "unknown metric"},
+		{in: "threat>=high", wantErr: "not a non-negative integer"},
+		{in: "count>-1", wantErr: "not a non-negative integer"},
+		{in: "threat=8", wantErr: "no comparison operator"},
+		{in: "threat>=8&&count>0", wantErr: "not a non-negative integer"},
+	}
+	for _, tt := range tests {
+		g, err := ParseGate(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseGate(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseGate(%q) error = %v", tt.in, err)
+			continue
+		}
+		got := ""
+		if g != nil {
+			got = g.String()
+		}
+		if got != tt.want {
+			t.Errorf("ParseGate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGate_Fails(t *testing.T) {
+	violations := &Counts{Total: 3, ByThreatLevel: map[int]int{3: 2, 8: 1}}
+	clean := newCounts()
+	scanned := Summary{Applications: 4, AppsZeroViolations: 2, AppsWithViolations: 1, AppsNoReport: 1}
+	empty := Summary{Applications: 2, AppsNoReport: 2}
+
+	tests := []struct {
+		gate    string
+		counts  *Counts
+		summary Summary
+		want    bool
+	}{
+		{"threat>=8", violations, scanned, true},
+		{"threat>=9", violations, scanned, false},
+		{"threat>=1", clean, scanned, false},
+		{"threat>7", violations, scanned, true},
+		{"threat<8", violations, scanned, false},
+		{"count>0", violations, scanned, true},
+		{"count>0", clean, scanned, false},
+		{"count>=4", violations, scanned, false},
+		{"count!=3", violations, scanned, false},
+		{"count<=3", violations, scanned, true},
+		{"apps==0", violations, scanned, false},
+		{"apps==0", clean, empty, true},
+		{"apps<3", clean, scanned, false},
+	}
+	for _, tt := range tests {
+		g, err := ParseGate(tt.gate)
+		if err != nil {
+			t.Fatalf("ParseGate(%q): %v", tt.gate, err)
+		}
+		if got := g.Fails(tt.counts, tt.summary); got != tt.want {
+			t.Errorf("%s with %d violations and %d apps evaluated: Fails = %v, want %v",
+				tt.gate, tt.counts.Total, tt.summary.AppsZeroViolations+tt.summary.AppsWithViolations, got, tt.want)
+		}
+	}
+}
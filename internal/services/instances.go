@@ -0,0 +1,200 @@
+// internal/services/instances.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// Instance is one IQ Server of a multi-instance run, see Options.Instances.
+type Instance struct {
+	// Name tags the instance's rows in the Instance column; empty means the URL's host.
+	Name      string
+	ServerURL string
+	Username  string
+	Password  string
+}
+
+// newInstancesService builds a service that runs a collect-only service per
+// Options.Instances entry and writes their merged rows.
+func newInstancesService(opts Options, filter appFilter, columns report.Columns, formats []string, redact *redactor) (*IQReportService, error) {
+	switch {
+	case opts.StreamOutput, opts.SplitByOrg, opts.Resume:
+		return nil, fmt.Errorf("multiple IQ instances cannot be combined with streaming output, split by organization or resume")
+	case opts.TLSServerName != "":
+		return nil, fmt.Errorf("a TLS server name applies to a single IQ Server and cannot be combined with multiple IQ instances")
+	}
+	s := &IQReportService{opts: opts, logger: opts.Logger, appFilter: filter, columns: columns, formats: formats, extraColumns: opts.extraColumns(), redactor: redact}
+	var names []string
+	for _, inst := range opts.Instances {
+		name := inst.Name
+		if name == "" {
+			if u, err := url.Parse(inst.ServerURL); err == nil {
+				name = u.Hostname()
+			}
+		}
+		if name == "" || slices.Contains(names, name) {
+			return nil, fmt.Errorf("IQ instance %q needs a unique name", inst.ServerURL)
+		}
+		names = append(names, name)
+
+		// Writing, error reports, uploads, notification and metrics happen once, for the merged rows
+		instOpts := opts
+		instOpts.Instances = nil
+		instOpts.ServerURL, instOpts.Username, instOpts.Password = inst.ServerURL, inst.Username, inst.Password
+		instOpts.Logger = opts.Logger.With().Str("instance", name).Logger()
+		// Cassettes are named by request path only, so each instance records to its own directory
+		if opts.RecordDir != "" {
+			instOpts.RecordDir = filepath.Join(opts.RecordDir, sanitizeFilenamePart(name))
+		}
+		if opts.ReplayDir != "" {
+			instOpts.ReplayDir = filepath.Join(opts.ReplayDir, sanitizeFilenamePart(name))
+		}
+		instOpts.MaxRows = 0
+		instOpts.Progress = nil
+		instOpts.Uploader = nil
+		instOpts.WriteErrorReport = false
+		instOpts.NotifyWebhookURL = ""
+		instOpts.MetricsPushgatewayURL = ""
+		instOpts.RedactKeyFile = false
+		child, err := New(instOpts)
+		if err != nil {
+			return nil, fmt.Errorf("IQ instance %s: %w", name, err)
+		}
+		child.instance = name
+		child.collectOnly = true
+		child.redactor = redact
+		s.instances = append(s.instances, child)
+	}
+	return s, nil
+}
+
+// generateInstances runs every instance concurrently and writes their rows as one report,
+// tagged by instance. An instance that fails outright (unreachable, no applications) is
+// recorded as a failure like an application, and does not stop the others.
+func (s *IQReportService) generateInstances(ctx context.Context) (Result, error) {
+	startedAt := time.Now()
+	logger := s.logger
+	logger.Info().Int("instances", len(s.instances)).Msg("GenerateLatestPolicyReport invoked for multiple IQ instances")
+
+	results := make([]Result, len(s.instances))
+	errs := make([]error, len(s.instances))
+	var wg sync.WaitGroup
+	for i, inst := range s.instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = inst.generate(ctx)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil && !deadlineExceeded(ctx) {
+		return Result{}, fmt.Errorf("run aborted: %w", err)
+	}
+
+	var rows []report.Row
+	var result Result
+	summary := Summary{ByThreat: make(map[string]int)}
+	meta := report.Metadata{GeneratedAt: startedAt.UTC(), OrganizationIDs: []string{}, ToolVersion: s.opts.ToolVersion}
+	var instanceErrs []error
+	var timeoutErr *RunTimeoutError
+	var servers []string
+	succeeded := 0
+	for i, inst := range s.instances {
+		res, err := results[i], errs[i]
+		var partial *PartialFailureError
+		var timeout *RunTimeoutError
+		switch {
+		case err == nil, errors.As(err, &partial):
+		case errors.As(err, &timeout):
+			if timeoutErr == nil {
+				timeoutErr = &RunTimeoutError{Timeout: s.opts.RunTimeout, Err: timeout.Err}
+			}
+			timeoutErr.Incomplete += timeout.Incomplete
+			timeoutErr.Total += timeout.Total
+		default:
+			logger.Error().Err(err).Str("instance", inst.instance).Msg("IQ instance failed")
+			instErr := fmt.Errorf("instance %s: %w", inst.instance, err)
+			instanceErrs = append(instanceErrs, instErr)
+			result.Failures = append(result.Failures, newAppFailure("", inst.instance, instErr))
+			continue
+		}
+		succeeded++
+		rows = append(rows, res.rows...)
+		mergeSummary(&summary, res.Summary)
+		for _, f := range res.Failures {
+			f.PublicID = inst.instance + "/" + f.PublicID
+			result.Failures = append(result.Failures, f)
+		}
+		for _, f := range res.AccessDenied {
+			f.PublicID = inst.instance + "/" + f.PublicID
+			result.AccessDenied = append(result.AccessDenied, f)
+		}
+		servers = append(servers, report.ServerOrigin(inst.opts.ServerURL))
+		for _, id := range res.meta.OrganizationIDs {
+			if !slices.Contains(meta.OrganizationIDs, id) {
+				meta.OrganizationIDs = append(meta.OrganizationIDs, id)
+			}
+		}
+	}
+	slices.Sort(meta.OrganizationIDs)
+	meta.IQServerURL = strings.Join(servers, ", ")
+	total := summary.Applications + len(instanceErrs)
+
+	result.Summary = summary
+	if succeeded == 0 {
+		if timeoutErr != nil {
+			return result, timeoutErr
+		}
+		return result, errors.Join(instanceErrs...)
+	}
+	if timeoutErr != nil && !s.opts.WritePartialOnTimeout {
+		return result, timeoutErr
+	}
+
+	rows = s.capRows(logger, rows, &summary)
+	summary.TotalRows = len(rows)
+	countThreats(summary.ByThreat, rows)
+	result.Summary = summary
+	s.logSummary(summary)
+
+	filename, err := s.reportFilename(startedAt, s.opts.OrganizationID, s.formats[0])
+	if err != nil {
+		return result, err
+	}
+	if s.opts.OutputMode == OutputModeCount {
+		result.Counts = newCounts()
+		result.Counts.add(rows)
+	} else {
+		writeOpts := s.outputOptions()
+		writeOpts.Metadata = meta
+		if result.Path, result.Paths, err = s.writeFormats(startedAt, s.opts.OrganizationID, rows, writeOpts); err != nil {
+			return result, err
+		}
+	}
+	if err := s.finish(ctx, logger, filename, &result, timeoutErr != nil); err != nil {
+		return result, err
+	}
+	return result, runError(timeoutErr, result.Failures, total)
+}
+
+// mergeSummary adds the application counts of an instance's summary to dst.
+func mergeSummary(dst *Summary, src Summary) {
+	dst.Applications += src.Applications
+	dst.AppsNoReport += src.AppsNoReport
+	dst.AppsStale += src.AppsStale
+	dst.AppsZeroViolations += src.AppsZeroViolations
+	dst.AppsWithViolations += src.AppsWithViolations
+	dst.AppsAccessDenied += src.AppsAccessDenied
+	dst.AppsFailed += src.AppsFailed
+	dst.StaleReports = append(dst.StaleReports, src.StaleReports...)
+}
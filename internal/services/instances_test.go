@@ -0,0 +1,127 @@
+// internal/services/instances_test.go
+package services
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateLatestPolicyReport_MultipleInstances(t *testing.T) {
+	staging := startStub(t, stubHandlers())
+	prodHandlers := stubHandlers()
+	policy := prodHandlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	prodHandlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{{"id": "aid-9", "publicId": "apid-9", "organizationId": "org-1"}},
+		})
+	}
+	prodHandlers["/api/v2/reports/applications/aid-9"] = prodHandlers["/api/v2/reports/applications/aid-1"]
+	prodHandlers["/api/v2/applications/apid-9/reports/rpt-xyz/policy"] = policy
+	prod := startStub(t, prodHandlers)
+	broken := startStub(t, map[string]http.HandlerFunc{
+		"/api/v2/applications": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		},
+	})
+
+	svc := newTestService(t, "", func(o *Options) {
+		o.Instances = []Instance{
+			{Name: "staging", ServerURL: staging, Username: "u", Password: "p"},
+			{Name: "prod", ServerURL: prod, Username: "u", Password: "p"},
+			{Name: "broken", ServerURL: broken, Username: "u", Password: "p"},
+		}
+		o.OutputColumns = []string{"Instance", "Application", "Organization", "Policy"}
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("err = %v, want a partial failure for the broken instance", err)
+	}
+	if len(partial.Failures) != 1 || partial.Failures[0].PublicID != "broken" || partial.Total != 3 {
+		t.Errorf("failures = %+v, total %d", partial.Failures, partial.Total)
+	}
+	if !strings.Contains(err.Error(), "broken") {
+		t.Errorf("message = %q", err.Error())
+	}
+
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("merged report not written: %v", err)
+	}
+	want := "Instance,Application,Organization,Policy\n" +
+		"staging,apid-1,personal,Security-Medium\n" +
+		"prod,apid-9,personal,Security-Medium\n"
+	if string(b) != want {
+		t.Errorf("csv = %q, want %q", b, want)
+	}
+	if res.Summary.Applications != 2 || res.Summary.TotalRows != 2 || res.Summary.AppsWithViolations != 2 {
+		t.Errorf("summary = %+v", res.Summary)
+	}
+}
+func TestGenerateLatestPolicyReport_InstancesRecordAndReplay(t *testing.T) {
+	prodHandlers := stubHandlers()
+	prodHandlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{{"id": "aid-1", "publicId": "apid-9", "organizationId": "org-1"}},
+		})
+	}
+	prodHandlers["/api/v2/applications/apid-9/reports/rpt-xyz/policy"] = prodHandlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	servers := map[string]string{"staging": startStub(t, stubHandlers()), "prod": startStub(t, prodHandlers)}
+	dir := t.TempDir()
+
+	// Both servers answer the same paths; replaying must still give each its own responses
+	run := func(mutate func(*Options)) string {
+		t.Helper()
+		svc := newTestService(t, "", func(o *Options) {
+			o.Instances = []Instance{
+				{Name: "staging", ServerURL: servers["staging"], Username: "u", Password: "p"},
+				{Name: "prod", ServerURL: servers["prod"], Username: "u", Password: "p"},
+			}
+			o.OutputColumns = []string{"Instance", "Application"}
+			mutate(o)
+		})
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("GenerateLatestPolicyReport: %v", err)
+		}
+		b, err := os.ReadFile(res.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	recorded := run(func(o *Options) { o.RecordDir = dir })
+	for name := range servers {
+		if entries, err := os.ReadDir(filepath.Join(dir, name)); err != nil || len(entries) == 0 {
+			t.Errorf("no cassettes recorded for %s: %v", name, err)
+		}
+	}
+	if replayed := run(func(o *Options) { o.ReplayDir = dir }); replayed != recorded {
+		t.Errorf("replayed report = %q, want %q", replayed, recorded)
+	}
+	if want := "Instance,Application\nstaging,apid-1\nprod,apid-9\n"; recorded != want {
+		t.Errorf("recorded report = %q, want %q", recorded, want)
+	}
+
+	// The default columns gain Instance only in multi-instance runs
+	if merged := run(func(o *Options) { o.OutputColumns = nil }); !slices.Contains(strings.Split(strings.SplitN(merged, "\n", 2)[0], ","), "Instance") {
+		t.Errorf("default header of a multi-instance run lacks Instance:\n%s", merged)
+	}
+}
+
+func TestNewIQReportService_InstancesNeedUniqueNames(t *testing.T) {
+	_, err := New(Options{Logger: testLogger(), Instances: []Instance{
+		{ServerURL: "https://iq.example.com", Username: "u", Password: "p"},
+		{ServerURL: "https://iq.example.com/other", Username: "u", Password: "p"},
+	}})
+	if err == nil {
+		t.Fatal("expected an error for two instances named iq.example.com")
+	}
+}
@@ -3,196 +3,1255 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
-	"github.com/anmicius0/iqserver-report-fetch-go/internal/config"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/metrics"
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
 )
 
 // IQReportService orchestrates fetching data and exporting CSV reports.
 type IQReportService struct {
-	cfg    *config.Config
-	cl     *client.Client
-	logger zerolog.Logger
+	opts      Options
+	cl        *client.Client
+	logger    zerolog.Logger
+	appFilter appFilter
+	columns   report.Columns
+	// formats are the parsed Options.OutputFormat, written in order.
+	formats []string
+	// extraColumns are the optional columns the enabled features fill, see Options.extraColumns.
+	extraColumns report.Columns
+
+	// instances holds one collect-only service per Options.Instances entry; when set, the
+	// service merges their rows instead of querying a server itself.
+	instances []*IQReportService
+	// instance names the server of a collect-only service, tagging its rows.
+	instance string
+	// collectOnly returns the rows in Result.rows instead of writing them.
+	collectOnly bool
+	// redactor pseudonymizes names when Options.Redact is set; instances share the parent's.
+	redactor *redactor
 }
 
 // AppReportResult holds the violation rows and any error encountered
 // while processing a single application concurrently.
 type AppReportResult struct {
-	Rows []report.Row
-	Err  error
+	// AppID and PublicID identify the application the result belongs to.
+	AppID    string
+	PublicID string
+	Rows     []report.Row
+	Err      error
+	// NoReport is set when the application had no report to fetch and was skipped.
+	NoReport bool
+	// Stale is set when the latest report predates Options.Since and was skipped.
+	Stale bool
+	// EvaluationDate is when the fetched report was evaluated; zero if unknown or skipped.
+	EvaluationDate time.Time
+	// AccessDenied is set when IQ answered 403 for the application; Err then holds that
+	// error, but the application counts as skipped rather than failed.
+	AccessDenied bool
+
+	// fromCheckpoint marks results replayed from a previous interrupted run.
+	fromCheckpoint bool
+	// seq is the application's position in the run, restoring order when streaming.
+	seq int
+	// handled, when set, is signalled once the aggregation has handed the result to the output.
+	handled chan<- struct{}
 }
 
-// NewIQReportService constructs a new service.
-func NewIQReportService(cfg *config.Config, cl *client.Client, logger zerolog.Logger) *IQReportService {
-	return &IQReportService{cfg: cfg, cl: cl, logger: logger}
+// Summary describes the outcome of a report run.
+type Summary struct {
+	// Applications is the number of applications in scope after filtering.
+	Applications int
+	// AppsNoReport counts applications skipped because they have no (matching) report.
+	AppsNoReport int
+	// AppsStale counts applications skipped because their latest report predates Options.Since.
+	AppsStale int
+	// AppsZeroViolations counts applications whose report had no violations.
+	AppsZeroViolations int
+	// AppsWithViolations counts applications contributing at least one row.
+	AppsWithViolations int
+	// AppsAccessDenied counts applications skipped because IQ returned 403 for them.
+	AppsAccessDenied int
+	// AppsFailed counts applications whose report could not be fetched.
+	AppsFailed int
+	// TotalRows is the number of rows written to the report.
+	TotalRows int
+	// TruncatedRows counts rows dropped by Options.MaxRows.
+	TruncatedRows int
+	// ByThreat counts the written violation rows (clean rows excluded) per report.Severity bucket.
+	ByThreat map[string]int
+	// StaleReports lists the reported applications whose latest report is older than
+	// Options.StaleAfter, in processing order.
+	StaleReports []StaleReport
 }
 
-// GenerateLatestPolicyReport fetches latest policy violations for applications (optionally filtered by orgID)
-// and writes a CSV to cfg.OutputDir/filename. It returns the absolute file path.
-func (s *IQReportService) GenerateLatestPolicyReport(ctx context.Context, orgID *string, filename string) (string, error) {
-	logger := s.logger.With().Str("filename", filename).Logger()
-	if orgID != nil {
+// StaleReport is an application whose latest report is older than Options.StaleAfter.
+type StaleReport struct {
+	AppPublicID    string
+	EvaluationDate time.Time
+}
+
+// Result is the outcome of GenerateLatestPolicyReport.
+type Result struct {
+	// Path is the written report file; empty when the report went to stdout, was split or was
+	// written in several formats.
+	Path string
+	// Paths lists the per-organization files written when Options.SplitByOrg is set, or one
+	// file per format, in Options.OutputFormat order, when it lists several.
+	Paths []string
+	// ErrorsPath is the written error report, set when Options.WriteErrorReport is enabled.
+	ErrorsPath string
+	Summary    Summary
+	// Failures lists the applications that could not be scanned.
+	Failures []AppFailure
+	// AccessDenied lists the applications skipped because IQ returned 403 for them.
+	AccessDenied []AppFailure
+	// RedactionKeyPath is the written redaction key, set when Options.RedactKeyFile is enabled.
+	RedactionKeyPath string
+	// Uploaded lists the remote locations of files copied by Options.Uploader.
+	Uploaded []string
+	// LatestPaths are the updated latest.<ext> links or copies, one per format, set when
+	// Options.WriteLatest is enabled.
+	LatestPaths []string
+	// Backlog shows whether fetching or writing limited a streamed run; zero otherwise.
+	Backlog BacklogStats
+	// Counts tallies the violations of an OutputModeCount run, which writes no report.
+	Counts *Counts
+
+	// rows and meta are the collected rows and run metadata of a collect-only instance run.
+	rows []report.Row
+	meta report.Metadata
+}
+
+// NewIQReportService constructs a new service around an existing client,
+// compiling any configured application filters. With opts.Instances, cl is unused
+// and a client is built per instance.
+func NewIQReportService(opts Options, cl *client.Client) (*IQReportService, error) {
+	opts = opts.withDefaults()
+	filter, err := newAppFilter(opts.AppIncludeRegex, opts.AppExcludeRegex)
+	if err != nil {
+		return nil, err
+	}
+	if opts.AppFetchChunkSize > 0 {
+		switch {
+		case opts.AppNameQuery != "":
+			return nil, fmt.Errorf("chunked application listing cannot be combined with an application name query")
+		case opts.OrganizationID != "":
+			return nil, fmt.Errorf("chunked application listing cannot be combined with organization ID %q, whose applications are listed in one request", opts.OrganizationID)
+		case opts.ReportID != "":
+			return nil, fmt.Errorf("chunked application listing cannot be combined with a report ID")
+		}
+	}
+	if opts.OrgNamesOptional && opts.OnMissingOrg == MissingOrgError {
+		return nil, fmt.Errorf("optional organization names cannot be combined with on-missing-org %q", MissingOrgError)
+	}
+	if opts.OrganizationID != "" && len(opts.OrganizationIDs) > 0 {
+		return nil, fmt.Errorf("organization ID %q cannot be combined with a list of organization IDs", opts.OrganizationID)
+	}
+	if opts.SplitByOrg {
+		if opts.OutputDest != OutputDestFile {
+			return nil, fmt.Errorf("split by organization requires file output, not %q", opts.OutputDest)
+		}
+		if !strings.Contains(opts.OutputFilenameTemplate, "{org}") {
+			return nil, fmt.Errorf("split by organization requires {org} in the filename template %q", opts.OutputFilenameTemplate)
+		}
+	}
+	formats, err := validateFormats(opts)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := report.ParseColumns(opts.OutputColumns)
+	if err != nil {
+		return nil, err
+	}
+	if columns != nil && (opts.OutputMode == string(report.ModeSummary) || opts.OutputMode == OutputModeCount) {
+		return nil, fmt.Errorf("output columns apply to detailed output only, not %q mode", opts.OutputMode)
+	}
+	if _, err := report.ParseColumns(opts.ExtraColumns); err != nil {
+		return nil, err
+	}
+	if len(opts.ExtraColumns) > 0 && columns != nil {
+		return nil, fmt.Errorf("extra columns cannot be combined with output columns, which list every column to write")
+	}
+	if opts.ReportID != "" && (len(opts.Instances) > 0 || opts.ReportStage != "") {
+		return nil, fmt.Errorf("a report ID cannot be combined with multiple servers or a report stage")
+	}
+	if opts.Uploader != nil && opts.OutputDest != OutputDestFile {
+		return nil, fmt.Errorf("uploading requires file output, not %q", opts.OutputDest)
+	}
+	if opts.Redact && opts.IncludeReportURL {
+		return nil, fmt.Errorf("report URLs identify the application and cannot be combined with redaction")
+	}
+	if opts.RedactKeyFile && (!opts.Redact || opts.OutputDest != OutputDestFile) {
+		return nil, fmt.Errorf("a redaction key file requires redaction and file output")
+	}
+	if opts.OutputMode == OutputModeCount && (opts.StreamOutput || opts.SplitByOrg || opts.Uploader != nil) {
+		return nil, fmt.Errorf("count mode writes no report and cannot be combined with streaming output, split by organization or upload")
+	}
+	var redact *redactor
+	if opts.Redact {
+		redact = newRedactor(opts.RedactSalt)
+	}
+	if len(opts.Instances) > 0 {
+		return newInstancesService(opts, filter, columns, formats, redact)
+	}
+	if opts.StreamOutput {
+		switch {
+		case opts.OutputDest != OutputDestFile:
+			return nil, fmt.Errorf("streaming output requires file output, not %q", opts.OutputDest)
+		case formats[0] != "csv" || opts.OutputMode == "summary":
+			return nil, fmt.Errorf("streaming output requires detailed csv, not %s/%s", opts.OutputFormat, opts.OutputMode)
+		case opts.SplitByOrg, opts.MaxRows > 0, opts.EnrichCVE:
+			return nil, fmt.Errorf("streaming output cannot be combined with split by organization, max rows or CVE enrichment")
+		}
+	}
+	return &IQReportService{opts: opts, cl: cl, logger: opts.Logger, appFilter: filter, columns: columns, formats: formats, extraColumns: opts.extraColumns(), redactor: redact}, nil
+}
+
+// GenerateLatestPolicyReport fetches latest policy violations for applications (optionally filtered by
+// opts.OrganizationID) and writes a report in opts.OutputFormat to opts.OutputDir, named by expanding
+// opts.OutputFilenameTemplate. The returned Result carries the file path (empty when opts.OutputDest
+// is stdout) and a run summary.
+//
+// A failing application does not abort the run: the report is written from the remaining
+// applications and a *PartialFailureError is returned alongside the Result. Applications the
+// account may not read (HTTP 403) are skipped and listed in Result.AccessDenied instead.
+// Cancellation of ctx still aborts without writing. When the deadline (Options.RunTimeout or
+// ctx's own) fires, a *RunTimeoutError is returned; with Options.WritePartialOnTimeout the
+// applications finished by then are still written (not when streaming).
+//
+// When Options.NotifyWebhookURL is set, the outcome is posted to it afterwards, successful or not.
+func (s *IQReportService) GenerateLatestPolicyReport(ctx context.Context) (Result, error) {
+	runCtx := ctx
+	if s.opts.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.opts.RunTimeout)
+		defer cancel()
+	}
+	s.sweepTempFiles()
+	result, err := s.generate(runCtx)
+	if err != nil && deadlineExceeded(runCtx) && !errors.Is(err, ErrRunTimeout) {
+		// The deadline fired outside the application fan-out, e.g. while listing applications
+		err = &RunTimeoutError{Timeout: s.opts.RunTimeout, Err: err}
+	}
+	s.notify(ctx, result, err)
+	return result, err
+}
+
+// generate runs the report; see GenerateLatestPolicyReport.
+func (s *IQReportService) generate(ctx context.Context) (Result, error) {
+	if s.instances != nil {
+		return s.generateInstances(ctx)
+	}
+	startedAt := time.Now()
+	logger := s.logger
+	var orgID *string
+	if s.opts.OrganizationID != "" {
+		orgID = &s.opts.OrganizationID
 		logger = logger.With().Str("orgID", *orgID).Logger()
 	}
 
 	logger.Info().Msg("GenerateLatestPolicyReport invoked")
 
+	if err := s.validatePolicyFilters(ctx, logger); err != nil {
+		return Result{}, err
+	}
+
 	// =================================================================
-	// 1. APPLICATION AND ORGANIZATION FETCHING (Sequential Setup)
+	// 1. APPLICATION AND ORGANIZATION FETCHING (Concurrent Setup)
 	// =================================================================
 
-	// Fetch application list
-	apps, err := s.cl.GetApplications(ctx, orgID)
+	// Fetch the application list; when scoped to one organization its name is resolved
+	// in parallel, since the ID is known up front. Either failing aborts both. Chunked
+	// listing only finds the organizations here; their applications are listed below.
+	var apps []client.Application
+	var orgs []client.Organization
+	var chunks *appChunks
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		if s.opts.AppNameQuery != "" {
+			if apps, err = s.searchApplications(gctx, orgID); err != nil {
+				logger.Error().Err(err).Str("query", s.opts.AppNameQuery).Msg("failed to search applications")
+				return fmt.Errorf("search applications: %w", err)
+			}
+			return nil
+		}
+		if s.opts.AppFetchChunkSize > 0 {
+			if chunks, orgs, err = s.newAppChunks(gctx, logger); err != nil {
+				logger.Error().Err(err).Msg("failed to retrieve application list")
+				return err
+			}
+			return nil
+		}
+		if apps, err = s.cl.GetApplications(gctx, orgID); err != nil {
+			logger.Error().Err(err).Msg("failed to retrieve application list")
+			return fmt.Errorf("get applications: %w", err)
+		}
+		return nil
+	})
+	if orgID != nil {
+		g.Go(func() error {
+			var err error
+			if orgs, err = s.cl.GetOrganizationsByIDs(gctx, []string{*orgID}); err != nil {
+				if s.optionalOrgNames(gctx, logger, err) {
+					return nil
+				}
+				logger.Error().Err(err).Msg("failed to retrieve organization")
+				return fmt.Errorf("get organizations: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return Result{}, permissionError(err, s.opts.OrganizationID)
+	}
+
+	// nextChunk lists chunks until one keeps applications after filtering, and returns
+	// none once every organization has been listed.
+	nextChunk := func(ctx context.Context) ([]client.Application, error) {
+		for !chunks.done() {
+			listed, err := chunks.list(ctx)
+			if err != nil {
+				logger.Error().Err(err).Msg("failed to retrieve application list")
+				return nil, permissionError(err, "")
+			}
+			if listed, err = s.scopeApplications(logger, listed); err != nil || len(listed) > 0 {
+				return listed, err
+			}
+		}
+		return nil, nil
+	}
+
+	var err error
+	if chunks != nil {
+		apps, err = nextChunk(ctx)
+	} else {
+		logger.Info().Int("count", len(apps)).Msg("Fetched applications")
+		apps, err = s.scopeApplications(logger, apps)
+	}
 	if err != nil {
-		logger.Error().Err(err).Msg("failed to retrieve application list")
-		return "", fmt.Errorf("get applications: %w", err)
+		return Result{}, err
 	}
-	logger.Info().Int("count", len(apps)).Msg("Fetched applications")
 
 	if len(apps) == 0 {
 		logger.Warn().Msg("Task finished: no applications found matching criteria")
-		return "", fmt.Errorf("no applications found")
+		return Result{}, ErrNoApplications
 	}
-
-	// Fetch organizations to create an ID-to-name map
-	orgs, err := s.cl.GetOrganizations(ctx)
-	if err != nil {
-		logger.Error().Err(err).Msg("failed to retrieve organization list")
-		return "", fmt.Errorf("get organizations: %w", err)
+	if s.opts.ReportID != "" && len(apps) != 1 {
+		logger.Error().Str("reportID", s.opts.ReportID).Int("apps", len(apps)).Msg("report ID given but the filters leave several applications")
+		return Result{}, fmt.Errorf("%w: report %s, %d applications", ErrReportIDScope, s.opts.ReportID, len(apps))
 	}
+
+	// Create an organization ID-to-name map, resolving only organizations of in-scope apps
 	orgIDToName := make(map[string]string)
 	for _, org := range orgs {
 		orgIDToName[org.ID] = org.Name
 	}
+	if err := s.resolveOrgNames(ctx, logger, apps, orgIDToName); err != nil {
+		return Result{}, err
+	}
 	logger.Info().Int("count", len(orgIDToName)).Msg("Created organization ID-to-name map")
 
+	// Resolve the output filename now that organization names are known
+	var orgToken string
+	if orgID != nil && *orgID != "" {
+		orgToken = *orgID
+		if name, ok := orgIDToName[*orgID]; ok {
+			orgToken = name
+		}
+		if s.redactor != nil {
+			orgToken = s.redactor.pseudonym(redactOrganization, orgToken)
+		}
+	}
+	filename, err := s.reportFilename(startedAt, orgToken, s.formats[0])
+	if err != nil {
+		logger.Error().Err(err).Msg("invalid output filename")
+		return Result{}, err
+	}
+	logger = logger.With().Str("filename", filename).Logger()
+	logger.Info().Msg("Report filename set")
+
 	// =================================================================
 	// 2. PROCESS APPLICATIONS CONCURRENTLY
 	// =================================================================
 
-	// Setup concurrency primitives: semaphore (max 10), channel for results, WaitGroup
-	sem := make(chan struct{}, 10) // Bounded semaphore: max 10 concurrent
-	resultsChan := make(chan AppReportResult, len(apps))
+	// Resume from a checkpoint of an interrupted run with the same parameters
+	var cp *checkpoint
+	if s.opts.Resume {
+		cp, err = openCheckpoint(s.opts.OutputDir, checkpointKey(s.opts), s.permissions())
+		if err != nil {
+			return Result{}, err
+		}
+		defer cp.close()
+
+		resumed := 0
+		for _, app := range apps {
+			if _, ok := cp.done[app.ID]; ok {
+				resumed++
+			}
+		}
+		ev := logger.Info().Str("checkpoint", cp.path).Int("resumed", resumed).Int("remaining", len(apps)-resumed)
+		if chunks != nil {
+			ev = ev.Int("recorded", len(cp.done)) // resumed and remaining cover the first chunk only
+		}
+		ev.Msg("Resuming from checkpoint")
+	}
+
+	// Setup concurrency primitives: semaphore (opts.MaxConcurrency), channel for results, WaitGroup.
+	// The results buffer is bounded by the worker count, not the app count: the aggregation loop
+	// below drains it while workers produce, so at most MaxConcurrency finished results wait in
+	// memory. Workers release their semaphore slot only after handing off their result, which
+	// keeps fetches from racing ahead of aggregation; the closing goroutine waits on wg alone.
+	//
+	// Every send and semaphore wait also selects on the run's context, so after cancellation
+	// goroutines unwind without waiting for a slot or a reader; the aggregation still drains
+	// resultsChan until it closes, so none outlive GenerateLatestPolicyReport.
+	//
+	// A dispatcher walks apps in order, tagging each result with its index. When streaming,
+	// it also takes a window slot per application, returned once that application's rows are
+	// written, so results held back for ordering never exceed StreamBuffer applications.
+	// With chunked listing it lists the next chunk only after the aggregation handed every
+	// result of the current one to the output, so listing and fetching alternate.
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+	sem := make(chan struct{}, s.opts.MaxConcurrency) // Bounded semaphore
+	resultsChan := make(chan AppReportResult, s.opts.MaxConcurrency)
+	var window chan struct{}
+	if s.opts.StreamOutput {
+		window = make(chan struct{}, s.opts.StreamBuffer)
+	}
 	var wg sync.WaitGroup
+	var listErr error
+	total, scoped := len(apps), apps // grow with each listed chunk, scoped only when splitting by organization
+	tracker := newProgressTracker(total, s.opts.ProgressLogEvery, s.opts.ProgressLogInterval, nil)
 
-	s.logger.Info().Int("appsToProcess", len(apps)).Int("maxConcurrent", 10).Msg("Starting concurrent report fetching for applications")
+	s.logger.Info().Int("appsToProcess", len(apps)).Int("maxConcurrent", s.opts.MaxConcurrency).Bool("stream", s.opts.StreamOutput).Msg("Starting concurrent report fetching for applications")
 
-	// Launch a goroutine for each application
-	for _, a := range apps {
-		wg.Add(1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dispatched := 0
+		for chunk := apps; len(chunk) > 0; {
+			var handled chan struct{}
+			if chunks != nil {
+				handled = make(chan struct{}, len(chunk))
+			}
+			for _, app := range chunk {
+				seq := dispatched
+				dispatched++
+				if window != nil {
+					select {
+					case window <- struct{}{}:
+					case <-runCtx.Done():
+						return
+					}
+				}
 
-		// Capture loop variable 'a' for use in the goroutine closure
-		app := a
+				// Replay checkpointed results through the same aggregation path
+				if cp != nil {
+					if res, ok := cp.done[app.ID]; ok {
+						if s.predatesSince(res.EvaluationDate) {
+							res = AppReportResult{AppID: res.AppID, Stale: true, fromCheckpoint: true}
+						}
+						res.PublicID = app.PublicID
+						res.seq, res.handled = seq, handled
+						select {
+						case resultsChan <- res:
+						case <-runCtx.Done():
+							return
+						}
+						continue
+					}
+				}
 
-		go func() {
-			sem <- struct{}{} // Acquire semaphore
-			defer func() {
-				<-sem // Release semaphore
-				wg.Done()
-			}()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					// Acquire semaphore, unless the run is cancelled while waiting for a slot
+					select {
+					case sem <- struct{}{}:
+					case <-runCtx.Done():
+						return
+					}
+					defer func() { <-sem }() // Release semaphore
 
-			// Check for context cancellation/timeout early
-			if ctx.Err() != nil {
-				resultsChan <- AppReportResult{Err: ctx.Err()}
+					// Each application gets its own deadline, still cancelled with the root context
+					appCtx, cancel := runCtx, func() {}
+					if s.opts.AppTimeout > 0 {
+						appCtx, cancel = context.WithTimeout(runCtx, s.opts.AppTimeout)
+					}
+					res := s.processAppIsolated(appCtx, app, orgIDToName)
+					cancel()
+					res.AppID = app.ID
+					res.PublicID = app.PublicID
+					res.seq, res.handled = seq, handled
+					// A cancelled run drops the result; the aggregation reports the cancellation itself
+					select {
+					case resultsChan <- res:
+					case <-runCtx.Done():
+					}
+				}()
+			}
+			if chunks == nil {
 				return
 			}
 
-			appLogger := s.logger.With().Str("appPublicID", app.PublicID).Str("appInternalID", app.ID).Logger()
-
-			// 2a. Fetch latest report info
-			reportInfo, err := s.cl.GetLatestReportInfo(ctx, app.ID)
+			// List the next chunk once every result of this one was handed to the output
+			for range chunk {
+				select {
+				case <-handled:
+				case <-runCtx.Done():
+					return
+				}
+			}
+			next, err := nextChunk(runCtx)
+			if err == nil {
+				err = s.resolveOrgNames(runCtx, logger, next, orgIDToName)
+			}
 			if err != nil {
-				resultsChan <- AppReportResult{Err: fmt.Errorf("latest report for %s: %w", app.PublicID, err)}
+				listErr = err
+				cancelRun()
 				return
 			}
+			total += len(next)
+			tracker.grow(len(next))
+			if s.opts.SplitByOrg {
+				scoped = append(scoped, next...)
+			}
+			chunk = next
+		}
+	}()
 
-			// Skip if no report available
-			if reportInfo == nil || strings.TrimSpace(reportInfo.ReportHTMLURL) == "" {
-				appLogger.Info().Msg("No recent report found for application, skipping")
-				resultsChan <- AppReportResult{}
-				return
+	// Wait for all goroutines to finish, then close the channel in a non-blocking way
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	// Aggregate results, handing each application's rows (nil when skipped or failed) to sink.
+	// After sink fails the run is cancelled and the remaining results are only drained.
+	// Every hand-off is sampled into backlog.
+	var failures, denied []AppFailure
+	var backlog BacklogStats
+	summary := Summary{Applications: len(apps)}
+	collect := func(sink func(seq int, rows []report.Row) error) error {
+		var sinkErr error
+		done, cutOff := 0, 0
+		waitStart := time.Now()
+		for res := range resultsChan {
+			backlog.observe(len(resultsChan), len(window), time.Since(waitStart))
+			done++
+			if res.Err != nil && deadlineExceeded(ctx) && errors.Is(res.Err, context.DeadlineExceeded) {
+				cutOff++
+			}
+			if s.opts.Progress != nil {
+				s.opts.Progress(done, total)
+			}
+			if est, due := tracker.observe(done); due {
+				logger.Info().
+					Int("done", est.Done).
+					Int("total", est.Total).
+					Float64("appsPerSecond", est.Rate).
+					Dur("elapsed", est.Elapsed).
+					Dur("eta", est.Remaining).
+					Msg("Progress")
 			}
+			rows := s.classify(logger, res, cp, &summary, &failures, &denied)
+			if sinkErr != nil {
+				continue
+			}
+			sinkStart := time.Now()
+			if sinkErr = sink(res.seq, rows); sinkErr != nil {
+				cancelRun()
+			}
+			waitStart = time.Now()
+			backlog.WriterBusy += waitStart.Sub(sinkStart)
+			if res.handled != nil {
+				res.handled <- struct{}{}
+			}
+		}
+		summary.Applications = total
+		if deadlineExceeded(ctx) {
+			return &RunTimeoutError{Timeout: s.opts.RunTimeout, Incomplete: total - done + cutOff, Total: total, Err: ctx.Err()}
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("run aborted: %w", err)
+		}
+		if sinkErr != nil {
+			return sinkErr
+		}
+		return listErr
+	}
 
-			// 2b. Extract report ID and validate
-			_, reportID, found := strings.Cut(reportInfo.ReportHTMLURL, "/report/")
-			if !found || reportID == "" {
-				resultsChan <- AppReportResult{Err: fmt.Errorf("cannot parse report id from %q", reportInfo.ReportHTMLURL)}
-				return
+	writeOpts := s.outputOptions()
+	writeOpts.Metadata = s.metadata(startedAt, apps)
+	if chunks != nil {
+		// Written before the later chunks are listed, so it names every listed organization
+		writeOpts.Metadata.OrganizationIDs = slices.Sorted(slices.Values(chunks.ids))
+	}
+	var result Result
+	var timeoutErr *RunTimeoutError
+	var allViolationRows []report.Row
+	byThreat := make(map[string]int)
+	if s.opts.StreamOutput {
+		// =================================================================
+		// 3. STREAM THE REPORT IN APPLICATION ORDER AS RESULTS ARRIVE
+		// =================================================================
+		target, err := report.JoinOutputPath(s.opts.OutputDir, filename)
+		if err != nil {
+			cancelRun()
+			for range resultsChan {
 			}
-			appLogger.Debug().Str("reportID", reportID).Str("stage", reportInfo.Stage).Msg("Parsed report ID")
+			return Result{}, err
+		}
+		s.logger.Info().Str("path", target).Str("format", string(writeOpts.Format)).Msg("Streaming report")
+		err = report.StreamFile(target, writeOpts, s.logger, func(write func([]report.Row) error) error {
+			ordered := report.NewOrderedBuffer(func(_ int, rows []report.Row) error {
+				<-window // the application's rows leave memory; let the dispatcher start another
+				summary.TotalRows += len(rows)
+				countThreats(byThreat, rows)
+				return write(rows)
+			})
+			return collect(ordered.Add)
+		})
+		summary.ByThreat = byThreat
+		result.Summary, result.Failures, result.AccessDenied = summary, failures, denied
+		result.Backlog = backlog
+		backlog.log(s.logger)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, err
+			}
+			return result, fmt.Errorf("stream %s: %w", writeOpts.Format, err)
+		}
+		s.logSummary(summary)
+		result.Path = target
+	} else if s.opts.OutputMode == OutputModeCount && !s.collectOnly {
+		// =================================================================
+		// 3. TALLY THE VIOLATIONS AS RESULTS ARRIVE, WITHOUT WRITING A REPORT
+		// =================================================================
+		counts := newCounts()
+		err := collect(func(_ int, rows []report.Row) error {
+			counts.add(rows)
+			return nil
+		})
+		summary.TotalRows = counts.Total
+		summary.ByThreat = counts.ByThreat
+		result.Summary, result.Failures, result.AccessDenied = summary, failures, denied
+		result.Counts = counts
+		if errors.As(err, &timeoutErr) && s.opts.WritePartialOnTimeout {
+			logger.Warn().Err(err).Msg("RUN TIMED OUT: counting the applications finished so far")
+		} else if err != nil {
+			return result, err
+		}
+		s.logSummary(summary)
+	} else {
+		err := collect(func(_ int, rows []report.Row) error {
+			allViolationRows = append(allViolationRows, rows...)
+			return nil
+		})
+		result.Summary, result.Failures, result.AccessDenied = summary, failures, denied
+		if errors.As(err, &timeoutErr) && s.opts.WritePartialOnTimeout {
+			logger.Warn().Err(err).Msg("RUN TIMED OUT: writing the applications finished so far")
+		} else if err != nil {
+			result.Summary.TotalRows = len(allViolationRows)
+			return result, err
+		}
+
+		allViolationRows = s.capRows(logger, allViolationRows, &summary)
+		summary.TotalRows = len(allViolationRows)
+		countThreats(byThreat, allViolationRows)
+		summary.ByThreat = byThreat
+		result.Summary = summary
+		s.logSummary(summary)
+
+		if s.opts.EnrichCVE && timeoutErr == nil {
+			s.enrichCVEs(ctx, allViolationRows)
+		}
+
+		// An instance of a multi-instance run hands its rows to the parent, which writes them
+		if s.collectOnly {
+			result.rows, result.meta = allViolationRows, writeOpts.Metadata
+			return result, runError(timeoutErr, failures, total)
+		}
+
+		// =================================================================
+		// 3. REPORT GENERATION AND FINAL PATH RETURN
+		// =================================================================
 
-			// 2c. Look up organization name
-			orgName, ok := orgIDToName[app.OrganizationID]
-			if !ok {
-				orgName = app.OrganizationID
-				appLogger.Warn().Str("orgID", app.OrganizationID).Msg("organization name not found, using ID as fallback")
+		if s.opts.SplitByOrg {
+			paths, err := s.writeSplitByOrg(startedAt, scoped, orgIDToName, allViolationRows, writeOpts)
+			result.Paths = paths
+			if err != nil {
+				return result, err
+			}
+		} else {
+			path, paths, err := s.writeFormats(startedAt, orgToken, allViolationRows, writeOpts)
+			result.Path, result.Paths = path, paths
+			if err != nil {
+				return result, err
 			}
+		}
+	}
+	if timeoutErr == nil {
+		// A partial timed-out report keeps the checkpoint, so a resumed run can finish the rest
+		s.clearCheckpoint(cp)
+	}
 
-			// 2d. Fetch policy violations (Returns []client.ViolationRow)
-			clientRows, err := s.cl.GetPolicyViolations(ctx, app.PublicID, reportID, orgName)
+	if err := s.finish(ctx, logger, filename, &result, timeoutErr != nil); err != nil {
+		return result, err
+	}
+	return result, runError(timeoutErr, failures, total)
+}
+
+// finish writes the error report, uploads the written files and pushes metrics once the
+// report of result is written. A timed-out partial report is not uploaded.
+func (s *IQReportService) finish(ctx context.Context, logger zerolog.Logger, filename string, result *Result, timedOut bool) error {
+	if s.opts.WriteErrorReport {
+		errorsPath, err := report.JoinOutputPath(s.opts.OutputDir, errorsFilename(filename))
+		if err != nil {
+			return err
+		}
+		listed := result.Failures
+		if s.opts.ErrorReportAccessDenied {
+			listed = append(slices.Clone(result.Failures), result.AccessDenied...)
+		}
+		if err := report.WriteErrorsFile(errorsPath, errorRows(listed), s.permissions(), s.logger); err != nil {
+			return fmt.Errorf("write error report: %w", err)
+		}
+		result.ErrorsPath = errorsPath
+	}
+	if s.opts.RedactKeyFile {
+		keyPath, err := report.JoinOutputPath(s.opts.OutputDir, redactionKeyFilename(filename))
+		if err != nil {
+			return err
+		}
+		if err := report.WriteRedactionKeyFile(keyPath, s.redactor.key(), s.permissions(), s.logger); err != nil {
+			return fmt.Errorf("write redaction key: %w", err)
+		}
+		result.RedactionKeyPath = keyPath
+	}
+	if s.opts.WriteLatest && !s.opts.SplitByOrg && !timedOut {
+		written := result.Paths
+		if result.Path != "" {
+			written = []string{result.Path}
+		}
+		for i, path := range written {
+			ext := report.Options{Format: report.Format(s.formats[i]), Gzip: s.opts.OutputGzip}.Extension()
+			latest, err := report.WriteLatest(path, ext, s.permissions(), s.logger)
 			if err != nil {
-				resultsChan <- AppReportResult{Err: fmt.Errorf("policy violations for %s: %w", app.PublicID, err)}
-				return
+				return fmt.Errorf("update latest report: %w", err)
 			}
-			appLogger.Debug().Int("rowsCount", len(clientRows)).Msg("Fetched policy violations")
-
-			// 2e. Convert client rows to report rows (report.Row is the expected output type)
-			reportRows := make([]report.Row, len(clientRows))
-			for i, r := range clientRows {
-				reportRows[i] = report.Row{
-					Application:    r.Application,
-					Organization:   r.Organization,
-					Policy:         r.Policy,
-					Format:         r.Format,
-					Component:      r.Component,
-					Threat:         r.Threat,
-					PolicyAction:   r.PolicyAction,
-					ConstraintName: r.ConstraintName,
-					Condition:      r.Condition,
-					CVE:            r.CVE,
-				}
+			result.LatestPaths = append(result.LatestPaths, latest)
+		}
+	}
+
+	if s.opts.Uploader != nil {
+		if timedOut {
+			logger.Warn().Msg("run timed out, skipping upload of the partial report")
+		} else {
+			uploaded, err := s.uploadFiles(ctx, *result)
+			result.Uploaded = uploaded
+			if err != nil {
+				return err
 			}
+		}
+	}
+
+	s.pushMetrics(ctx, result.Summary.Applications, result.Summary.TotalRows, result.Summary.ByThreat)
+	return nil
+}
 
-			// 2f. Send successful results to the channel
-			resultsChan <- AppReportResult{Rows: reportRows}
-		}()
+// runError is the error returned alongside a written report: the timeout when the run was
+// cut short, else a *PartialFailureError when some of total applications failed.
+func runError(timeoutErr *RunTimeoutError, failures []AppFailure, total int) error {
+	if timeoutErr != nil {
+		return timeoutErr
 	}
+	if len(failures) > 0 {
+		return &PartialFailureError{Failures: failures, Total: total}
+	}
+	return nil
+}
 
-	// Wait for all goroutines to finish, then close the channel in a non-blocking way
-	go func() {
-		wg.Wait()
-		close(resultsChan)
+// capRows applies Options.MaxRows after a deterministic sort, so the highest-threat rows
+// survive, recording the dropped count in summary.
+func (s *IQReportService) capRows(logger zerolog.Logger, rows []report.Row, summary *Summary) []report.Row {
+	if s.opts.MaxRows <= 0 {
+		return rows
+	}
+	report.SortRows(rows)
+	if len(rows) > s.opts.MaxRows {
+		summary.TruncatedRows = len(rows) - s.opts.MaxRows
+		rows = rows[:s.opts.MaxRows]
+		logger.Warn().
+			Int("maxRows", s.opts.MaxRows).
+			Int("truncatedRows", summary.TruncatedRows).
+			Msg("REPORT TRUNCATED: row limit reached, lowest-threat rows dropped")
+	}
+	return rows
+}
+
+// writeRows writes rows to stdout or to filename under OutputDir, returning the file
+// path (empty for stdout).
+func (s *IQReportService) writeRows(filename string, rows []report.Row, writeOpts report.Options) (string, error) {
+	if s.opts.OutputDest == OutputDestStdout {
+		writeOpts.Gzip = false
+		s.logger.Info().Str("format", string(writeOpts.Format)).Int("totalRows", len(rows)).Msg("Writing report to stdout")
+		if err := report.Write(os.Stdout, rows, writeOpts, s.logger); err != nil {
+			return "", fmt.Errorf("write %s: %w", writeOpts.Format, err)
+		}
+		return "", nil
+	}
+	target, err := report.JoinOutputPath(s.opts.OutputDir, filename)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Info().Str("path", target).Str("format", string(writeOpts.Format)).Int("totalRows", len(rows)).Msg("Writing report")
+	if err := report.WriteFile(target, rows, writeOpts, s.logger); err != nil {
+		return "", fmt.Errorf("write %s: %w", writeOpts.Format, err)
+	}
+	s.logger.Info().Str("path", target).Msg("Report written successfully")
+	return target, nil
+}
+
+// searchApplications finds applications by Options.AppNameQuery on the server instead of
+// listing all of them, keeping only those of orgID when set.
+func (s *IQReportService) searchApplications(ctx context.Context, orgID *string) ([]client.Application, error) {
+	apps, err := s.cl.SearchApplications(ctx, s.opts.AppNameQuery)
+	if err != nil {
+		return nil, err
+	}
+	if orgID != nil {
+		apps = slices.DeleteFunc(apps, func(a client.Application) bool { return a.OrganizationID != *orgID })
+	}
+	s.logger.Info().Str("query", s.opts.AppNameQuery).Int("matched", len(apps)).Msg("Searched applications by name")
+	return apps, nil
+}
+
+// scopeApplications drops duplicate applications and those outside Options.OrganizationIDs
+// or the application name filter from apps.
+func (s *IQReportService) scopeApplications(logger zerolog.Logger, apps []client.Application) ([]client.Application, error) {
+	apps, err := s.dedupApplications(logger, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.opts.OrganizationIDs) > 0 {
+		fetched := len(apps)
+		inScope := make(map[string]bool, len(s.opts.OrganizationIDs))
+		for _, id := range s.opts.OrganizationIDs {
+			inScope[id] = true
+		}
+		apps = slices.DeleteFunc(apps, func(a client.Application) bool { return !inScope[a.OrganizationID] })
+		logger.Info().
+			Int("fetched", fetched).
+			Int("kept", len(apps)).
+			Int("organizations", len(s.opts.OrganizationIDs)).
+			Msg("Applied organization filter")
+	}
+
+	if s.appFilter.active() {
+		fetched := len(apps)
+		apps = s.appFilter.apply(apps)
+		logger.Info().
+			Int("fetched", fetched).
+			Int("kept", len(apps)).
+			Str("include", s.opts.AppIncludeRegex).
+			Str("exclude", s.opts.AppExcludeRegex).
+			Msg("Applied application name filter")
+	}
+	return apps, nil
+}
+
+// reportFilename expands the output filename template for org and format, adding .gz when
+// compressing.
+func (s *IQReportService) reportFilename(now time.Time, org, format string) (string, error) {
+	filename, err := ExpandFilename(s.opts.OutputFilenameTemplate, FilenameTokens{
+		Now:    now,
+		Org:    org,
+		Format: format,
+	})
+	if err != nil {
+		return "", err
+	}
+	if s.opts.OutputGzip && !strings.HasSuffix(filename, ".gz") {
+		filename += ".gz"
+	}
+	return filename, nil
+}
+
+// classify records res in the summary and failure lists, checkpointing successful results,
+// and returns the rows it contributes to the report (nil when skipped or failed).
+func (s *IQReportService) classify(logger zerolog.Logger, res AppReportResult, cp *checkpoint, summary *Summary, failures, denied *[]AppFailure) []report.Row {
+	if res.AccessDenied {
+		// Not checkpointed either, so a resume picks the app up once access is granted
+		logger.Warn().Err(res.Err).Str("appPublicID", res.PublicID).Msg("access denied to application, skipping")
+		*denied = append(*denied, newAppFailure(res.AppID, res.PublicID, res.Err))
+		summary.AppsAccessDenied++
+		return nil
+	}
+	if res.Err != nil {
+		// Record the failure and keep going; failed apps are not checkpointed so a resume retries them
+		logger.Error().Err(res.Err).Str("appPublicID", res.PublicID).Msg("application failed")
+		*failures = append(*failures, newAppFailure(res.AppID, res.PublicID, res.Err))
+		summary.AppsFailed++
+		return nil
+	}
+	// Skips for Options.Since are not checkpointed: SINCE may differ, or be relative to now, on resume
+	if cp != nil && !res.fromCheckpoint && !res.Stale {
+		if err := cp.record(res); err != nil {
+			logger.Warn().Err(err).Str("appID", res.AppID).Msg("failed to record checkpoint")
+		}
+	}
+	switch {
+	case res.NoReport:
+		summary.AppsNoReport++
+	case res.Stale:
+		summary.AppsStale++
+	case !slices.ContainsFunc(res.Rows, func(r report.Row) bool { return !r.Clean }):
+		summary.AppsZeroViolations++
+	default:
+		summary.AppsWithViolations++
+	}
+	if s.isStale(res) {
+		logger.Warn().
+			Str("appPublicID", res.PublicID).
+			Time("evaluationDate", res.EvaluationDate).
+			Dur("staleAfter", s.opts.StaleAfter).
+			Msg("latest report is stale")
+		summary.StaleReports = append(summary.StaleReports, StaleReport{AppPublicID: res.PublicID, EvaluationDate: res.EvaluationDate})
+	}
+	return res.Rows
+}
+
+// predatesSince reports whether a report evaluated at date falls before Options.Since; an
+// unknown date is kept.
+func (s *IQReportService) predatesSince(date time.Time) bool {
+	return !s.opts.Since.IsZero() && !date.IsZero() && date.Before(s.opts.Since)
+}
+
+// isStale reports whether res is a fetched report evaluated longer than Options.StaleAfter
+// ago; an unknown evaluation date is never stale.
+func (s *IQReportService) isStale(res AppReportResult) bool {
+	if s.opts.StaleAfter <= 0 || res.NoReport || res.Stale || res.EvaluationDate.IsZero() {
+		return false
+	}
+	return time.Since(res.EvaluationDate) > s.opts.StaleAfter
+}
+
+// logSummary logs the run summary once the report rows are final.
+func (s *IQReportService) logSummary(summary Summary) {
+	s.logger.Info().
+		Int("applications", summary.Applications).
+		Int("appsNoReport", summary.AppsNoReport).
+		Int("appsStale", summary.AppsStale).
+		Int("appsZeroViolations", summary.AppsZeroViolations).
+		Int("appsWithViolations", summary.AppsWithViolations).
+		Int("appsAccessDenied", summary.AppsAccessDenied).
+		Int("appsFailed", summary.AppsFailed).
+		Int("totalRows", summary.TotalRows).
+		Int("truncatedRows", summary.TruncatedRows).
+		Int("staleReports", len(summary.StaleReports)).
+		Msg("Run summary")
+}
+
+// processAppIsolated runs processApp, turning a panic (e.g. on an unexpected report shape)
+// into that application's error so the other applications and the run carry on.
+func (s *IQReportService) processAppIsolated(ctx context.Context, app client.Application, orgIDToName map[string]string) (res AppReportResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error().
+				Str("appPublicID", app.PublicID).
+				Str("panic", fmt.Sprint(r)).
+				Str("stack", string(debug.Stack())).
+				Msg("application processing panicked")
+			res = AppReportResult{Err: fmt.Errorf("processing %s panicked: %v", app.PublicID, r)}
+		}
 	}()
+	return s.processApp(ctx, app, orgIDToName)
+}
 
-	// Aggregate results
-	var allViolationRows []report.Row
-	for res := range resultsChan {
-		if res.Err != nil {
-			// Fail fast if any application processing encountered a critical error
-			return "", res.Err
+// processApp fetches the latest report for a single application and converts its
+// policy violations to report rows. It runs on a worker goroutine.
+func (s *IQReportService) processApp(ctx context.Context, app client.Application, orgIDToName map[string]string) AppReportResult {
+	// Check for context cancellation/timeout early
+	if ctx.Err() != nil {
+		return AppReportResult{Err: ctx.Err()}
+	}
+
+	appLogger := s.logger.With().Str("appPublicID", app.PublicID).Str("appInternalID", app.ID).Logger()
+
+	// 2a. Fetch latest report info; a fixed stage is asked for directly, saving a listing,
+	// and a pinned report ID needs no lookup (its link has the form IQ reports)
+	var reportInfo *client.ReportInfo
+	var err error
+	switch {
+	case s.opts.ReportID != "":
+		reportInfo = &client.ReportInfo{ReportHTMLURL: "ui/links/application/" + url.PathEscape(app.PublicID) + "/report/" + url.PathEscape(s.opts.ReportID)}
+	case s.opts.ReportStage != "":
+		reportInfo, err = s.cl.GetLatestReportForStage(ctx, app.ID, s.opts.ReportStage)
+	default:
+		reportInfo, err = s.cl.GetLatestReportInfo(ctx, app.ID, "")
+	}
+	if err != nil {
+		return AppReportResult{Err: fmt.Errorf("latest report for %s: %w", app.PublicID, err), AccessDenied: client.IsForbidden(err)}
+	}
+
+	// Skip if no report available
+	if reportInfo == nil || strings.TrimSpace(reportInfo.ReportHTMLURL) == "" {
+		appLogger.Info().Msg("No recent report found for application, skipping")
+		return AppReportResult{NoReport: true}
+	}
+
+	// Skip reports evaluated before the incremental cutoff; an unknown date is kept
+	if s.predatesSince(reportInfo.EvaluationDate) {
+		appLogger.Info().Time("evaluationDate", reportInfo.EvaluationDate).Time("since", s.opts.Since).Msg("Latest report predates cutoff, skipping")
+		return AppReportResult{Stale: true}
+	}
+
+	// 2b. Extract report ID and validate
+	_, reportID, found := strings.Cut(reportInfo.ReportHTMLURL, "/report/")
+	if !found || reportID == "" {
+		return AppReportResult{Err: fmt.Errorf("cannot parse report id from %q", reportInfo.ReportHTMLURL)}
+	}
+	appLogger.Debug().Str("reportID", reportID).Str("stage", reportInfo.Stage).Msg("Parsed report ID")
+
+	// 2c. Look up organization name
+	orgName, ok := orgIDToName[app.OrganizationID]
+	if !ok {
+		orgName = app.OrganizationID
+		appLogger.Warn().Str("orgID", app.OrganizationID).Msg("organization name not found, using ID as fallback")
+	}
+
+	// 2d. Fetch policy violations (Returns []client.ViolationRow)
+	clientRows, err := s.cl.GetPolicyViolations(ctx, app.PublicID, reportID, orgName)
+	if err != nil {
+		return AppReportResult{Err: fmt.Errorf("policy violations for %s: %w", app.PublicID, err), AccessDenied: client.IsForbidden(err)}
+	}
+	appLogger.Debug().Int("rowsCount", len(clientRows)).Msg("Fetched policy violations")
+	if len(clientRows) == 0 && s.opts.MarkCleanApps {
+		// A marker row tells a clean application apart from a skipped one
+		clientRows = []client.ViolationRow{{Clean: true, EvaluatedAt: reportInfo.EvaluationDate}}
+	}
+
+	// 2e. Convert client rows to report rows (report.Row is the expected output type)
+	reportRows := make([]report.Row, len(clientRows))
+	var reportURL string
+	if s.opts.IncludeReportURL {
+		reportURL = absoluteURL(s.opts.ServerURL, s.opts.APIBasePath, reportInfo.ReportHTMLURL)
+	}
+	includeReasons := s.columns.Selects("reasons") || (s.columns == nil && s.extraColumns.Selects("reasons"))
+	application, organization := app.PublicID, orgName
+	if s.redactor != nil {
+		application = s.redactor.pseudonym(redactApplication, application)
+		organization = s.redactor.pseudonym(redactOrganization, organization)
+	}
+	for i, r := range clientRows {
+		var evaluatedAt string
+		if !r.EvaluatedAt.IsZero() {
+			evaluatedAt = r.EvaluatedAt.Format(time.RFC3339)
 		}
-		// Append successful rows
-		allViolationRows = append(allViolationRows, res.Rows...)
+		cves := r.CVEs
+		if cves == nil {
+			cves = []string{} // encoded as [] rather than null
+		}
+		var reasons []string
+		if includeReasons {
+			reasons = r.Reasons
+			if reasons == nil {
+				reasons = []string{}
+			}
+		}
+		reportRows[i] = report.Row{
+			Application:    application,
+			Organization:   organization,
+			Policy:         r.Policy,
+			Format:         r.Format,
+			Component:      r.Component,
+			Threat:         r.Threat,
+			ThreatRaw:      r.ThreatRaw,
+			PolicyAction:   r.PolicyAction,
+			ConstraintName: r.ConstraintName,
+			Condition:      r.Condition,
+			Conditions:     r.Conditions,
+			CVE:            r.CVE,
+			CVEs:           cves,
+			License:        r.License,
+			Stage:          reportInfo.Stage,
+			EvaluatedAt:    evaluatedAt,
+			Group:          r.Group,
+			Name:           r.Name,
+			Version:        r.Version,
+			Purl:           r.Purl,
+			Occurrences:    r.Occurrences,
+			Reasons:        reasons,
+			Clean:          r.Clean,
+			Severity:       report.SeverityLabel(r.Threat),
+			Instance:       s.instance,
+			ReportURL:      reportURL,
+		}
+		reportRows[i].ID = report.ViolationID(reportRows[i])
 	}
 
-	// =================================================================
-	// 3. CSV GENERATION AND FINAL PATH RETURN
-	// =================================================================
+	// 2f. Look up the recommended version of each violating component
+	if s.opts.IncludeRemediation {
+		s.addRemediation(ctx, app.ID, reportInfo.Stage, clientRows, reportRows)
+	}
 
-	target := filepath.Join(s.cfg.OutputDir, filename)
-	s.logger.Info().Str("path", target).Int("totalRows", len(allViolationRows)).Msg("Writing CSV report")
+	// 2g. Return successful results
+	return AppReportResult{Rows: reportRows, EvaluationDate: reportInfo.EvaluationDate}
+}
 
-	if err := report.WriteCSV(target, allViolationRows, s.logger); err != nil {
-		return "", fmt.Errorf("write csv: %w", err)
+// sweepTempFiles removes temp files of interrupted writes from OutputDir, per
+// Options.TempFileMaxAge. Failures are logged; they do not stop the run.
+func (s *IQReportService) sweepTempFiles() {
+	if s.opts.TempFileMaxAge <= 0 || s.opts.OutputDest != OutputDestFile {
+		return
+	}
+	removed, err := report.SweepTempFiles(s.opts.OutputDir, s.opts.TempFileMaxAge, s.logger)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("dir", s.opts.OutputDir).Msg("failed to sweep stale temp files")
 	}
+	if removed > 0 {
+		s.logger.Info().Int("removed", removed).Msg("Swept stale temp files")
+	}
+}
 
-	s.logger.Info().Str("path", target).Msg("Report written successfully")
+// clearCheckpoint deletes the run's checkpoint after the report has been written.
+func (s *IQReportService) clearCheckpoint(cp *checkpoint) {
+	if cp == nil {
+		return
+	}
+	if err := cp.remove(); err != nil {
+		s.logger.Warn().Err(err).Str("checkpoint", cp.path).Msg("failed to remove checkpoint")
+	}
+}
 
-	return target, nil
+// outputOptions maps the output configuration onto report writer options.
+func (s *IQReportService) outputOptions() report.Options {
+	return report.Options{
+		Format:                 report.Format(s.formats[0]),
+		Mode:                   report.Mode(s.opts.OutputMode),
+		Gzip:                   s.opts.OutputGzip,
+		MarkdownConditionWidth: s.opts.MarkdownConditionWidth,
+		CSVDelimiter:           s.opts.CSVDelimiter,
+		CSVWriteBOM:            s.opts.CSVWriteBOM,
+		ThreatAsFloat:          s.opts.ThreatAsFloat,
+		Columns:                s.columns,
+		ExtraColumns:           s.extraColumns,
+		Perm:                   s.permissions(),
+	}
+}
+
+// extraColumns returns the optional report columns added to the default columns: those of
+// Options.ExtraColumns and those the enabled features fill (CVE details, Recommended Version,
+// Instance, Report URL and Reasons).
+func (o Options) extraColumns() report.Columns {
+	var names []string
+	if o.EnrichCVE {
+		names = append(names, "cveSeverity", "cvssScore", "cvssVector", "cveDescription")
+	}
+	if o.IncludeRemediation {
+		names = append(names, "recommendedVersion")
+	}
+	if len(o.Instances) > 0 {
+		names = append(names, "instance")
+	}
+	if o.IncludeReportURL {
+		names = append(names, "reportUrl")
+	}
+	if o.IncludeReasons {
+		names = append(names, "reasons")
+	}
+	cols, _ := report.ParseColumns(o.ExtraColumns) // validated by NewIQReportService
+	filled, _ := report.ParseColumns(names)        // known, distinct names
+	for _, idx := range filled {
+		if !slices.Contains(cols, idx) {
+			cols = append(cols, idx)
+		}
+	}
+	return cols
+}
+
+// metadata describes the run for self-describing (JSON) reports covering apps.
+func (s *IQReportService) metadata(startedAt time.Time, apps []client.Application) report.Metadata {
+	var orgIDs []string
+	for _, app := range apps {
+		if app.OrganizationID != "" && !slices.Contains(orgIDs, app.OrganizationID) {
+			orgIDs = append(orgIDs, app.OrganizationID)
+		}
+	}
+	slices.Sort(orgIDs)
+	return report.Metadata{
+		GeneratedAt:     startedAt.UTC(),
+		IQServerURL:     report.ServerOrigin(s.opts.ServerURL),
+		OrganizationIDs: orgIDs,
+		ToolVersion:     s.opts.ToolVersion,
+	}
+}
+
+// permissions returns the modes for written files and created directories.
+func (s *IQReportService) permissions() report.Permissions {
+	return report.Permissions{File: s.opts.OutputFileMode, Dir: s.opts.OutputDirMode}
+}
+
+// pushMetrics sends run metrics to the configured Pushgateway. Failures are logged, not returned,
+// since the report itself has already been written.
+func (s *IQReportService) pushMetrics(ctx context.Context, appCount, rowCount int, byThreat map[string]int) {
+	if s.opts.MetricsPushgatewayURL == "" {
+		return
+	}
+	err := metrics.Push(ctx, s.opts.MetricsPushgatewayURL, nil, metrics.RunMetrics{
+		Org:                 s.opts.OrganizationID,
+		ApplicationsScanned: appCount,
+		ViolationRows:       rowCount,
+		ByThreat:            byThreat,
+	})
+	if err != nil {
+		s.logger.Warn().Err(err).Str("url", s.opts.MetricsPushgatewayURL).Msg("failed to push metrics")
+		return
+	}
+	s.logger.Info().Str("url", s.opts.MetricsPushgatewayURL).Msg("Pushed run metrics")
+}
+
+// countThreats adds the violation rows (clean rows excluded) to the per-severity counts.
+func countThreats(byThreat map[string]int, rows []report.Row) {
+	for _, r := range rows {
+		if !r.Clean {
+			byThreat[report.Severity(r.Threat)]++
+		}
+	}
+}
+
+// absoluteURL resolves ref, a link IQ returned, against the server URL without its API base
+// path (apiBasePath, "" = client.DefaultAPIBasePath), credentials or query, so servers under a
+// context path keep it. ref is returned unchanged when already absolute or unparsable.
+func absoluteURL(serverURL, apiBasePath, ref string) string {
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil || r.IsAbs() {
+		return ref
+	}
+	base, err := url.Parse(strings.TrimSpace(serverURL))
+	if err != nil || base.Host == "" {
+		return ref
+	}
+	if apiBasePath == "" {
+		apiBasePath = client.DefaultAPIBasePath
+	}
+	base.User, base.RawQuery, base.Fragment = nil, "", ""
+	base.Path = strings.TrimSuffix(strings.TrimRight(base.Path, "/"), strings.TrimRight(apiBasePath, "/")) + "/"
+	return base.ResolveReference(r).String()
 }
@@ -2,19 +2,29 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
-	"github.com/anmicius0/iqserver-report-fetch-go/internal/config"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
 	"github.com/rs/zerolog"
 )
 
@@ -22,92 +32,119 @@ func testLogger() zerolog.Logger {
 	return zerolog.New(io.Discard)
 }
 
-func TestGenerateLatestPolicyReport_Integration(t *testing.T) {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/v2/applications", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		resp := map[string]any{
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// stubHandlers returns the handlers for a single application "apid-1" in org "personal"
+// with one Security-Medium violation. Tests may replace entries before calling startStub.
+func stubHandlers() map[string]http.HandlerFunc {
+	listApps := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
 			"applications": []map[string]any{
 				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
 			},
-		}
-		_ = json.NewEncoder(w).Encode(resp)
-	})
-	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		resp := map[string]any{
-			"organizations": []map[string]any{
-				{"id": "org-1", "name": "personal"},
-			},
-		}
-		_ = json.NewEncoder(w).Encode(resp)
-	})
-	mux.HandleFunc("/api/v2/reports/applications/aid-1", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		resp := []map[string]any{
-			{
-				"stage":         "build",
-				"reportHtmlUrl": "https://stub/report/rpt-xyz",
-			},
-		}
-		_ = json.NewEncoder(w).Encode(resp)
-	})
-	mux.HandleFunc("/api/v2/applications/apid-1/reports/rpt-xyz/policy", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		resp := map[string]any{
-			"components": []any{
-				map[string]any{
-					"displayName": "comp-A",
-					"componentIdentifier": map[string]any{
-						"format": "maven",
-					},
-					"violations": []any{
-						map[string]any{
-							"policyName":        "Security-Medium",
-							"policyThreatLevel": 7,
-							"constraints": []any{
-								map[string]any{
-									"constraintName": "Medium risk CVSS score",
-									"conditions": []any{
-										map[string]any{"conditionSummary": "Security Vulnerability Severity >= 4"},
-										map[string]any{"conditionSummary": "Security Vulnerability Severity < 7"},
+		})
+	}
+	return map[string]http.HandlerFunc{
+		"/api/v2/applications":                    listApps,
+		"/api/v2/applications/organization/org-1": listApps,
+		"/api/v2/organizations": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{
+				"organizations": []map[string]any{
+					{"id": "org-1", "name": "personal"},
+				},
+			})
+		},
+		"/api/v2/organizations/org-1": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{"id": "org-1", "name": "personal"})
+		},
+		"/api/v2/reports/applications/aid-1": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, []map[string]any{
+				{
+					"stage":         "build",
+					"reportHtmlUrl": "https://stub/report/rpt-xyz",
+				},
+			})
+		},
+		"/api/v2/applications/apid-1/reports/rpt-xyz/policy": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{
+				"reportTime": 1700000000000,
+				"components": []any{
+					map[string]any{
+						"displayName": "comp-A",
+						"componentIdentifier": map[string]any{
+							"format": "maven",
+						},
+						"violations": []any{
+							map[string]any{
+								"policyName":        "Security-Medium",
+								"policyThreatLevel": 7,
+								"constraints": []any{
+									map[string]any{
+										"constraintName": "Medium risk CVSS score",
+										"conditions": []any{
+											map[string]any{"conditionSummary": "Security Vulnerability Severity >= 4"},
+											map[string]any{"conditionSummary": "Security Vulnerability Severity < 7"},
+										},
 									},
 								},
 							},
 						},
 					},
 				},
-			},
-		}
-		_ = json.NewEncoder(w).Encode(resp)
-	})
+			})
+		},
+	}
+}
 
+// startStub serves handlers from an httptest server and returns the /api/v2 base URL.
+func startStub(t *testing.T, handlers map[string]http.HandlerFunc) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	for pattern, h := range handlers {
+		mux.HandleFunc(pattern, h)
+	}
 	srv := httptest.NewServer(mux)
-	defer srv.Close()
+	t.Cleanup(srv.Close)
+	return strings.TrimRight(srv.URL, "/") + "/api/v2"
+}
 
-	baseURL := strings.TrimRight(srv.URL, "/") + "/api/v2"
-	iqClient, err := client.NewClient(baseURL, "u", "p", testLogger())
-	if err != nil {
-		t.Fatalf("client init: %v", err)
+// newTestService builds a service against baseURL writing into a temp dir.
+func newTestService(t *testing.T, baseURL string, mutate func(*Options)) *IQReportService {
+	t.Helper()
+	opts := Options{
+		ServerURL:              baseURL,
+		Username:               "u",
+		Password:               "p",
+		OutputDir:              t.TempDir(),
+		OutputFilenameTemplate: "report.{format}",
+		OutputFormat:           "csv",
+		OutputDest:             OutputDestFile,
+		Logger:                 testLogger(),
 	}
-
-	tmpDir := t.TempDir()
-	cfg := &config.Config{
-		IQServerURL:    baseURL,
-		IQUsername:     "u",
-		IQPassword:     "p",
-		OrganizationID: "",
-		OutputDir:      tmpDir,
+	if mutate != nil {
+		mutate(&opts)
+	}
+	svc, err := New(opts)
+	if err != nil {
+		t.Fatalf("service init: %v", err)
 	}
+	return svc
+}
 
-	svc := NewIQReportService(cfg, iqClient, testLogger())
+func TestGenerateLatestPolicyReport_Integration(t *testing.T) {
+	baseURL := startStub(t, stubHandlers())
+	svc := newTestService(t, baseURL, nil)
 
-	filename := "report.csv"
-	outputPath, err := svc.GenerateLatestPolicyReport(rCtx(t), nil, filename)
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
 	if err != nil {
 		t.Fatalf("GenerateLatestPolicyReport: %v", err)
 	}
-	if !strings.HasSuffix(outputPath, filepath.Join(tmpDir, filename)) {
+	outputPath := res.Path
+	if !strings.HasSuffix(outputPath, filepath.Join(svc.opts.OutputDir, "report.csv")) {
 		t.Errorf("unexpected path: %q", outputPath)
 	}
 	b, err := os.ReadFile(outputPath)
@@ -124,12 +161,1667 @@ func TestGenerateLatestPolicyReport_Integration(t *testing.T) {
 	if !strings.Contains(content, "maven") {
 		t.Errorf("format field 'maven' missing from output")
 	}
+	if !strings.Contains(content, ",Stage,Evaluated At,Group,Name,Version,") {
+		t.Errorf("stage/evaluated-at header missing")
+	}
+	if !strings.Contains(content, ",build,2023-11-14T22:13:20Z,") {
+		t.Errorf("stage/evaluated-at values missing:\n%s", content)
+	}
+}
+
+func TestGenerateLatestPolicyReport_JSONToStdout(t *testing.T) {
+	baseURL := startStub(t, stubHandlers())
+	svc := newTestService(t, baseURL, func(o *Options) {
+		o.OutputFormat = "json"
+		o.OutputDest = OutputDestStdout
+		o.Password = "s3cr3t-token"
+		o.ToolVersion = "1.2.3"
+	})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	origStdout := os.Stdout
+	os.Stdout = w
+	res, genErr := svc.GenerateLatestPolicyReport(rCtx(t))
+	os.Stdout = origStdout
+	_ = w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	if genErr != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", genErr)
+	}
+	if res.Path != "" {
+		t.Errorf("expected empty path for stdout output, got %q", res.Path)
+	}
+
+	var doc report.ReportDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("stdout is not a JSON report document: %v\n%s", err, out)
+	}
+	rows := doc.Rows
+	if len(rows) != 1 || rows[0].Application != "apid-1" || rows[0].Organization != "personal" {
+		t.Errorf("unexpected rows: %#v", rows)
+	}
+	if doc.RowCount != 1 || doc.ToolVersion != "1.2.3" || !slices.Equal(doc.OrganizationIDs, []string{"org-1"}) ||
+		doc.GeneratedAt.IsZero() || doc.IQServerURL != strings.TrimSuffix(baseURL, "/api/v2") {
+		t.Errorf("unexpected metadata: %+v", doc.Metadata)
+	}
+	if strings.Contains(string(out), "s3cr3t-token") {
+		t.Errorf("credentials leaked into the report:\n%s", out)
+	}
+	entries, _ := os.ReadDir(svc.opts.OutputDir)
+	if len(entries) != 0 {
+		t.Errorf("expected no files written, found %d", len(entries))
+	}
+}
+
+func TestNew_FromOptionsLiteral(t *testing.T) {
+	baseURL := startStub(t, stubHandlers())
+	outDir := t.TempDir()
+
+	svc, err := New(Options{
+		ServerURL:              baseURL,
+		Username:               "u",
+		Password:               "p",
+		OrganizationID:         "org-1",
+		MaxConcurrency:         2,
+		OutputDir:              outDir,
+		OutputFilenameTemplate: "{org}.{format}",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if svc.opts.OutputFormat != "csv" || svc.opts.OutputDest != OutputDestFile {
+		t.Errorf("defaults not applied: %#v", svc.opts)
+	}
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if want := filepath.Join(outDir, "personal.csv"); res.Path != want {
+		t.Errorf("path = %q, want %q", res.Path, want)
+	}
 }
 
 // rCtx returns a cancellable context with a small timeout and ensures cancel via t.Cleanup.
+func TestOptions_ConnPoolScalesWithConcurrency(t *testing.T) {
+	got := Options{MaxConcurrency: 200}.connPool()
+	if got.MaxIdleConnsPerHost != 202 || got.MaxIdleConns != 202 || got.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("connPool() = %+v, want 202 per host and overall, default timeout", got)
+	}
+	got = Options{HTTPMaxIdleConnsPerHost: 5, HTTPIdleConnTimeout: time.Second}.connPool()
+	if got.MaxIdleConnsPerHost != 5 || got.MaxIdleConns != 100 || got.IdleConnTimeout != time.Second {
+		t.Errorf("connPool() = %+v, want explicit settings kept", got)
+	}
+}
+
 func rCtx(t *testing.T) context.Context {
 	t.Helper()
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	t.Cleanup(cancel)
 	return ctx
 }
+
+func TestGenerateLatestPolicyReport_ProgressCallback(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-1"},
+				{"id": "aid-3", "publicId": "apid-3", "organizationId": "org-1"},
+			},
+		})
+	}
+	for _, id := range []string{"aid-2", "aid-3"} {
+		handlers["/api/v2/reports/applications/"+id] = func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, []any{})
+		}
+	}
+	baseURL := startStub(t, handlers)
+
+	var calls [][2]int
+	svc := newTestService(t, baseURL, func(o *Options) {
+		o.Progress = func(done, total int) { calls = append(calls, [2]int{done, total}) }
+	})
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("progress called %d times, want 3: %v", len(calls), calls)
+	}
+	for i, c := range calls {
+		if c[0] != i+1 || c[1] != 3 {
+			t.Errorf("call %d = %v, want [%d 3]", i, c, i+1)
+		}
+	}
+}
+
+func TestGenerateLatestPolicyReport_SummaryDistinguishesNoReportAndClean(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-1"},
+				{"id": "aid-3", "publicId": "apid-3", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []any{})
+	}
+	handlers["/api/v2/reports/applications/aid-3"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-clean"}})
+	}
+	handlers["/api/v2/applications/apid-3/reports/rpt-clean/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{}})
+	}
+	svc := newTestService(t, startStub(t, handlers), nil)
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	want := Summary{Applications: 3, AppsNoReport: 1, AppsZeroViolations: 1, AppsWithViolations: 1, TotalRows: 1, ByThreat: map[string]int{"high": 1}}
+	if !reflect.DeepEqual(res.Summary, want) {
+		t.Errorf("summary = %+v, want %+v", res.Summary, want)
+	}
+}
+
+func TestGenerateLatestPolicyReport_FetchesAppsAndOrgsConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	handlers := stubHandlers()
+	for _, path := range []string{"/api/v2/applications/organization/org-1", "/api/v2/organizations/org-1"} {
+		inner := handlers[path]
+		handlers[path] = func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			inner(w, r)
+		}
+	}
+	// With an organization scope its name is resolved alongside the app list
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.OrganizationID = "org-1" })
+
+	start := time.Now()
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 2*delay-20*time.Millisecond {
+		t.Errorf("setup took %v; expected roughly max(%v, %v), not the sum", elapsed, delay, delay)
+	}
+}
+
+func TestGenerateLatestPolicyReport_OrganizationsFailureAborts(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/organizations/org-1"] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}
+	svc := newTestService(t, startStub(t, handlers), nil)
+
+	_, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err == nil || !strings.Contains(err.Error(), "get organizations") {
+		t.Fatalf("expected get organizations error, got %v", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_OrgNamesOptional(t *testing.T) {
+	for _, scoped := range []bool{false, true} {
+		t.Run(fmt.Sprintf("scoped=%v", scoped), func(t *testing.T) {
+			handlers := stubHandlers()
+			handlers["/api/v2/organizations/org-1"] = func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "boom", http.StatusInternalServerError)
+			}
+			handlers["/api/v2/organizations"] = handlers["/api/v2/organizations/org-1"]
+			svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+				o.OrgNamesOptional = true
+				if scoped {
+					o.OrganizationID = "org-1"
+				}
+			})
+
+			res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+			if err != nil {
+				t.Fatalf("GenerateLatestPolicyReport error = %v", err)
+			}
+			b, err := os.ReadFile(res.Path)
+			if err != nil {
+				t.Fatalf("read report: %v", err)
+			}
+			if !strings.Contains(string(b), ",apid-1,org-1,") {
+				t.Errorf("organization ID not used as the name:\n%s", b)
+			}
+		})
+	}
+
+	if _, err := NewIQReportService(Options{OrgNamesOptional: true, OnMissingOrg: MissingOrgError}, nil); err == nil {
+		t.Error("expected optional organization names to conflict with OnMissingOrg error")
+	}
+}
+
+func TestGenerateLatestPolicyReport_ListingPermissionDenied(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		status    int
+		orgID     string
+		wantPerm  bool
+		wantInErr string
+	}{
+		{"unauthorized", http.StatusUnauthorized, "", false, "HTTP 401"},
+		{"forbidden", http.StatusForbidden, "", true, "credentials are valid but lack permission"},
+		{"forbidden for organization", http.StatusForbidden, "org-1", true, "lack permission for organization org-1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers := stubHandlers()
+			deny := func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, http.StatusText(tt.status), tt.status)
+			}
+			handlers["/api/v2/applications"] = deny
+			handlers["/api/v2/applications/organization/org-1"] = deny
+			svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.OrganizationID = tt.orgID })
+
+			_, err := svc.GenerateLatestPolicyReport(rCtx(t))
+			if err == nil {
+				t.Fatal("GenerateLatestPolicyReport succeeded, want an error")
+			}
+			if got := errors.Is(err, client.ErrPermissionDenied); got != tt.wantPerm {
+				t.Errorf("errors.Is(%v, ErrPermissionDenied) = %v, want %v", err, got, tt.wantPerm)
+			}
+			if client.IsUnauthorized(err) == tt.wantPerm {
+				t.Errorf("IsUnauthorized(%v) = %v, want %v", err, !tt.wantPerm, !tt.wantPerm)
+			}
+			if !strings.Contains(err.Error(), tt.wantInErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantInErr)
+			}
+		})
+	}
+}
+
+func TestGenerateLatestPolicyReport_AppFetchChunks(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("full application list requested despite chunking")
+		http.Error(w, "response too large", http.StatusGatewayTimeout)
+	}
+	handlers["/api/v2/organizations"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"organizations": []map[string]any{
+			{"id": "org-1", "name": "personal"}, {"id": "org-2", "name": "team"}, {"id": "org-3", "name": "empty"},
+		}})
+	}
+	var mu sync.Mutex
+	var listed []string
+	for id, apps := range map[string][]map[string]any{
+		"org-1": {{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"}},
+		"org-2": {{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-2"}},
+		"org-3": {},
+	} {
+		handlers["/api/v2/applications/organization/"+id] = func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			listed = append(listed, id)
+			mu.Unlock()
+			writeJSON(w, map[string]any{"applications": apps})
+		}
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []any{})
+	}
+	baseURL := startStub(t, handlers)
+
+	svc := newTestService(t, baseURL, func(o *Options) { o.AppFetchChunkSize = 2 })
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport error = %v", err)
+	}
+	if res.Summary.Applications != 2 || res.Summary.AppsWithViolations != 1 || res.Summary.AppsNoReport != 1 {
+		t.Errorf("summary = %+v, want both chunked applications processed", res.Summary)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), ",apid-1,personal,") {
+		t.Errorf("report missing the chunked application:\n%s", b)
+	}
+	slices.Sort(listed)
+	if !slices.Equal(listed, []string{"org-1", "org-2", "org-3"}) {
+		t.Errorf("listed organizations %v, want every organization once", listed)
+	}
+
+	// With ORGANIZATION_IDS only those organizations are listed.
+	listed = nil
+	svc = newTestService(t, baseURL, func(o *Options) {
+		o.AppFetchChunkSize = 2
+		o.OrganizationIDs = []string{"org-2"}
+	})
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("GenerateLatestPolicyReport error = %v", err)
+	}
+	if !slices.Equal(listed, []string{"org-2"}) {
+		t.Errorf("listed organizations %v, want [org-2]", listed)
+	}
+}
+
+func TestGenerateLatestPolicyReport_AppFetchChunksAlternate(t *testing.T) {
+	handlers := stubHandlers()
+	var mu sync.Mutex
+	var events []string
+	logEvent := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	handlers["/api/v2/organizations"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"organizations": []map[string]any{
+			{"id": "org-1", "name": "personal"}, {"id": "org-2", "name": "team"}, {"id": "org-3", "name": "ops"},
+		}})
+	}
+	for _, n := range []string{"1", "2", "3"} {
+		handlers["/api/v2/applications/organization/org-"+n] = func(w http.ResponseWriter, r *http.Request) {
+			logEvent("list org-" + n)
+			writeJSON(w, map[string]any{"applications": []map[string]any{
+				{"id": "aid-" + n, "publicId": "apid-" + n, "organizationId": "org-" + n},
+			}})
+		}
+	}
+	fetchReport := handlers["/api/v2/reports/applications/aid-1"]
+	for _, n := range []string{"1", "2", "3"} {
+		handlers["/api/v2/reports/applications/aid-"+n] = func(w http.ResponseWriter, r *http.Request) {
+			logEvent("fetch aid-" + n)
+			if n == "1" {
+				fetchReport(w, r)
+				return
+			}
+			writeJSON(w, []any{})
+		}
+	}
+	baseURL := startStub(t, handlers)
+
+	svc := newTestService(t, baseURL, func(o *Options) {
+		o.AppFetchChunkSize = 1
+		o.MaxConcurrency = 4
+	})
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport error = %v", err)
+	}
+	want := []string{"list org-1", "fetch aid-1", "list org-2", "fetch aid-2", "list org-3", "fetch aid-3"}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want each chunk's reports fetched before the next chunk is listed", events)
+	}
+	if res.Summary.Applications != 3 || res.Summary.AppsWithViolations != 1 || res.Summary.AppsNoReport != 2 {
+		t.Errorf("summary = %+v, want every chunk counted", res.Summary)
+	}
+
+	// A single organization is listed in one request anyway
+	if _, err := NewIQReportService(Options{AppFetchChunkSize: 1, OrganizationID: "org-1"}, nil); err == nil {
+		t.Error("NewIQReportService accepted chunked listing with an organization ID")
+	}
+}
+
+func TestGenerateLatestPolicyReport_ResolvesOnlyInScopeOrganizations(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-2"},
+				{"id": "aid-3", "publicId": "sandbox-3", "organizationId": "org-3"},
+			},
+		})
+	}
+	for _, id := range []string{"aid-2", "aid-3"} {
+		handlers["/api/v2/reports/applications/"+id] = func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, []any{})
+		}
+	}
+	var mu sync.Mutex
+	requested := make(map[string]int)
+	for id, name := range map[string]string{"org-1": "personal", "org-2": "team", "org-3": "sandbox"} {
+		handlers["/api/v2/organizations/"+id] = func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested[id]++
+			mu.Unlock()
+			writeJSON(w, map[string]any{"id": id, "name": name})
+		}
+	}
+	handlers["/api/v2/organizations"] = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("full organization list should not be fetched")
+		writeJSON(w, map[string]any{"organizations": []any{}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.AppExcludeRegex = "^sandbox-" })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	// A second run on the same client reuses the resolved names.
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("second GenerateLatestPolicyReport: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for id, want := range map[string]int{"org-1": 1, "org-2": 1, "org-3": 0} {
+		if got := requested[id]; got != want {
+			t.Errorf("organization %s requested %d times, want %d", id, got, want)
+		}
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), ",apid-1,personal,") {
+		t.Errorf("organization name not resolved:\n%s", b)
+	}
+}
+
+func TestGenerateLatestPolicyReport_OrganizationIDs(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-2"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("application of an organization outside ORGANIZATION_IDS should not be fetched")
+		writeJSON(w, []any{})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.OrganizationIDs = []string{"org-1", "org-9"} })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.Applications != 1 {
+		t.Errorf("applications = %d, want 1", res.Summary.Applications)
+	}
+
+	if _, err := NewIQReportService(Options{OrganizationID: "org-1", OrganizationIDs: []string{"org-2"}}, nil); err == nil {
+		t.Error("OrganizationID with OrganizationIDs should be rejected")
+	}
+}
+
+func TestGenerateLatestPolicyReport_FailedAppRecordedInErrorReport(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-broken", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.WriteErrorReport = true })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *PartialFailureError, got %v", err)
+	}
+	if len(partial.Failures) != 1 || partial.Failures[0].PublicID != "apid-broken" {
+		t.Errorf("failures = %+v, want apid-broken only", partial.Failures)
+	}
+	if res.Summary.AppsFailed != 1 || res.Summary.AppsWithViolations != 1 {
+		t.Errorf("summary = %+v, want 1 failed and 1 with violations", res.Summary)
+	}
+
+	// The healthy app still made it into the report.
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), "apid-1") {
+		t.Errorf("report missing healthy app:\n%s", b)
+	}
+
+	if want := filepath.Join(svc.opts.OutputDir, "report-errors.csv"); res.ErrorsPath != want {
+		t.Errorf("ErrorsPath = %q, want %q", res.ErrorsPath, want)
+	}
+	eb, err := os.ReadFile(res.ErrorsPath)
+	if err != nil {
+		t.Fatalf("read error report: %v", err)
+	}
+	errCSV := string(eb)
+	if !strings.HasPrefix(errCSV, "Application,Endpoint,HTTP Status,Error\n") {
+		t.Errorf("unexpected header:\n%s", errCSV)
+	}
+	if !strings.Contains(errCSV, "apid-broken,reports/applications/aid-2,500,") {
+		t.Errorf("error report missing failed app:\n%s", errCSV)
+	}
+}
+
+func TestGenerateLatestPolicyReport_PinnedReportID(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("latest report looked up despite a pinned report ID")
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	}
+	handlers["/api/v2/applications/apid-1/reports/rpt-old/policy"] = handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	delete(handlers, "/api/v2/applications/apid-1/reports/rpt-xyz/policy")
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.ReportID = "rpt-old"
+		o.AppIncludeRegex = "^apid-1$"
+		o.IncludeReportURL = true
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport error = %v", err)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), "comp-A") || !strings.Contains(string(b), "/report/rpt-old") {
+		t.Errorf("report not built from the pinned report:\n%s", b)
+	}
+
+	// Several applications in scope cannot share one report ID.
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-10", "organizationId": "org-1"},
+			},
+		})
+	}
+	svc = newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.ReportID = "rpt-old"
+		o.AppIncludeRegex = "^apid-1"
+	})
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); !errors.Is(err, ErrReportIDScope) {
+		t.Errorf("err = %v, want ErrReportIDScope", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_MalformedReportJSON(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-garbled", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-bad"}})
+	}
+	handlers["/api/v2/applications/apid-garbled/reports/rpt-bad/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"components":[{"displayName":`))
+	}
+	var logs bytes.Buffer
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.Logger = zerolog.New(zerolog.SyncWriter(&logs))
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *PartialFailureError, got %v", err)
+	}
+	if len(partial.Failures) != 1 || partial.Failures[0].PublicID != "apid-garbled" {
+		t.Fatalf("failures = %+v, want apid-garbled only", partial.Failures)
+	}
+	if !errors.Is(partial.Failures[0].Err, client.ErrMalformedResponse) {
+		t.Errorf("failure error = %v, want ErrMalformedResponse", partial.Failures[0].Err)
+	}
+	if res.Summary.AppsFailed != 1 || res.Summary.AppsWithViolations != 1 {
+		t.Errorf("summary = %+v, want 1 failed and 1 with violations", res.Summary)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), "apid-1") {
+		t.Errorf("report missing healthy app:\n%s", b)
+	}
+	if !strings.Contains(logs.String(), `"rawBodySnippet":"{\"components\":[{\"displayName\":"`) {
+		t.Errorf("raw body snippet not logged:\n%s", logs.String())
+	}
+}
+
+func TestGenerateLatestPolicyReport_SinceSkipsOldReports(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-old", "publicId": "apid-old", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{
+			"stage":          "build",
+			"reportHtmlUrl":  "https://stub/report/rpt-xyz",
+			"evaluationDate": "2024-05-02T10:00:00.000-05:00",
+		}})
+	}
+	handlers["/api/v2/reports/applications/aid-old"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{
+			"stage":          "build",
+			"reportHtmlUrl":  "https://stub/report/rpt-old",
+			"evaluationDate": "2024-04-01T10:00:00.000-05:00",
+		}})
+	}
+	var oldFetched atomic.Bool
+	handlers["/api/v2/applications/apid-old/reports/rpt-old/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		oldFetched.Store(true)
+		writeJSON(w, map[string]any{"components": []any{}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.Since = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if oldFetched.Load() {
+		t.Error("policy report of the out-of-window app was fetched")
+	}
+	want := Summary{Applications: 2, AppsStale: 1, AppsWithViolations: 1, TotalRows: 1, ByThreat: map[string]int{"high": 1}}
+	if !reflect.DeepEqual(res.Summary, want) {
+		t.Errorf("summary = %+v, want %+v", res.Summary, want)
+	}
+}
+
+func TestGenerateLatestPolicyReport_WriteLatest(t *testing.T) {
+	handlers := stubHandlers()
+	// The second run sees a clean report, so its file differs from the first
+	var secondRun atomic.Bool
+	policy := handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		if secondRun.Load() {
+			writeJSON(w, map[string]any{"components": []any{}})
+			return
+		}
+		policy(w, r)
+	}
+	baseURL := startStub(t, handlers)
+	dir := t.TempDir()
+
+	var paths []string
+	for i, template := range []string{"first.{format}", "second.{format}"} {
+		secondRun.Store(i == 1)
+		svc := newTestService(t, baseURL, func(o *Options) {
+			o.OutputDir = dir
+			o.OutputFilenameTemplate = template
+			o.WriteLatest = true
+		})
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		if want := []string{filepath.Join(dir, "latest.csv")}; !slices.Equal(res.LatestPaths, want) {
+			t.Errorf("run %d: LatestPaths = %q, want %q", i+1, res.LatestPaths, want)
+		}
+		paths = append(paths, res.Path)
+	}
+
+	first, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := os.ReadFile(filepath.Join(dir, "latest.csv"))
+	if err != nil {
+		t.Fatalf("read latest: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatal("both runs wrote the same report; the test cannot tell them apart")
+	}
+	if !bytes.Equal(latest, second) {
+		t.Errorf("latest.csv =\n%s\nwant the second report\n%s", latest, second)
+	}
+}
+
+func TestGenerateLatestPolicyReport_ExtraColumns(t *testing.T) {
+	baseURL := startStub(t, stubHandlers())
+	header := func(mutate func(*Options)) []string {
+		t.Helper()
+		svc := newTestService(t, baseURL, mutate)
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("GenerateLatestPolicyReport: %v", err)
+		}
+		b, err := os.ReadFile(res.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.SplitN(string(b), "\n", 2)[0], ",")
+	}
+
+	def := header(func(o *Options) {})
+	for _, optional := range []string{"License", "Purl", "Occurrence Count", "Report URL"} {
+		if slices.Contains(def, optional) {
+			t.Errorf("default header has optional column %s: %v", optional, def)
+		}
+	}
+	// Named extras and the columns of enabled features join the defaults, in the default order
+	got := header(func(o *Options) {
+		o.ExtraColumns = []string{"occurrenceCount", "Purl"}
+		o.IncludeReportURL = true
+	})
+	if want := append(slices.Clone(def), "Report URL", "Purl", "Occurrence Count"); !slices.Equal(got, want) {
+		t.Errorf("header = %v, want %v", got, want)
+	}
+
+	svc := newTestService(t, baseURL, func(o *Options) {})
+	o := svc.opts
+	o.ExtraColumns, o.OutputColumns = []string{"Purl"}, []string{"Application"}
+	if _, err := New(o); err == nil || !strings.Contains(err.Error(), "extra columns") {
+		t.Errorf("err = %v, want extra columns rejected with output columns", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_MultipleFormats(t *testing.T) {
+	baseURL := startStub(t, stubHandlers())
+	svc := newTestService(t, baseURL, func(o *Options) {
+		o.OutputFormat = "csv, json"
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	dir := svc.opts.OutputDir
+	want := []string{filepath.Join(dir, "report.csv"), filepath.Join(dir, "report.json")}
+	if res.Path != "" || !slices.Equal(res.Paths, want) {
+		t.Fatalf("Path = %q, Paths = %q, want Paths %q", res.Path, res.Paths, want)
+	}
+
+	f, err := os.Open(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	data, err := os.ReadFile(want[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc report.ReportDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(records)-1 != len(doc.Rows) || doc.RowCount != len(doc.Rows) || len(doc.Rows) == 0 {
+		t.Errorf("csv has %d rows, json has %d (rowCount %d)", len(records)-1, len(doc.Rows), doc.RowCount)
+	}
+
+	for name, mutate := range map[string]func(*Options){
+		"no {format}": func(o *Options) { o.OutputFilenameTemplate = "report" },
+		"stdout":      func(o *Options) { o.OutputDest = OutputDestStdout },
+		"split":       func(o *Options) { o.SplitByOrg, o.OutputFilenameTemplate = true, "{org}.{format}" },
+	} {
+		o := svc.opts
+		o.OutputFormat = "csv,json"
+		mutate(&o)
+		if _, err := New(o); err == nil || !strings.Contains(err.Error(), "output formats") {
+			t.Errorf("%s: err = %v, want several formats rejected", name, err)
+		}
+	}
+}
+
+func TestGenerateLatestPolicyReport_MarkCleanApps(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{}})
+	}
+	baseURL := startStub(t, handlers)
+
+	for _, mark := range []bool{false, true} {
+		svc := newTestService(t, baseURL, func(o *Options) {
+			o.OutputFormat = "json"
+			o.MarkCleanApps = mark
+		})
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("mark=%v: %v", mark, err)
+		}
+		if sum := res.Summary; sum.AppsZeroViolations != 1 || sum.AppsNoReport != 0 || sum.AppsWithViolations != 0 {
+			t.Errorf("mark=%v: summary = %+v, want the application counted as clean, not skipped", mark, sum)
+		}
+
+		data, err := os.ReadFile(res.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc report.ReportDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("decode json: %v", err)
+		}
+		if !mark {
+			if len(doc.Rows) != 0 {
+				t.Errorf("mark=false: rows = %#v, want none", doc.Rows)
+			}
+			continue
+		}
+		if len(doc.Rows) != 1 {
+			t.Fatalf("mark=true: rows = %#v, want one marker row", doc.Rows)
+		}
+		row := doc.Rows[0]
+		if !row.Clean || row.Application != "apid-1" || row.Organization != "personal" || row.Component != "" || row.Policy != "" {
+			t.Errorf("mark=true: marker row = %#v", row)
+		}
+	}
+}
+
+func TestGenerateLatestPolicyReport_StaleAfter(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-old", "publicId": "apid-old", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{
+			"stage":          "build",
+			"reportHtmlUrl":  "https://stub/report/rpt-xyz",
+			"evaluationDate": time.Now().Add(-24 * time.Hour).Format(time.RFC3339),
+		}})
+	}
+	handlers["/api/v2/reports/applications/aid-old"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{
+			"stage":          "build",
+			"reportHtmlUrl":  "https://stub/report/rpt-old",
+			"evaluationDate": "2024-04-01T10:00:00.000-05:00",
+		}})
+	}
+	handlers["/api/v2/applications/apid-old/reports/rpt-old/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{}})
+	}
+	var logs bytes.Buffer
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.StaleAfter = 90 * 24 * time.Hour
+		o.Logger = zerolog.New(zerolog.SyncWriter(&logs))
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	want := []StaleReport{{AppPublicID: "apid-old", EvaluationDate: time.Date(2024, 4, 1, 15, 0, 0, 0, time.UTC)}}
+	if len(res.Summary.StaleReports) != 1 || res.Summary.StaleReports[0].AppPublicID != want[0].AppPublicID ||
+		!res.Summary.StaleReports[0].EvaluationDate.Equal(want[0].EvaluationDate) {
+		t.Errorf("stale reports = %+v, want %+v", res.Summary.StaleReports, want)
+	}
+	// Stale applications are still reported
+	if res.Summary.AppsZeroViolations != 1 || res.Summary.AppsWithViolations != 1 || res.Summary.AppsStale != 0 {
+		t.Errorf("summary = %+v, want both applications reported", res.Summary)
+	}
+	if n := strings.Count(logs.String(), "latest report is stale"); n != 1 {
+		t.Errorf("logged %d stale warnings, want 1:\n%s", n, logs.String())
+	}
+}
+
+func TestGenerateLatestPolicyReport_ReportStageUsesHistory(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		t.Error("report listing fetched although REPORT_STAGE is set")
+		writeJSON(w, []map[string]any{})
+	}
+	handlers["/api/v2/reports/applications/aid-1/history"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"reports": []map[string]any{{
+			"stage":         "build",
+			"reportHtmlUrl": "https://stub/report/rpt-xyz",
+		}}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.ReportStage = "build"
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.TotalRows != 1 {
+		t.Errorf("summary = %+v, want 1 row", res.Summary)
+	}
+}
+
+func TestGenerateLatestPolicyReport_AppTimeoutIsolatesSlowApp(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-slow", "publicId": "apid-slow", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-slow"] = func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.AppTimeout = 100 * time.Millisecond })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *PartialFailureError, got %v", err)
+	}
+	if len(partial.Failures) != 1 || partial.Failures[0].PublicID != "apid-slow" {
+		t.Fatalf("failures = %+v, want apid-slow only", partial.Failures)
+	}
+	if !errors.Is(partial.Failures[0].Err, context.DeadlineExceeded) {
+		t.Errorf("failure err = %v, want deadline exceeded", partial.Failures[0].Err)
+	}
+	if res.Summary.AppsWithViolations != 1 || res.Path == "" {
+		t.Errorf("healthy app not reported: %+v", res)
+	}
+}
+
+func TestGenerateLatestPolicyReport_SummaryMode(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-2"}})
+	}
+	violation := func(policy string, threat int, constraints ...string) map[string]any {
+		cs := make([]any, len(constraints))
+		for i, c := range constraints {
+			cs[i] = map[string]any{"constraintName": c, "conditions": []any{map[string]any{"conditionSummary": c}}}
+		}
+		return map[string]any{"policyName": policy, "policyThreatLevel": threat, "constraints": cs}
+	}
+	handlers["/api/v2/applications/apid-2/reports/rpt-2/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{
+			map[string]any{"displayName": "comp-B", "violations": []any{
+				violation("Security-High", 9, "c1", "c2"),
+				violation("License", 4, "c3"),
+			}},
+			map[string]any{"displayName": "comp-C", "violations": []any{
+				violation("Security-High", 8, "c4"),
+			}},
+		}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.OutputMode = "summary" })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	want := "Application,Organization,Max Threat,Violations,Policies\n" +
+		"apid-2,personal,9,4,License; Security-High\n" +
+		"apid-1,personal,7,1,Security-Medium\n"
+	if string(b) != want {
+		t.Errorf("summary report =\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestGenerateLatestPolicyReport_AccessDeniedSkipped(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-private", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-private"}})
+	}
+	handlers["/api/v2/applications/apid-private/reports/rpt-private/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}
+
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprintf("include=%v", include), func(t *testing.T) {
+			svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+				o.WriteErrorReport = true
+				o.ErrorReportAccessDenied = include
+			})
+
+			res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+			if err != nil {
+				t.Fatalf("access denied must not fail the run: %v", err)
+			}
+			if res.Summary.AppsAccessDenied != 1 || res.Summary.AppsFailed != 0 || res.Summary.AppsWithViolations != 1 {
+				t.Errorf("summary = %+v, want 1 access denied, 0 failed, 1 with violations", res.Summary)
+			}
+			if len(res.AccessDenied) != 1 || res.AccessDenied[0].PublicID != "apid-private" || res.AccessDenied[0].StatusCode != http.StatusForbidden {
+				t.Errorf("AccessDenied = %+v", res.AccessDenied)
+			}
+
+			eb, err := os.ReadFile(res.ErrorsPath)
+			if err != nil {
+				t.Fatalf("read error report: %v", err)
+			}
+			if listed := strings.Contains(string(eb), "apid-private,applications/apid-private/reports/rpt-private/policy,403,"); listed != include {
+				t.Errorf("error report lists denied app = %v, want %v:\n%s", listed, include, eb)
+			}
+		})
+	}
+}
+
+func TestGenerateLatestPolicyReport_SplitByOrg(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-2"},
+			},
+		})
+	}
+	handlers["/api/v2/organizations/org-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"id": "org-2", "name": "team"})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-2"}})
+	}
+	handlers["/api/v2/applications/apid-2/reports/rpt-2/policy"] = handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.SplitByOrg = true
+		o.OutputFilenameTemplate = "report_{org}.{format}"
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	dir := svc.opts.OutputDir
+	want := []string{filepath.Join(dir, "report_personal.csv"), filepath.Join(dir, "report_team.csv")}
+	if !slices.Equal(res.Paths, want) || res.Path != "" {
+		t.Fatalf("Paths = %v Path = %q, want %v", res.Paths, res.Path, want)
+	}
+	if res.Summary.TotalRows != 2 || res.Summary.AppsWithViolations != 2 {
+		t.Errorf("summary = %+v, want combined totals", res.Summary)
+	}
+
+	for i, own := range []string{"apid-1", "apid-2"} {
+		b, err := os.ReadFile(res.Paths[i])
+		if err != nil {
+			t.Fatalf("read %s: %v", res.Paths[i], err)
+		}
+		other := []string{"apid-2", "apid-1"}[i]
+		if !strings.Contains(string(b), own) || strings.Contains(string(b), other) {
+			t.Errorf("%s should hold only %s rows:\n%s", res.Paths[i], own, b)
+		}
+	}
+}
+
+func TestNewIQReportService_SplitByOrgRequiresOrgToken(t *testing.T) {
+	_, err := NewIQReportService(Options{SplitByOrg: true, OutputFilenameTemplate: "report.{format}"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "{org}") {
+		t.Errorf("err = %v, want error mentioning {org}", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_ManyAppsTinyBuffer(t *testing.T) {
+	const n = 300
+	policy := stubHandlers()["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	apps := make([]map[string]any, n)
+	for i := range apps {
+		apps[i] = map[string]any{"id": fmt.Sprintf("aid-%d", i), "publicId": fmt.Sprintf("apid-%d", i), "organizationId": "org-1"}
+	}
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"applications": apps})
+	}
+	handlers["/api/v2/reports/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-xyz"}})
+	}
+	handlers["/api/v2/applications/"] = policy // every /applications/{publicId}/reports/rpt-xyz/policy
+
+	// MaxConcurrency 1 also sizes the results buffer to 1.
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.MaxConcurrency = 1 })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.TotalRows != n || res.Summary.AppsWithViolations != n {
+		t.Errorf("summary = %+v, want %d rows from %d apps", res.Summary, n, n)
+	}
+}
+
+func TestGenerateLatestPolicyReport_MaxRowsKeepsHighestThreat(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-critical", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-2"}})
+	}
+	handlers["/api/v2/applications/apid-critical/reports/rpt-2/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{map[string]any{
+			"displayName": "comp-B",
+			"violations": []any{map[string]any{
+				"policyName":        "Security-Critical",
+				"policyThreatLevel": 10,
+				"constraints":       []any{map[string]any{"constraintName": "Critical", "conditions": []any{}}},
+			}},
+		}}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.MaxRows = 1 })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if res.Summary.TotalRows != 1 || res.Summary.TruncatedRows != 1 {
+		t.Errorf("summary = %+v, want 1 row written and 1 truncated", res.Summary)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(b), "apid-critical") || strings.Contains(string(b), "apid-1,") {
+		t.Errorf("report should keep only the threat-10 row:\n%s", b)
+	}
+}
+
+func TestGenerateLatestPolicyReport_IncludeRemediation(t *testing.T) {
+	var calls atomic.Int32
+	handlers := stubHandlers()
+	handlers["/api/v2/components/remediation/application/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, map[string]any{"remediation": map[string]any{"versionChanges": []any{
+			map[string]any{"type": "next-no-violations", "data": map[string]any{"component": map[string]any{
+				"componentIdentifier": map[string]any{"format": "maven", "coordinates": map[string]any{"version": "9.9.9"}},
+			}}},
+		}}})
+	}
+	// Two constraints on one component yield two rows but need only one lookup
+	handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"components": []any{map[string]any{
+			"displayName":         "comp-A",
+			"componentIdentifier": map[string]any{"format": "maven"},
+			"violations": []any{map[string]any{
+				"policyName":        "Security-High",
+				"policyThreatLevel": 9,
+				"constraints": []any{
+					map[string]any{"constraintName": "one", "conditions": []any{}},
+					map[string]any{"constraintName": "two", "conditions": []any{}},
+				},
+			}},
+		}}})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.IncludeRemediation = true
+		o.OutputFormat = "json"
+		o.OutputFilenameTemplate = "report.json"
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var doc report.ReportDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	rows := doc.Rows
+	if len(rows) != 2 || rows[0].RecommendedVersion != "9.9.9" || rows[1].RecommendedVersion != "9.9.9" {
+		t.Errorf("rows = %+v, want RecommendedVersion 9.9.9", rows)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("remediation calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestGenerateLatestPolicyReport_IncludeReportURL(t *testing.T) {
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) {
+		o.IncludeReportURL = true
+		o.OutputColumns = []string{"Application", "Report URL"}
+	})
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if want := "Application,Report URL\napid-1,https://stub/report/rpt-xyz\n"; string(b) != want {
+		t.Errorf("report = %q, want %q", b, want)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		server, ref, want string
+	}{
+		{"https://iq.example.com", "https://iq.example.com/ui/links/application/a/report/r", "https://iq.example.com/ui/links/application/a/report/r"},
+		{"https://u:p@iq.example.com/api/v2?x=1", "ui/links/application/a/report/r", "https://iq.example.com/ui/links/application/a/report/r"},
+		{"https://iq.example.com/iq/", "ui/links/application/a/report/r", "https://iq.example.com/iq/ui/links/application/a/report/r"},
+		{"https://iq.example.com/iq/api/v2", "/ui/links/application/a/report/r", "https://iq.example.com/ui/links/application/a/report/r"},
+	}
+	for _, tt := range tests {
+		if got := absoluteURL(tt.server, "", tt.ref); got != tt.want {
+			t.Errorf("absoluteURL(%q, %q) = %q, want %q", tt.server, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateLatestPolicyReport_StreamOutputKeepsAppOrder(t *testing.T) {
+	const n = 8
+	policy := stubHandlers()["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	apps := make([]map[string]any, n)
+	for i := range apps {
+		apps[i] = map[string]any{"id": fmt.Sprintf("aid-%d", i), "publicId": fmt.Sprintf("apid-%d", i), "organizationId": "org-1"}
+	}
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"applications": apps})
+	}
+	handlers["/api/v2/reports/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-xyz"}})
+	}
+	// Earlier applications answer later, so results arrive out of order; apid-5 fails.
+	var finished []string
+	var mu sync.Mutex
+	handlers["/api/v2/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		_, _ = fmt.Sscanf(r.URL.Path, "/api/v2/applications/apid-%d/", &i)
+		time.Sleep(time.Duration(n-i) * 15 * time.Millisecond)
+		mu.Lock()
+		finished = append(finished, fmt.Sprintf("apid-%d", i))
+		mu.Unlock()
+		if i == 5 {
+			http.Error(w, "boom", http.StatusBadRequest)
+			return
+		}
+		policy(w, r)
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.MaxConcurrency = 4
+		o.StreamOutput = true
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var pf *PartialFailureError
+	if !errors.As(err, &pf) || len(pf.Failures) != 1 {
+		t.Fatalf("err = %v, want partial failure for apid-5", err)
+	}
+	if finished[0] == "apid-0" {
+		t.Fatalf("results arrived in order %v; the test needs them out of order", finished)
+	}
+
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")[1:]
+	var got []string
+	for i, line := range lines {
+		cells := strings.Split(line, ",")
+		if cells[0] != strconv.Itoa(i+1) {
+			t.Errorf("row %d numbered %s", i+1, cells[0])
+		}
+		got = append(got, cells[1])
+	}
+	want := []string{"apid-0", "apid-1", "apid-2", "apid-3", "apid-4", "apid-6", "apid-7"}
+	if !slices.Equal(got, want) {
+		t.Errorf("streamed rows = %v, want %v", got, want)
+	}
+	if res.Summary.TotalRows != len(want) {
+		t.Errorf("TotalRows = %d, want %d", res.Summary.TotalRows, len(want))
+	}
+}
+
+func TestGenerateLatestPolicyReport_StreamOutputRecordsBacklog(t *testing.T) {
+	const n = 6
+	policy := stubHandlers()["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	apps := make([]map[string]any, n)
+	for i := range apps {
+		apps[i] = map[string]any{"id": fmt.Sprintf("aid-%d", i), "publicId": fmt.Sprintf("apid-%d", i), "organizationId": "org-1"}
+	}
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"applications": apps})
+	}
+	handlers["/api/v2/reports/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-xyz"}})
+	}
+	handlers["/api/v2/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		policy(w, r)
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.MaxConcurrency = 4
+		o.StreamBuffer = 2
+		o.StreamOutput = true
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	b := res.Backlog
+	if b.Samples != n {
+		t.Errorf("backlog samples = %d, want %d", b.Samples, n)
+	}
+	if b.MaxHeld < 1 || b.MaxHeld > 2 {
+		t.Errorf("MaxHeld = %d, want 1..2 with StreamBuffer 2", b.MaxHeld)
+	}
+	if b.WriterIdle <= 0 || b.Bottleneck() == "" {
+		t.Errorf("backlog = %+v, bottleneck %q; want idle time and a bottleneck", b, b.Bottleneck())
+	}
+}
+
+func TestNewIQReportService_StreamOutputRejectsBufferedFeatures(t *testing.T) {
+	for name, mutate := range map[string]func(*Options){
+		"stdout":  func(o *Options) { o.OutputDest = OutputDestStdout },
+		"json":    func(o *Options) { o.OutputFormat = "json" },
+		"summary": func(o *Options) { o.OutputMode = "summary" },
+		"maxRows": func(o *Options) { o.MaxRows = 10 },
+	} {
+		opts := Options{StreamOutput: true}
+		mutate(&opts)
+		if _, err := NewIQReportService(opts, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGenerateLatestPolicyReport_NotifyWebhook(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer hook.Close()
+
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) { o.NotifyWebhookURL = hook.URL })
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+
+	var body map[string]any
+	select {
+	case body = <-received:
+	default:
+		t.Fatal("webhook was not called")
+	}
+	want := map[string]any{
+		"text":               "iqfetch run success: 1 applications, 1 rows (high 1), report " + res.Path,
+		"status":             "success",
+		"applications":       float64(1),
+		"appsFailed":         float64(0),
+		"appsWithViolations": float64(1),
+		"totalRows":          float64(1),
+		"byThreat":           map[string]any{"high": float64(1)},
+		"paths":              []any{res.Path},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("payload = %v\nwant %v", body, want)
+	}
+}
+
+func TestGenerateLatestPolicyReport_NotifyFailureDoesNotFailRun(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer hook.Close()
+
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) { o.NotifyWebhookURL = hook.URL })
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("a failed notification must not fail the run: %v", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_AppNameQueryUsesSearch(t *testing.T) {
+	handlers := stubHandlers()
+	var listed atomic.Bool
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		listed.Store(true)
+		http.Error(w, "should not list", http.StatusInternalServerError)
+	}
+	handlers["/api/v2/search/advanced"] = func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("query"), "applicationName:*apid*") {
+			t.Errorf("query = %q", r.URL.Query().Get("query"))
+		}
+		writeJSON(w, map[string]any{
+			"totalNumberOfHits": 1,
+			"groupingByDTOS": []any{map[string]any{"entries": []any{map[string]any{
+				"itemType": "application", "applicationId": "aid-1", "applicationPublicId": "apid-1",
+				"applicationName": "apid", "organizationId": "org-1",
+			}}}},
+		})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.AppNameQuery = "apid" })
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if listed.Load() {
+		t.Error("listed all applications despite APP_NAME_QUERY")
+	}
+	if res.Summary.Applications != 1 || res.Summary.TotalRows != 1 {
+		t.Errorf("summary = %+v, want the searched app's row", res.Summary)
+	}
+
+	handlers["/api/v2/search/advanced"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"totalNumberOfHits": 0, "groupingByDTOS": []any{}})
+	}
+	svc = newTestService(t, startStub(t, handlers), func(o *Options) { o.AppNameQuery = "none" })
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); !errors.Is(err, ErrNoApplications) {
+		t.Errorf("err = %v, want ErrNoApplications", err)
+	}
+}
+
+func TestGenerateLatestPolicyReport_CancelLeavesNoGoroutines(t *testing.T) {
+	const n = 50
+	apps := make([]map[string]any, n)
+	for i := range apps {
+		apps[i] = map[string]any{"id": fmt.Sprintf("aid-%d", i), "publicId": fmt.Sprintf("apid-%d", i), "organizationId": "org-1"}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"applications": apps})
+	}
+	// The first report lookup cancels the run; every lookup blocks until its request is abandoned
+	handlers["/api/v2/reports/applications/"] = func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}
+	mux := http.NewServeMux()
+	for pattern, h := range handlers {
+		mux.HandleFunc(pattern, h)
+	}
+	srv := httptest.NewServer(mux)
+	// MaxConcurrency 2 leaves most workers waiting for a semaphore slot when the run is cancelled
+	svc := newTestService(t, srv.URL+"/api/v2", func(o *Options) { o.MaxConcurrency = 2 })
+
+	_, err := svc.GenerateLatestPolicyReport(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	srv.CloseClientConnections()
+	srv.Close()
+
+	// Only the service's own goroutines are checked: connection goroutines of the client and
+	// of other tests' stub servers come and go independently
+	deadline := time.Now().Add(2 * time.Second)
+	leaked := serviceGoroutines()
+	for len(leaked) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		leaked = serviceGoroutines()
+	}
+	if len(leaked) > 0 {
+		t.Errorf("%d service goroutines remain after the run returned:\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+	}
+}
+
+// serviceGoroutines returns the stacks of the goroutines running IQReportService code, such
+// as report workers and the application dispatcher.
+func serviceGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	var stacks []string
+	for stack := range strings.SplitSeq(string(buf), "\n\n") {
+		if strings.Contains(stack, "services.(*IQReportService)") {
+			stacks = append(stacks, stack)
+		}
+	}
+	return stacks
+}
+
+func TestGenerateLatestPolicyReport_RunTimeoutTypedError(t *testing.T) {
+	handlers := stubHandlers()
+	// The application's reports never arrive before the run deadline
+	handlers["/api/v2/reports/applications/aid-1"] = func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) { o.RunTimeout = 50 * time.Millisecond })
+
+	_, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var timeout *RunTimeoutError
+	if !errors.Is(err, ErrRunTimeout) || !errors.As(err, &timeout) || timeout.Timeout != 50*time.Millisecond {
+		t.Fatalf("err = %v, want *RunTimeoutError for 50ms", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v should still match context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "run exceeded the 50ms run timeout") {
+		t.Errorf("message = %q", err.Error())
+	}
+}
+
+func TestGenerateLatestPolicyReport_RunTimeoutWritesPartial(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-slow", "organizationId": "org-1"},
+			},
+		})
+	}
+	// The second application never answers before the run deadline
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.RunTimeout = 300 * time.Millisecond
+		o.WritePartialOnTimeout = true
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	var timeout *RunTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want *RunTimeoutError", err)
+	}
+	if timeout.Incomplete != 1 || timeout.Total != 2 {
+		t.Errorf("timeout = %+v, want 1 of 2 apps incomplete", timeout)
+	}
+	if !strings.Contains(err.Error(), "(1 of 2 apps incomplete)") {
+		t.Errorf("message = %q", err.Error())
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("partial report not written: %v", err)
+	}
+	if !strings.Contains(string(b), "apid-1") || strings.Contains(string(b), "apid-slow") {
+		t.Errorf("partial report should hold only apid-1:\n%s", b)
+	}
+}
+
+func TestGenerateLatestPolicyReport_LicenseColumn(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"components": []any{map[string]any{
+				"displayName": "comp-A",
+				"violations": []any{map[string]any{
+					"policyName":        "License-Banned",
+					"policyThreatLevel": 10,
+					"constraints": []any{map[string]any{
+						"constraintName": "Banned license",
+						"conditions": []any{map[string]any{
+							"conditionSummary": "License Threat Group is Banned",
+							"conditionReason":  "Found 1 License in Banned: GPL-3.0",
+						}},
+					}},
+				}},
+			}},
+		})
+	}
+	svc := newTestService(t, startStub(t, handlers), func(o *Options) {
+		o.OutputColumns = []string{"Policy", "Policy/Action", "License"}
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	b, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if want := "Policy,Policy/Action,License\nLicense-Banned,License-10,GPL-3.0\n"; string(b) != want {
+		t.Errorf("csv = %q, want %q", b, want)
+	}
+}
+
+func TestGenerateLatestPolicyReport_SweepsStaleTempFiles(t *testing.T) {
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) { o.TempFileMaxAge = time.Hour })
+	stale := filepath.Join(svc.opts.OutputDir, ".tmp-42.csv")
+	if err := os.WriteFile(stale, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temp file not swept: %v", err)
+	}
+}
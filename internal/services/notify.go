@@ -0,0 +1,57 @@
+// internal/services/notify.go
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/notify"
+)
+
+// notifyTimeout bounds the webhook call so a slow endpoint cannot hold up the run.
+const notifyTimeout = 10 * time.Second
+
+// notify posts the run outcome to Options.NotifyWebhookURL. It is best effort: failures are
+// logged, and it still runs when ctx was cancelled, so an aborted run is reported too.
+func (s *IQReportService) notify(ctx context.Context, result Result, runErr error) {
+	if s.opts.NotifyWebhookURL == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+
+	err := notify.Send(ctx, s.opts.NotifyWebhookURL, nil, runPayload(s.opts.OrganizationID, result, runErr))
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to send run notification")
+		return
+	}
+	s.logger.Info().Msg("Sent run notification")
+}
+
+// runPayload describes a finished run for the webhook.
+func runPayload(org string, result Result, runErr error) notify.Payload {
+	p := notify.Payload{
+		Status:             notify.StatusSuccess,
+		Org:                org,
+		Applications:       result.Summary.Applications,
+		AppsFailed:         result.Summary.AppsFailed,
+		AppsWithViolations: result.Summary.AppsWithViolations,
+		TotalRows:          result.Summary.TotalRows,
+		ByThreat:           result.Summary.ByThreat,
+		Paths:              result.Paths,
+	}
+	if result.Path != "" {
+		p.Paths = []string{result.Path}
+	}
+	var partial *PartialFailureError
+	switch {
+	case errors.As(runErr, &partial):
+		p.Status = notify.StatusPartial
+	case runErr != nil:
+		p.Status = notify.StatusFailed
+		p.Error = runErr.Error()
+	}
+	p.Summarize()
+	return p
+}
@@ -0,0 +1,367 @@
+// internal/services/options.go
+package services
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/upload"
+	"github.com/rs/zerolog"
+)
+
+// Output destinations accepted by Options.OutputDest.
+const (
+	OutputDestFile   = "file"
+	OutputDestStdout = "stdout"
+)
+
+// Policies accepted by Options.OnMissingOrg.
+const (
+	MissingOrgFallback = "fallback"
+	MissingOrgFetch    = "fetch"
+	MissingOrgError    = "error"
+)
+
+// Policies accepted by Options.OnDuplicateApp.
+const (
+	DuplicateAppFirst = "first"
+	DuplicateAppError = "error"
+)
+
+// defaultMaxConcurrency bounds in-flight application fetches when Options.MaxConcurrency is unset.
+const defaultMaxConcurrency = 10
+
+// defaultIdleConnTimeout closes pooled connections idle this long when Options.HTTPIdleConnTimeout is unset.
+const defaultIdleConnTimeout = 90 * time.Second
+
+// Options is the complete, environment-independent configuration of the report service.
+// Importers can fill it directly; config.Load produces it from env vars.
+type Options struct {
+	// IQ Server connection. ServerURL is the host; APIBasePath (default /api/v2) is appended.
+	ServerURL     string
+	Username      string
+	Password      string
+	APIBasePath   string
+	StrictAPIPath bool
+	// Instances, when set, reports on several IQ Servers in one run instead of ServerURL:
+	// each is queried with its own client and credentials, and the merged report tags
+	// every row with its instance's name.
+	Instances []Instance
+	// HTTPTrace logs full request/response headers and bodies (credentials redacted).
+	HTTPTrace bool
+	// UserAgent identifies the tool in IQ Server access logs (empty = client.DefaultUserAgent).
+	UserAgent string
+	// ToolVersion is recorded in the metadata of JSON reports.
+	ToolVersion string
+	// RecordDir saves every API response for later replay; ReplayDir serves those
+	// recordings instead of the network (and wins if both are set). With Instances, each
+	// instance uses a subdirectory named after it.
+	RecordDir string
+	ReplayDir string
+
+	// Scope
+	OrganizationID string
+	// OrganizationIDs restricts the run to applications of any of these organizations,
+	// filtered from the full application list; it cannot be combined with OrganizationID.
+	OrganizationIDs []string
+	// OnDuplicateApp decides what happens when the application list holds an internal ID
+	// twice: "first" (default) keeps the first listing and logs the duplicate, "error"
+	// fails the run.
+	OnDuplicateApp string
+	// OnMissingOrg decides what happens when an application's organization cannot be
+	// resolved: "fallback" (default) uses the ID as the name, "fetch" retries a single-org
+	// lookup before falling back, "error" fails the run.
+	OnMissingOrg    string
+	AppIncludeRegex string
+	AppExcludeRegex string
+	// OrgNamesOptional continues with organization IDs as names when looking the names up
+	// fails, instead of failing the run; it cannot be combined with OnMissingOrg "error".
+	OrgNamesOptional bool
+	// AppNameQuery selects applications whose name contains it via IQ's search API instead of
+	// listing every application; the regex filters still apply to the matches.
+	AppNameQuery string
+	// AppFetchChunkSize lists applications per organization, this many organizations at a
+	// time, instead of in one response that may be too large for big instances (0 = one
+	// request). Each chunk's reports are fetched and handed to the output before the next
+	// chunk is listed. It cannot be combined with OrganizationID, AppNameQuery or ReportID.
+	AppFetchChunkSize int
+	// ReportStage restricts reports to one stage, fetched per application with a single
+	// report-history call instead of listing all reports.
+	ReportStage string
+	// ReportID pins the report fetched instead of each application's latest, e.g. to
+	// reproduce a historical report; the filters must leave exactly one application.
+	ReportID string
+	// ReportSelection picks among an application's reports: "latest" (default, newest
+	// evaluation date) or "stage" (first stage in ReportStageOrder, then newest).
+	ReportSelection  string
+	ReportStageOrder []string
+	// Since skips applications whose latest report was evaluated before it (zero = no cutoff).
+	Since time.Time
+	// StaleAfter flags applications whose latest report was evaluated longer ago than this:
+	// they are still reported, but logged with a warning and listed in Summary.StaleReports
+	// (0 = no check).
+	StaleAfter time.Duration
+
+	// Concurrency (defaults to 10 when <= 0)
+	MaxConcurrency int
+	// RequestsPerSecond caps IQ Server requests across all workers (0 = unlimited).
+	RequestsPerSecond float64
+	// BreakerThreshold consecutive failed requests (transport errors, 5xx) within BreakerWindow
+	// make the client short-circuit requests with client.ErrCircuitOpen for BreakerCooldown
+	// before letting a trial request through (0 = no circuit breaker).
+	BreakerThreshold int
+	BreakerWindow    time.Duration
+	BreakerCooldown  time.Duration
+	// Retries repeats requests that failed with a transport error, 429 or 5xx up to this many
+	// times, waiting a random time up to RetryBaseWait doubled per attempt, capped at
+	// RetryMaxWait (0 = no retries).
+	Retries       int
+	RetryBaseWait time.Duration
+	RetryMaxWait  time.Duration
+	// HTTPHeaders are sent on every request, e.g. a token an API gateway requires; the
+	// Authorization header cannot be overridden.
+	HTTPHeaders map[string]string
+	// TLSServerName verifies IQ Server's certificate against this name instead of the
+	// ServerURL host, e.g. behind a load balancer; verification stays on (empty = the host).
+	TLSServerName string
+	// RequestObserver is notified around every IQ request, e.g. to trace them (nil = none).
+	RequestObserver client.RequestObserver
+	// HTTPMaxIdleConns, HTTPMaxIdleConnsPerHost and HTTPIdleConnTimeout tune the keep-alive
+	// connection pool; zero scales the idle limits with MaxConcurrency and keeps idle
+	// connections for 90s, so workers reuse connections instead of redoing TLS handshakes.
+	HTTPMaxIdleConns        int
+	HTTPMaxIdleConnsPerHost int
+	HTTPIdleConnTimeout     time.Duration
+	// RunTimeout bounds the whole run (0 = only the caller's context applies). When it fires,
+	// GenerateLatestPolicyReport returns a *RunTimeoutError; WritePartialOnTimeout then still
+	// writes the applications finished so far.
+	RunTimeout            time.Duration
+	WritePartialOnTimeout bool
+	// AppTimeout bounds the work for a single application, starting once it gets a worker
+	// slot (0 = only the caller's context applies).
+	AppTimeout time.Duration
+
+	// Output
+	OutputDir              string
+	OutputFilenameTemplate string
+	// OutputFormat is csv, json, md or html, or a comma-separated list of them such as
+	// "csv,json" to write the same rows once per format, each to its own file (see
+	// ParseOutputFormats).
+	OutputFormat string
+	// OutputMode is "detailed" (default, one row per violation), "summary" (one row per application)
+	// or OutputModeCount (no report, Result.Counts only).
+	OutputMode string
+	// OutputColumns selects and orders the detailed report's columns by header or JSON key
+	// (empty = the default columns, in the default order); see report.ParseColumns.
+	OutputColumns []string
+	OutputDest    string
+	OutputGzip    bool
+	// ExtraColumns adds optional columns (License, Purl, Occurrence Count, or any other) to the
+	// default columns; columns the enabled features fill are added without it.
+	ExtraColumns []string
+	// OutputFileMode and OutputDirMode are the permissions of written reports (and checkpoints)
+	// and of directories created for them (0 = 0o644 and 0o755).
+	OutputFileMode os.FileMode
+	OutputDirMode  os.FileMode
+	// TempFileMaxAge removes temp files older than this from OutputDir before a run; they are
+	// left behind when a process is killed mid-write (0 = no sweep).
+	TempFileMaxAge time.Duration
+	// SplitByOrg writes one file per organization of the in-scope applications instead of one
+	// combined report. It requires file output and an {org} token in OutputFilenameTemplate.
+	SplitByOrg bool
+	// StreamOutput writes detailed CSV rows as applications finish, in application order,
+	// instead of holding the whole report in memory. It requires file output and cannot be
+	// combined with SplitByOrg, MaxRows or EnrichCVE, which need every row first.
+	StreamOutput bool
+	// StreamBuffer is how many applications may be fetched ahead of the streaming writer,
+	// finished or in flight, independently of MaxConcurrency (0 = MaxConcurrency).
+	StreamBuffer int
+	// MaxRows caps the rows written, keeping the highest-threat ones (0 = unlimited).
+	// Setting it also sorts the report by threat.
+	MaxRows int
+	// MarkdownConditionWidth truncates Condition cells in markdown output (0 = no limit).
+	MarkdownConditionWidth int
+	// CSVDelimiter separates CSV fields (0 = comma); CSVWriteBOM prefixes the UTF-8 BOM.
+	CSVDelimiter rune
+	CSVWriteBOM  bool
+	// ThreatAsFloat renders the CSV Threat column as the threat level IQ reported (e.g. 7.5)
+	// instead of the truncated integer.
+	ThreatAsFloat bool
+
+	// PolicyInclude and PolicyExclude filter violations by policy name with case-insensitive
+	// globs (e.g. "Security-*"); exclude wins, and an empty include keeps every policy.
+	PolicyInclude []string
+	PolicyExclude []string
+	// PolicyFilterStrict fails the run with ErrUnknownPolicy when a policy pattern matches no
+	// policy on the server, instead of only warning.
+	PolicyFilterStrict bool
+
+	// PolicyPageSize is the components per page requested from servers that paginate the
+	// policy violations report (0 = client.DefaultPolicyPageSize).
+	PolicyPageSize int
+
+	// ConditionSeparator joins condition summaries in the Condition column (empty = " | ").
+	ConditionSeparator string
+
+	// IncludeCleanComponents adds one Clean row per component without violations.
+	IncludeCleanComponents bool
+
+	// MarkCleanApps adds one Clean marker row, with empty component and policy fields, for
+	// each application whose report contributes no rows, so the report shows it was covered.
+	MarkCleanApps bool
+
+	// IncludeRemediation asks IQ for each violating component's nearest remediating version
+	// (once per component per application) and fills the Recommended Version column.
+	IncludeRemediation bool
+
+	// IncludeReportURL fills the Report URL column with the application's report in the IQ UI,
+	// made absolute against ServerURL when IQ returns a relative link.
+	IncludeReportURL bool
+	// IncludeReasons adds the Reasons column, listing the reasons IQ gives for each row's
+	// conditions (joined in CSV, an array in JSON); off by default to keep the output narrow.
+	IncludeReasons bool
+	// Redact replaces application and organization names in the rows and filenames with
+	// pseudonyms (app-1a2b3c4d, org-...), an HMAC of the name keyed by RedactSalt, so reports
+	// can be shared without them; RedactKeyFile also writes "<report>-redaction-key.csv"
+	// mapping the pseudonyms back. Error reports and logs keep the real names.
+	Redact        bool
+	RedactSalt    string
+	RedactKeyFile bool
+
+	// EnrichCVE looks up each distinct CVE once and adds severity, CVSS and description columns.
+	EnrichCVE bool
+
+	// WriteErrorReport writes a "<report>-errors.csv" next to the report listing each
+	// application that could not be scanned (header only when none failed).
+	WriteErrorReport bool
+	// ErrorReportAccessDenied also lists applications skipped with HTTP 403 in that report.
+	ErrorReportAccessDenied bool
+	// WriteLatest points "latest.<ext>" in OutputDir at each report written to a single file,
+	// a symlink where supported and a copy otherwise; timed-out partial reports are skipped.
+	WriteLatest bool
+
+	// Resume records completed applications under OutputDir/.checkpoint and, when a checkpoint
+	// for the same run parameters exists, skips applications it already holds.
+	Resume bool
+
+	// Uploader, when set, copies the written report files (and error report) to remote
+	// storage after writing, e.g. upload.NewS3 for OUTPUT_S3_URI. It requires file output.
+	Uploader upload.Uploader
+
+	// MetricsPushgatewayURL, when set, receives run metrics after each report.
+	MetricsPushgatewayURL string
+	// NotifyWebhookURL, when set, receives a JSON summary of each run (see notify.Payload),
+	// including failed ones. Delivery is best effort.
+	NotifyWebhookURL string
+
+	// Logger receives service and client logs; the zero value discards them.
+	Logger zerolog.Logger
+
+	// Progress, when set, is called from the aggregation goroutine each time an
+	// application's result arrives, with done counting up to total.
+	Progress ProgressFunc
+	// ProgressLogEvery and ProgressLogInterval log the progress with throughput (a moving
+	// average over recent applications) and estimated time remaining every so many
+	// applications or so often, whichever comes first (0 disables either).
+	ProgressLogEvery    int
+	ProgressLogInterval time.Duration
+}
+
+// ProgressFunc reports how many of the in-scope applications have been processed.
+type ProgressFunc func(done, total int)
+
+// withDefaults fills unset optional fields.
+func (o Options) withDefaults() Options {
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = defaultMaxConcurrency
+	}
+	if o.StreamBuffer <= 0 {
+		o.StreamBuffer = o.MaxConcurrency
+	}
+	if o.OutputFilenameTemplate == "" {
+		o.OutputFilenameTemplate = "{date}_{time}.{format}"
+	}
+	if o.OutputFormat == "" {
+		o.OutputFormat = "csv"
+	}
+	if o.OutputDest == "" {
+		o.OutputDest = OutputDestFile
+	}
+	if o.OnMissingOrg == "" {
+		o.OnMissingOrg = MissingOrgFallback
+	}
+	if o.OnDuplicateApp == "" {
+		o.OnDuplicateApp = DuplicateAppFirst
+	}
+	if o.OutputDir == "" && o.OutputDest == OutputDestFile {
+		o.OutputDir = "reports_output"
+	}
+	return o
+}
+
+// ClientOptions returns the client options implied by opts.
+func (o Options) ClientOptions() []client.Option {
+	basePath := o.APIBasePath
+	if basePath == "" {
+		basePath = client.DefaultAPIBasePath
+	}
+	return []client.Option{
+		client.WithAPIBasePath(basePath),
+		client.WithStrictAPIPath(o.StrictAPIPath),
+		client.WithUserAgent(o.UserAgent),
+		client.WithHTTPTrace(o.HTTPTrace),
+		client.WithRateLimit(o.RequestsPerSecond),
+		client.WithConnPool(o.connPool()),
+		client.WithTLSServerName(o.TLSServerName),
+		client.WithCircuitBreaker(o.BreakerThreshold, o.BreakerWindow, o.BreakerCooldown),
+		client.WithRetries(o.Retries, o.RetryBaseWait, o.RetryMaxWait),
+		client.WithHeaders(o.HTTPHeaders),
+		client.WithRequestObserver(o.RequestObserver),
+		client.WithRecordDir(o.RecordDir),
+		client.WithReplayDir(o.ReplayDir),
+		client.WithReportSelection(client.ReportSelection(o.ReportSelection), o.ReportStageOrder),
+		client.WithIncludeCleanComponents(o.IncludeCleanComponents),
+		client.WithConditionSeparator(o.ConditionSeparator),
+		client.WithPolicyFilter(o.PolicyInclude, o.PolicyExclude),
+		client.WithPolicyPageSize(o.PolicyPageSize),
+	}
+}
+
+// connPool returns the connection pool settings, filling unset ones from MaxConcurrency:
+// every worker (and CVE lookup) may hold a connection to IQ Server, plus some headroom.
+func (o Options) connPool() client.ConnPool {
+	workers := o.MaxConcurrency
+	if workers <= 0 {
+		workers = defaultMaxConcurrency
+	}
+	pool := client.ConnPool{
+		MaxIdleConns:        o.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost: o.HTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     o.HTTPIdleConnTimeout,
+	}
+	if pool.MaxIdleConnsPerHost <= 0 {
+		pool.MaxIdleConnsPerHost = workers + 2
+	}
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = max(100, pool.MaxIdleConnsPerHost)
+	}
+	if pool.IdleConnTimeout <= 0 {
+		pool.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	return pool
+}
+
+// New builds an IQ client and report service from opts alone, with no env dependency.
+// With Options.Instances, one client is built per instance instead.
+func New(opts Options) (*IQReportService, error) {
+	if len(opts.Instances) > 0 {
+		return NewIQReportService(opts, nil)
+	}
+	cl, err := client.NewClient(opts.ServerURL, opts.Username, opts.Password, opts.Logger, opts.ClientOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	return NewIQReportService(opts, cl)
+}
@@ -0,0 +1,87 @@
+// internal/services/orgs.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/rs/zerolog"
+)
+
+// resolveOrgNames adds the names of the organizations of apps that are absent from
+// orgIDToName, with one lookup for all of them and then Options.OnMissingOrg.
+func (s *IQReportService) resolveOrgNames(ctx context.Context, logger zerolog.Logger, apps []client.Application, orgIDToName map[string]string) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, app := range apps {
+		if _, ok := orgIDToName[app.OrganizationID]; !ok && !seen[app.OrganizationID] {
+			seen[app.OrganizationID] = true
+			missing = append(missing, app.OrganizationID)
+		}
+	}
+	if len(missing) > 0 {
+		more, err := s.cl.GetOrganizationsByIDs(ctx, missing)
+		if err != nil && !s.optionalOrgNames(ctx, logger, err) {
+			logger.Error().Err(err).Msg("failed to retrieve organizations")
+			return fmt.Errorf("get organizations: %w", err)
+		}
+		for _, org := range more {
+			orgIDToName[org.ID] = org.Name
+		}
+	}
+	return s.resolveMissingOrgs(ctx, logger, apps, orgIDToName)
+}
+
+// resolveMissingOrgs applies Options.OnMissingOrg to the organizations of apps that are
+// still absent from orgIDToName, adding any names a single-org lookup finds.
+func (s *IQReportService) resolveMissingOrgs(ctx context.Context, logger zerolog.Logger, apps []client.Application, orgIDToName map[string]string) error {
+	var missing []string
+	for _, app := range apps {
+		if _, ok := orgIDToName[app.OrganizationID]; !ok && !slices.Contains(missing, app.OrganizationID) {
+			missing = append(missing, app.OrganizationID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	switch s.opts.OnMissingOrg {
+	case MissingOrgError:
+		logger.Error().Strs("orgIDs", missing).Msg("organization names not found")
+		return fmt.Errorf("%w: %s", ErrOrganizationNotFound, strings.Join(missing, ", "))
+	case MissingOrgFetch:
+		for _, id := range missing {
+			if id == "" {
+				continue
+			}
+			org, err := s.cl.GetOrganization(ctx, id)
+			if client.IsNotFound(err) {
+				logger.Warn().Str("orgID", id).Msg("organization lookup found nothing, using ID as fallback")
+				continue
+			}
+			if err != nil {
+				if s.optionalOrgNames(ctx, logger.With().Str("orgID", id).Logger(), err) {
+					return nil // the lookups are failing; keep the IDs of the rest too
+				}
+				logger.Error().Err(err).Str("orgID", id).Msg("failed to retrieve organization")
+				return fmt.Errorf("get organization %s: %w", id, err)
+			}
+			orgIDToName[id] = org.Name
+		}
+	}
+	return nil
+}
+
+// optionalOrgNames reports whether the run may continue past err, a failed organization
+// lookup: with Options.OrgNamesOptional the affected organizations keep their IDs as names
+// and a warning is logged. A cancelled run never continues.
+func (s *IQReportService) optionalOrgNames(ctx context.Context, logger zerolog.Logger, err error) bool {
+	if !s.opts.OrgNamesOptional || ctx.Err() != nil {
+		return false
+	}
+	logger.Warn().Err(err).Msg("organization lookup failed, using organization IDs as names")
+	return true
+}
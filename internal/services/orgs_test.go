@@ -0,0 +1,71 @@
+// internal/services/orgs_test.go
+package services
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// missingOrgHandlers serves the stub with application apid-1 in org-2, which the
+// run's organization resolution does not find; found reports whether the follow-up
+// single-org lookup of ON_MISSING_ORG=fetch succeeds.
+func missingOrgHandlers(found bool) map[string]http.HandlerFunc {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-2"}},
+		})
+	}
+	lookups := 0
+	handlers["/api/v2/organizations/org-2"] = func(w http.ResponseWriter, r *http.Request) {
+		// The first lookup is the run's batch resolution; later ones come from the fetch policy
+		lookups++
+		if !found || lookups == 1 {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]any{"id": "org-2", "name": "late-org"})
+	}
+	return handlers
+}
+
+func TestGenerateLatestPolicyReport_OnMissingOrg(t *testing.T) {
+	tests := []struct {
+		policy  string
+		found   bool
+		wantOrg string
+		wantErr error
+	}{
+		{policy: MissingOrgFallback, found: true, wantOrg: "org-2"},
+		{policy: MissingOrgFetch, found: true, wantOrg: "late-org"},
+		{policy: MissingOrgFetch, found: false, wantOrg: "org-2"},
+		{policy: MissingOrgError, found: true, wantErr: ErrOrganizationNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.policy, func(t *testing.T) {
+			svc := newTestService(t, startStub(t, missingOrgHandlers(tc.found)), func(o *Options) {
+				o.OnMissingOrg = tc.policy
+				o.OutputColumns = []string{"Organization"}
+			})
+			res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) || res.Path != "" {
+					t.Fatalf("err = %v, path %q; want %v and no report", err, res.Path, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateLatestPolicyReport: %v", err)
+			}
+			b, err := os.ReadFile(res.Path)
+			if err != nil {
+				t.Fatalf("read csv: %v", err)
+			}
+			if want := "Organization\n" + tc.wantOrg + "\n"; string(b) != want {
+				t.Errorf("csv = %q, want %q", b, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// internal/services/policies.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/rs/zerolog"
+)
+
+// ErrUnknownPolicy is returned when Options.PolicyFilterStrict is set and a policy filter
+// pattern matches no policy on the server.
+var ErrUnknownPolicy = errors.New("policy filter matches no policy")
+
+// validatePolicyFilters checks the PolicyInclude and PolicyExclude patterns against the
+// server's policies, so a typo does not silently empty the report. Patterns that match
+// nothing are logged as a warning, or fail the run with Options.PolicyFilterStrict. When
+// the policies cannot be listed the check is skipped with a warning.
+func (s *IQReportService) validatePolicyFilters(ctx context.Context, logger zerolog.Logger) error {
+	patterns := slices.Concat(s.opts.PolicyInclude, s.opts.PolicyExclude)
+	if len(patterns) == 0 {
+		return nil
+	}
+	policies, err := s.cl.GetPolicies(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("get policies: %w", err)
+		}
+		logger.Warn().Err(err).Msg("Could not list policies; policy filters not validated")
+		return nil
+	}
+	unmatched := client.UnmatchedPolicyPatterns(patterns, policies)
+	if len(unmatched) == 0 {
+		return nil
+	}
+	if s.opts.PolicyFilterStrict {
+		logger.Error().Strs("patterns", unmatched).Int("policies", len(policies)).Msg("Policy filters match no policy")
+		return fmt.Errorf("%w: %s", ErrUnknownPolicy, strings.Join(unmatched, ", "))
+	}
+	logger.Warn().Strs("patterns", unmatched).Int("policies", len(policies)).Msg("Policy filters match no policy; check them for typos")
+	return nil
+}
@@ -0,0 +1,56 @@
+// internal/services/policies_test.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGenerateLatestPolicyReport_UnknownPolicyFilter(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/policies"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"policies": []map[string]any{
+			{"id": "p1", "name": "Security-Medium"},
+			{"id": "p2", "name": "License-Banned"},
+		}})
+	}
+	baseURL := startStub(t, handlers)
+
+	t.Run("warns", func(t *testing.T) {
+		var logs bytes.Buffer
+		svc := newTestService(t, baseURL, func(o *Options) {
+			o.Logger = zerolog.New(&logs)
+			o.PolicyInclude = []string{"Security-*", "Securty-High"}
+		})
+		if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); err != nil {
+			t.Fatalf("GenerateLatestPolicyReport: %v", err)
+		}
+		var warned bool
+		for _, line := range strings.Split(logs.String(), "\n") {
+			if strings.Contains(line, `"level":"warn"`) && strings.Contains(line, "match no policy") {
+				warned = true
+				if !strings.Contains(line, "Securty-High") || strings.Contains(line, "Security-*") {
+					t.Errorf("warning should name only the unmatched pattern: %s", line)
+				}
+			}
+		}
+		if !warned {
+			t.Errorf("no warning for unknown policy filter; logs:\n%s", logs.String())
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		svc := newTestService(t, baseURL, func(o *Options) {
+			o.PolicyExclude = []string{"Securty-High"}
+			o.PolicyFilterStrict = true
+		})
+		if _, err := svc.GenerateLatestPolicyReport(rCtx(t)); !errors.Is(err, ErrUnknownPolicy) {
+			t.Errorf("err = %v, want ErrUnknownPolicy", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+// internal/services/redact.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// pseudonymLen is the number of hex digits of a pseudonym, grown only on a collision.
+const pseudonymLen = 8
+
+// Kinds of redacted names, used as pseudonym prefixes.
+const (
+	redactApplication  = "app"
+	redactOrganization = "org"
+)
+
+// redactor replaces application and organization names with pseudonyms such as
+// app-1a2b3c4d: an HMAC-SHA256 of the name keyed by Options.RedactSalt, so a name maps to
+// the same pseudonym in every run with the same salt. It is safe for concurrent use.
+type redactor struct {
+	salt []byte
+
+	mu     sync.Mutex
+	byName map[string]string // kind + "\x00" + name -> pseudonym
+	names  map[string]string // pseudonym -> name
+}
+
+func newRedactor(salt string) *redactor {
+	return &redactor{salt: []byte(salt), byName: make(map[string]string), names: make(map[string]string)}
+}
+
+// pseudonym returns the pseudonym of name as a kind ("app" or "org"); empty stays empty.
+// Should two names share the leading digits, the later one gets more of its hash.
+func (r *redactor) pseudonym(kind, name string) string {
+	if name == "" {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := kind + "\x00" + name
+	if p, ok := r.byName[key]; ok {
+		return p
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(key))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	p := kind + "-" + sum[:pseudonymLen]
+	for n := pseudonymLen + 2; r.names[p] != ""; n += 2 {
+		p = kind + "-" + sum[:n]
+	}
+	r.byName[key], r.names[p] = p, name
+	return p
+}
+
+// key lists every pseudonym handed out with its name, sorted by pseudonym.
+func (r *redactor) key() []report.RedactionKeyRow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows := make([]report.RedactionKeyRow, 0, len(r.names))
+	for p, name := range r.names {
+		kind := "Organization"
+		if strings.HasPrefix(p, redactApplication+"-") {
+			kind = "Application"
+		}
+		rows = append(rows, report.RedactionKeyRow{Kind: kind, Pseudonym: p, Name: name})
+	}
+	slices.SortFunc(rows, func(a, b report.RedactionKeyRow) int { return strings.Compare(a.Pseudonym, b.Pseudonym) })
+	return rows
+}
@@ -0,0 +1,127 @@
+// internal/services/redact_test.go
+package services
+
+import (
+	"encoding/csv"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateLatestPolicyReport_Redact(t *testing.T) {
+	handlers := stubHandlers()
+	handlers["/api/v2/applications"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"applications": []map[string]any{
+				{"id": "aid-1", "publicId": "apid-1", "organizationId": "org-1"},
+				{"id": "aid-2", "publicId": "apid-2", "organizationId": "org-1"},
+			},
+		})
+	}
+	handlers["/api/v2/reports/applications/aid-2"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"stage": "build", "reportHtmlUrl": "https://stub/report/rpt-2"}})
+	}
+	handlers["/api/v2/applications/apid-2/reports/rpt-2/policy"] = handlers["/api/v2/applications/apid-1/reports/rpt-xyz/policy"]
+	baseURL := startStub(t, handlers)
+
+	run := func(salt string) (records [][]string, key string) {
+		t.Helper()
+		svc := newTestService(t, baseURL, func(o *Options) {
+			o.Redact = true
+			o.RedactSalt = salt
+			o.RedactKeyFile = true
+			o.OutputFilenameTemplate = "report_{org}.{format}"
+		})
+		res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+		if err != nil {
+			t.Fatalf("GenerateLatestPolicyReport: %v", err)
+		}
+		b, err := os.ReadFile(res.Path)
+		if err != nil {
+			t.Fatalf("read report: %v", err)
+		}
+		for _, name := range []string{"apid-1", "apid-2", "personal"} {
+			if strings.Contains(string(b), name) || strings.Contains(res.Path, name) {
+				t.Errorf("%q appears in the redacted report %s:\n%s", name, res.Path, b)
+			}
+		}
+		if !strings.Contains(string(b), "Security-Medium") || !strings.Contains(string(b), "comp-A") {
+			t.Errorf("policy and component data should stay intact:\n%s", b)
+		}
+		if records, err = csv.NewReader(strings.NewReader(string(b))).ReadAll(); err != nil {
+			t.Fatalf("parse report: %v", err)
+		}
+		k, err := os.ReadFile(res.RedactionKeyPath)
+		if err != nil {
+			t.Fatalf("read redaction key: %v", err)
+		}
+		return records[1:], string(k)
+	}
+
+	rows, key := run("s3cret")
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	app1, app2, org1, org2 := rows[0][1], rows[1][1], rows[0][2], rows[1][2]
+	if !regexp.MustCompile(`^app-[0-9a-f]{8}$`).MatchString(app1) || !regexp.MustCompile(`^org-[0-9a-f]{8}$`).MatchString(org1) {
+		t.Errorf("pseudonyms = %q, %q; want app-<hash8> and org-<hash8>", app1, org1)
+	}
+	if app1 == app2 {
+		t.Errorf("different applications share pseudonym %q", app1)
+	}
+	if org1 != org2 {
+		t.Errorf("one organization got pseudonyms %q and %q", org1, org2)
+	}
+	names := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(key), "\n")[1:] {
+		f := strings.Split(line, ",")
+		names[f[1]] = f[2]
+	}
+	if got := []string{names[app1], names[app2]}; !slices.Contains(got, "apid-1") || !slices.Contains(got, "apid-2") {
+		t.Errorf("redaction key maps %s, %s to %q:\n%s", app1, app2, got, key)
+	}
+	if names[org1] != "personal" {
+		t.Errorf("redaction key maps %s to %q:\n%s", org1, names[org1], key)
+	}
+
+	// The same salt gives the same pseudonyms in another run; another salt does not. Rows
+	// come out in completion order, so compare the sets of pseudonyms
+	want := pseudonyms(rows)
+	if got := pseudonyms(recordsOf(run("s3cret"))); !slices.Equal(got, want) {
+		t.Errorf("second run pseudonyms = %q, want %q", got, want)
+	}
+	if got := pseudonyms(recordsOf(run("other"))); slices.ContainsFunc(got, func(p string) bool { return slices.Contains(want, p) }) {
+		t.Errorf("a different salt kept pseudonyms: %q vs %q", got, want)
+	}
+}
+
+// pseudonyms returns the sorted, distinct application and organization names of report records.
+func pseudonyms(records [][]string) []string {
+	var out []string
+	for _, rec := range records {
+		out = append(out, rec[1], rec[2])
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// recordsOf returns the report records of run, dropping the redaction key returned alongside them.
+func recordsOf(records [][]string, _ string) [][]string { return records }
+
+func TestRedactor_Collision(t *testing.T) {
+	r := newRedactor("")
+	first := r.pseudonym(redactApplication, "a")
+	// Claim the short form of "b" for another name, as a hash collision would
+	sum := r.pseudonym(redactApplication, "b")
+	r = newRedactor("")
+	r.names[sum] = "someone-else"
+	if got := r.pseudonym(redactApplication, "b"); got == sum || !strings.HasPrefix(got, sum) {
+		t.Errorf("colliding pseudonym = %q, want a longer form of %q", got, sum)
+	}
+	if got := r.pseudonym(redactApplication, "a"); got != first {
+		t.Errorf("pseudonym(a) = %q, want %q", got, first)
+	}
+}
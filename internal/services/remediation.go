@@ -0,0 +1,33 @@
+// internal/services/remediation.go
+package services
+
+import (
+	"context"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// addRemediation fills RecommendedVersion on rows, asking IQ once per distinct component
+// of the application. clientRows and rows are parallel. Lookup failures are logged and
+// leave the column empty rather than failing the application.
+func (s *IQReportService) addRemediation(ctx context.Context, appID, stage string, clientRows []client.ViolationRow, rows []report.Row) {
+	recommended := make(map[string]string)
+	for i, cr := range clientRows {
+		key := cr.ComponentRef.Key()
+		if cr.Clean || key == "" {
+			continue
+		}
+		version, ok := recommended[key]
+		if !ok {
+			versions, err := s.cl.GetComponentRemediation(ctx, appID, stage, cr.ComponentRef)
+			if err != nil {
+				s.logger.Warn().Err(err).Str("appID", appID).Str("component", cr.Component).Msg("remediation lookup failed")
+			} else if len(versions) > 0 {
+				version = versions[0]
+			}
+			recommended[key] = version
+		}
+		rows[i].RecommendedVersion = version
+	}
+}
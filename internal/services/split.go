@@ -0,0 +1,67 @@
+// internal/services/split.go
+package services
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/client"
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/report"
+)
+
+// writeSplitByOrg writes one report per organization of apps, each holding only that
+// organization's rows, and returns the paths in organization name order. Organizations
+// without violations still get a (header-only) file.
+func (s *IQReportService) writeSplitByOrg(now time.Time, apps []client.Application, orgIDToName map[string]string, rows []report.Row, opts report.Options) ([]string, error) {
+	byOrg := make(map[string][]report.Row)
+	orgApps := make(map[string][]client.Application)
+	for _, app := range apps {
+		name, ok := orgIDToName[app.OrganizationID]
+		if !ok {
+			name = app.OrganizationID // same fallback processApp uses for the rows
+		}
+		if s.redactor != nil {
+			name = s.redactor.pseudonym(redactOrganization, name)
+		}
+		if _, seen := byOrg[name]; !seen {
+			byOrg[name] = nil
+		}
+		orgApps[name] = append(orgApps[name], app)
+	}
+	for _, r := range rows {
+		byOrg[r.Organization] = append(byOrg[r.Organization], r)
+	}
+
+	orgs := make([]string, 0, len(byOrg))
+	for org := range byOrg {
+		orgs = append(orgs, org)
+	}
+	slices.Sort(orgs)
+
+	var paths []string
+	owner := make(map[string]string) // filename -> organization, to catch collisions
+	for _, org := range orgs {
+		filename, err := s.reportFilename(now, org, s.formats[0])
+		if err != nil {
+			return paths, err
+		}
+		if other, ok := owner[filename]; ok {
+			return paths, fmt.Errorf("organizations %q and %q both map to file %s", other, org, filename)
+		}
+		owner[filename] = org
+
+		target, err := report.JoinOutputPath(s.opts.OutputDir, filename)
+		if err != nil {
+			return paths, err
+		}
+		orgOpts := opts
+		orgOpts.Metadata = s.metadata(now, orgApps[org])
+		if err := report.WriteFile(target, byOrg[org], orgOpts, s.logger); err != nil {
+			return paths, fmt.Errorf("write %s for organization %s: %w", opts.Format, org, err)
+		}
+		s.logger.Info().Str("path", target).Str("org", org).Int("totalRows", len(byOrg[org])).Msg("Organization report written")
+		paths = append(paths, target)
+	}
+	return paths, nil
+}
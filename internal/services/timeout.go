@@ -0,0 +1,48 @@
+// internal/services/timeout.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRunTimeout matches (via errors.Is) the error returned when the run's deadline fires,
+// whether from Options.RunTimeout or the caller's context.
+var ErrRunTimeout = errors.New("run timeout")
+
+// RunTimeoutError reports a run cut short by its deadline. It tells a too-short deadline
+// apart from server problems, which surface as application failures instead.
+type RunTimeoutError struct {
+	// Timeout is Options.RunTimeout, zero when the caller's context set the deadline.
+	Timeout time.Duration
+	// Incomplete of Total applications had not finished; Total is zero when the deadline
+	// fired before the applications were listed.
+	Incomplete int
+	Total      int
+	// Err is the underlying context error.
+	Err error
+}
+
+func (e *RunTimeoutError) Error() string {
+	limit := "its deadline"
+	if e.Timeout > 0 {
+		limit = fmt.Sprintf("the %s run timeout", e.Timeout)
+	}
+	if e.Total == 0 {
+		return fmt.Sprintf("run exceeded %s before applications were processed", limit)
+	}
+	return fmt.Sprintf("run exceeded %s (%d of %d apps incomplete)", limit, e.Incomplete, e.Total)
+}
+
+// Is makes errors.Is(err, ErrRunTimeout) true.
+func (e *RunTimeoutError) Is(target error) bool { return target == ErrRunTimeout }
+
+// Unwrap returns the context error, so errors.Is(err, context.DeadlineExceeded) still holds.
+func (e *RunTimeoutError) Unwrap() error { return e.Err }
+
+// deadlineExceeded reports whether ctx ended because its deadline passed.
+func deadlineExceeded(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
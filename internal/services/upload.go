@@ -0,0 +1,46 @@
+// internal/services/upload.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anmicius0/iqserver-report-fetch-go/internal/upload"
+)
+
+// uploadFiles copies the written report files (and the error report) with Options.Uploader,
+// returning the remote locations uploaded before any failure.
+func (s *IQReportService) uploadFiles(ctx context.Context, result Result) ([]string, error) {
+	files := result.Paths
+	if result.Path != "" {
+		files = []string{result.Path}
+	}
+	if result.ErrorsPath != "" {
+		files = append(files[:len(files):len(files)], result.ErrorsPath)
+	}
+
+	var uploaded []string
+	for _, path := range files {
+		name := filepath.Base(path)
+		if err := s.uploadFile(ctx, path, name); err != nil {
+			s.logger.Error().Err(err).Str("path", path).Msg("upload failed")
+			return uploaded, fmt.Errorf("upload %s: %w", path, err)
+		}
+		location := s.opts.Uploader.Location(name)
+		s.logger.Info().Str("path", path).Str("location", location).Msg("Uploaded report")
+		uploaded = append(uploaded, location)
+	}
+	return uploaded, nil
+}
+
+// uploadFile uploads the file at path as name, typed by its extension.
+func (s *IQReportService) uploadFile(ctx context.Context, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.opts.Uploader.Upload(ctx, name, f, upload.ContentType(name))
+}
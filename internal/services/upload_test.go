@@ -0,0 +1,70 @@
+// internal/services/upload_test.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// recordingUploader keeps every uploaded object in memory.
+type recordingUploader struct {
+	names        []string
+	bodies       map[string][]byte
+	contentTypes map[string]string
+}
+
+func (u *recordingUploader) Upload(_ context.Context, name string, body io.Reader, contentType string) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if u.bodies == nil {
+		u.bodies, u.contentTypes = make(map[string][]byte), make(map[string]string)
+	}
+	u.names = append(u.names, name)
+	u.bodies[name] = b
+	u.contentTypes[name] = contentType
+	return nil
+}
+
+func (u *recordingUploader) Location(name string) string { return "mem://reports/" + name }
+
+func TestGenerateLatestPolicyReport_UploadsWrittenFiles(t *testing.T) {
+	up := &recordingUploader{}
+	svc := newTestService(t, startStub(t, stubHandlers()), func(o *Options) {
+		o.Uploader = up
+		o.WriteErrorReport = true
+	})
+
+	res, err := svc.GenerateLatestPolicyReport(rCtx(t))
+	if err != nil {
+		t.Fatalf("GenerateLatestPolicyReport: %v", err)
+	}
+	if want := []string{"report.csv", "report-errors.csv"}; !reflect.DeepEqual(up.names, want) {
+		t.Fatalf("uploaded %v, want %v", up.names, want)
+	}
+	written, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !bytes.Equal(up.bodies["report.csv"], written) {
+		t.Errorf("uploaded body differs from the written report:\n%s", up.bodies["report.csv"])
+	}
+	if ct := up.contentTypes["report.csv"]; ct != "text/csv; charset=utf-8" {
+		t.Errorf("content type = %q", ct)
+	}
+	if want := []string{"mem://reports/report.csv", "mem://reports/report-errors.csv"}; !reflect.DeepEqual(res.Uploaded, want) {
+		t.Errorf("Uploaded = %v, want %v", res.Uploaded, want)
+	}
+}
+
+func TestNewIQReportService_UploadRequiresFileOutput(t *testing.T) {
+	_, err := New(Options{ServerURL: "http://iq", OutputDest: OutputDestStdout, Uploader: &recordingUploader{}, Logger: testLogger()})
+	if err == nil {
+		t.Fatal("expected an error for uploading stdout output")
+	}
+}
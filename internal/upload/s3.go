@@ -0,0 +1,80 @@
+// internal/upload/s3.go
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// putObjectAPI is the part of the S3 client S3Uploader uses; tests substitute it.
+type putObjectAPI interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Uploader uploads reports to an S3 bucket under a key prefix.
+type S3Uploader struct {
+	api    putObjectAPI
+	bucket string
+	prefix string
+}
+
+// ParseS3URI splits s3://bucket/prefix into bucket and prefix (without surrounding slashes).
+func ParseS3URI(uri string) (bucket, prefix string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", fmt.Errorf("parse s3 uri %q: %w", uri, err)
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("s3 uri %q must look like s3://bucket/prefix", uri)
+	}
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
+
+// NewS3 returns an uploader for uri (s3://bucket/prefix) using the standard AWS credential
+// chain and region resolution (env vars, shared config, instance or task roles).
+func NewS3(ctx context.Context, uri string) (*S3Uploader, error) {
+	bucket, prefix, err := ParseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	return &S3Uploader{api: s3.NewFromConfig(cfg), bucket: bucket, prefix: prefix}, nil
+}
+
+// key returns the object key for a report file name.
+func (u *S3Uploader) key(name string) string {
+	if u.prefix == "" {
+		return name
+	}
+	return path.Join(u.prefix, name)
+}
+
+// Upload puts body at the prefixed key for name with the given content type.
+func (u *S3Uploader) Upload(ctx context.Context, name string, body io.Reader, contentType string) error {
+	_, err := u.api.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(u.bucket),
+		Key:         aws.String(u.key(name)),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("put %s: %w", u.Location(name), err)
+	}
+	return nil
+}
+
+// Location returns the s3:// URI of the object for name.
+func (u *S3Uploader) Location(name string) string {
+	return "s3://" + u.bucket + "/" + u.key(name)
+}
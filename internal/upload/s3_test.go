@@ -0,0 +1,87 @@
+// internal/upload/s3_test.go
+package upload
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 records the last PutObject call.
+type fakeS3 struct {
+	in   *s3.PutObjectInput
+	body string
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.in, f.body = in, string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3Uploader_Upload(t *testing.T) {
+	api := &fakeS3{}
+	u := &S3Uploader{api: api, bucket: "reports", prefix: "iq/daily"}
+
+	if err := u.Upload(context.Background(), "report.json", strings.NewReader(`[{"no":1}]`), ContentType("report.json")); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if got := aws.ToString(api.in.Bucket); got != "reports" {
+		t.Errorf("bucket = %q", got)
+	}
+	if got := aws.ToString(api.in.Key); got != "iq/daily/report.json" {
+		t.Errorf("key = %q", got)
+	}
+	if got := aws.ToString(api.in.ContentType); got != "application/json" {
+		t.Errorf("content type = %q", got)
+	}
+	if api.body != `[{"no":1}]` {
+		t.Errorf("body = %q", api.body)
+	}
+	if got := u.Location("report.json"); got != "s3://reports/iq/daily/report.json" {
+		t.Errorf("Location = %q", got)
+	}
+}
+
+func TestParseS3URI(t *testing.T) {
+	tests := []struct {
+		uri, bucket, prefix string
+		wantErr             bool
+	}{
+		{uri: "s3://reports", bucket: "reports"},
+		{uri: "s3://reports/iq/", bucket: "reports", prefix: "iq"},
+		{uri: "s3://reports/a/b", bucket: "reports", prefix: "a/b"},
+		{uri: "https://reports/iq", wantErr: true},
+		{uri: "s3:///iq", wantErr: true},
+	}
+	for _, tc := range tests {
+		bucket, prefix, err := ParseS3URI(tc.uri)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseS3URI(%q) error = %v, wantErr %v", tc.uri, err, tc.wantErr)
+			continue
+		}
+		if bucket != tc.bucket || prefix != tc.prefix {
+			t.Errorf("ParseS3URI(%q) = %q, %q; want %q, %q", tc.uri, bucket, prefix, tc.bucket, tc.prefix)
+		}
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for name, want := range map[string]string{
+		"r.csv":     "text/csv; charset=utf-8",
+		"r.HTML":    "text/html; charset=utf-8",
+		"r.json.gz": "application/gzip",
+		"r.bin":     "application/octet-stream",
+	} {
+		if got := ContentType(name); got != want {
+			t.Errorf("ContentType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// internal/upload/upload.go
+package upload
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Uploader stores a written report file in remote storage. Implementations place the
+// object named name under their own destination (bucket, container, prefix).
+type Uploader interface {
+	Upload(ctx context.Context, name string, body io.Reader, contentType string) error
+	// Location describes where name ends up, e.g. s3://bucket/prefix/name, for logs.
+	Location(name string) string
+}
+
+// contentTypes maps report file extensions to their MIME types.
+var contentTypes = map[string]string{
+	".csv":  "text/csv; charset=utf-8",
+	".json": "application/json",
+	".md":   "text/markdown; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".gz":   "application/gzip",
+}
+
+// ContentType returns the MIME type for a report file name by its extension,
+// application/octet-stream when unknown.
+func ContentType(name string) string {
+	if ct, ok := contentTypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return ct
+	}
+	return "application/octet-stream"
+}